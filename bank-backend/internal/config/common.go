@@ -9,10 +9,19 @@ import (
 )
 
 type config struct {
-	Server               serverConfig `yaml:"server" json:"server"`
-	DBConfig             pgConfig     `yaml:"db" json:"db"`
-	Kafka                kafkaConfig  `yaml:"kafka" json:"kafka"`
-	ProcessTransferTopic string       `yaml:"process_transfer_topic" json:"process_transfer_topic"`
+	Server               serverConfig         `yaml:"server" json:"server"`
+	DBConfig             pgConfig             `yaml:"db" json:"db"`
+	Kafka                kafkaConfig          `yaml:"kafka" json:"kafka"`
+	ProcessTransferTopic string               `yaml:"process_transfer_topic" json:"process_transfer_topic"`
+	MoneyRequestTopic    string               `yaml:"money_request_topic" json:"money_request_topic"`
+	SplitBillTopic       string               `yaml:"split_bill_topic" json:"split_bill_topic"`
+	Outbox               outboxConfig         `yaml:"outbox" json:"outbox"`
+	OptimisticLock       optimisticLockConfig `yaml:"optimistic_lock" json:"optimistic_lock"`
+	JWT                  jwtConfig            `yaml:"jwt" json:"jwt"`
+	PinLockout           pinLockoutConfig     `yaml:"pin_lockout" json:"pin_lockout"`
+	OTP                  otpConfig            `yaml:"otp" json:"otp"`
+	StepUp               stepUpConfig         `yaml:"step_up" json:"step_up"`
+	KYC                  kycConfig            `yaml:"kyc" json:"kyc"`
 }
 
 func loadConfigFromReader(r io.Reader, c *config) error {
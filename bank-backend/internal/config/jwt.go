@@ -0,0 +1,32 @@
+package config
+
+import "bank-backend/pkg"
+
+type jwtKeyConfig struct {
+	ID             string `yaml:"id" json:"id"`
+	Algorithm      string `yaml:"algorithm" json:"algorithm"`
+	Secret         string `yaml:"secret" json:"-"`
+	SecretFile     string `yaml:"secret_file" json:"secret_file"`
+	PrivateKeyFile string `yaml:"private_key_file" json:"private_key_file"`
+	PublicKeyFile  string `yaml:"public_key_file" json:"public_key_file"`
+}
+
+type jwtConfig struct {
+	ActiveKeyID string         `yaml:"active_key_id" json:"active_key_id"`
+	Keys        []jwtKeyConfig `yaml:"keys" json:"keys"`
+}
+
+func (j jwtConfig) KeyManager() (*pkg.KeyManager, error) {
+	keys := make([]pkg.KeyConfig, 0, len(j.Keys))
+	for _, k := range j.Keys {
+		keys = append(keys, pkg.KeyConfig{
+			ID:             k.ID,
+			Algorithm:      k.Algorithm,
+			Secret:         k.Secret,
+			SecretFile:     k.SecretFile,
+			PrivateKeyFile: k.PrivateKeyFile,
+			PublicKeyFile:  k.PublicKeyFile,
+		})
+	}
+	return pkg.NewKeyManager(j.ActiveKeyID, keys)
+}
@@ -3,3 +3,8 @@ package config
 type kafkaConfig struct {
 	Broker string `yaml:"broker" json:"broker"`
 }
+
+type outboxConfig struct {
+	BatchSize      int  `yaml:"batch_size" json:"batch_size"`
+	PollIntervalMs uint `yaml:"poll_interval_ms" json:"poll_interval_ms"`
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+
+	"bank-backend/pkg"
+)
+
+type kycConfig struct {
+	// Storage is local, the only store that ships; it keeps documents under StorageDir
+	Storage       string `yaml:"storage" json:"storage"`
+	StorageDir    string `yaml:"storage_dir" json:"storage_dir"`
+	MaxPhotoBytes int64  `yaml:"max_photo_bytes" json:"max_photo_bytes"`
+}
+
+func (k kycConfig) BlobStore() (pkg.BlobStore, error) {
+	switch k.Storage {
+	case "", "local":
+		if k.StorageDir == "" {
+			return nil, fmt.Errorf("kyc: local storage needs storage_dir")
+		}
+		return pkg.LocalBlobStore{Dir: k.StorageDir}, nil
+	default:
+		return nil, fmt.Errorf("kyc: unknown storage %q", k.Storage)
+	}
+}
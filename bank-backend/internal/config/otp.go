@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"bank-backend/module/user/entity"
+	"bank-backend/pkg"
+)
+
+type otpConfig struct {
+	TTLSeconds            uint `yaml:"ttl_seconds" json:"ttl_seconds"`
+	MaxAttempts           int  `yaml:"max_attempts" json:"max_attempts"`
+	ResendCooldownSeconds uint `yaml:"resend_cooldown_seconds" json:"resend_cooldown_seconds"`
+	ResetTokenTTLSeconds  uint `yaml:"reset_token_ttl_seconds" json:"reset_token_ttl_seconds"`
+	// Sender is log or file; file appends to FilePath
+	Sender   string `yaml:"sender" json:"sender"`
+	FilePath string `yaml:"file_path" json:"file_path"`
+}
+
+func (o otpConfig) Policy() entity.OTPPolicy {
+	return entity.OTPPolicy{
+		TTL:            time.Duration(o.TTLSeconds) * time.Second,
+		MaxAttempts:    o.MaxAttempts,
+		ResendCooldown: time.Duration(o.ResendCooldownSeconds) * time.Second,
+		ResetTokenTTL:  time.Duration(o.ResetTokenTTLSeconds) * time.Second,
+	}
+}
+
+func (o otpConfig) OTPSender() (pkg.OTPSender, error) {
+	switch o.Sender {
+	case "", "log":
+		return pkg.LogOTPSender{}, nil
+	case "file":
+		if o.FilePath == "" {
+			return nil, fmt.Errorf("otp: file sender needs file_path")
+		}
+		return &pkg.FileOTPSender{Path: o.FilePath}, nil
+	default:
+		return nil, fmt.Errorf("otp: unknown sender %q", o.Sender)
+	}
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"bank-backend/utils/pgsql"
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -19,6 +21,20 @@ type pgConfig struct {
 	MaxConn  uint   `yaml:"max_conn" json:"max_conn"`
 }
 
+type optimisticLockConfig struct {
+	MaxAttempts int  `yaml:"max_attempts" json:"max_attempts"`
+	BaseDelayMs uint `yaml:"base_delay_ms" json:"base_delay_ms"`
+	MaxDelayMs  uint `yaml:"max_delay_ms" json:"max_delay_ms"`
+}
+
+func (o optimisticLockConfig) RetryPolicy() pgsql.RetryPolicy {
+	return pgsql.RetryPolicy{
+		MaxAttempts: o.MaxAttempts,
+		BaseDelay:   time.Duration(o.BaseDelayMs) * time.Millisecond,
+		MaxDelay:    time.Duration(o.MaxDelayMs) * time.Millisecond,
+	}
+}
+
 func InitializeDatabase(envConfig pgConfig, ctx context.Context) *pgxpool.Pool {
 	connStr := fmt.Sprintf(
 		"user=%s password=%s host=%s port=%d database=%s sslmode=%s pool_min_conns=%d pool_max_conns=%d",
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+
+	"bank-backend/module/user/entity"
+)
+
+type pinLockoutConfig struct {
+	MaxAttempts    int    `yaml:"max_attempts" json:"max_attempts"`
+	IPMaxAttempts  int    `yaml:"ip_max_attempts" json:"ip_max_attempts"`
+	BaseDelayMs    uint   `yaml:"base_delay_ms" json:"base_delay_ms"`
+	MaxDelayMs     uint   `yaml:"max_delay_ms" json:"max_delay_ms"`
+	LockoutMinutes uint   `yaml:"lockout_minutes" json:"lockout_minutes"`
+	Topic          string `yaml:"topic" json:"topic"`
+}
+
+func (p pinLockoutConfig) Policy() entity.PinLockoutPolicy {
+	return entity.PinLockoutPolicy{
+		MaxAttempts:   p.MaxAttempts,
+		IPMaxAttempts: p.IPMaxAttempts,
+		BaseDelay:     time.Duration(p.BaseDelayMs) * time.Millisecond,
+		MaxDelay:      time.Duration(p.MaxDelayMs) * time.Millisecond,
+		Lockout:       time.Duration(p.LockoutMinutes) * time.Minute,
+		Topic:         p.Topic,
+	}
+}
@@ -1,8 +1,16 @@
 package config
 
 import (
+	admincfg "bank-backend/module/admin/config"
+	admin "bank-backend/module/admin/transport"
+	bankclient "bank-backend/module/bank/client"
 	bankcfg "bank-backend/module/bank/config"
 	bank "bank-backend/module/bank/transport"
+	kycclient "bank-backend/module/kyc/client"
+	kyccfg "bank-backend/module/kyc/config"
+	kyc "bank-backend/module/kyc/transport"
+	"bank-backend/module/middleware"
+	userclient "bank-backend/module/user/client"
 	usercfg "bank-backend/module/user/config"
 	user "bank-backend/module/user/transport"
 	"bank-backend/pkg"
@@ -15,6 +23,7 @@ import (
 	"github.com/IBM/sarama"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/expvar"
 )
 
 func StartHTTPServer(ctx context.Context) {
@@ -27,13 +36,39 @@ func StartHTTPServer(ctx context.Context) {
 	pool := InitializeDatabase(cfg.DBConfig, ctx)
 	userCfg.PGx = pool
 	bankCfg.PGx = pool
+	middleware.SetDBPool(pool)
+	userCfg.ConflictRetry = cfg.OptimisticLock.RetryPolicy()
+	bankCfg.ConflictRetry = cfg.OptimisticLock.RetryPolicy()
+	userCfg.PinLockout = cfg.PinLockout.Policy()
 
 	defer pool.Close()
 
+	keyManager, err := cfg.JWT.KeyManager()
+	if err != nil {
+		log.Fatalln("unable to load jwt signing keys", err)
+	}
+	pkg.SetKeyManager(keyManager)
+
+	otpSender, err := cfg.OTP.OTPSender()
+	if err != nil {
+		log.Fatalln("unable to create otp sender", err)
+	}
+	userCfg.OTP = cfg.OTP.Policy()
+	userCfg.OTPSender = otpSender
+	userCfg.TransactionAuthorizationTTL = cfg.StepUp.TokenTTL()
+	bankCfg.StepUpThreshold = cfg.StepUp.ThresholdAmount
+
+	blobStore, err := cfg.KYC.BlobStore()
+	if err != nil {
+		log.Fatalln("unable to create kyc blob store", err)
+	}
+	kycCfg := kyccfg.KycConfig{PGx: pool, BlobStore: blobStore, MaxPhotoBytes: cfg.KYC.MaxPhotoBytes}
+
 	validate := validator.New()
 	validate.RegisterValidation("indonesianphone", utils.ValidateIndonesianPhoneNumber)
 	userCfg.Validate = validate
 	bankCfg.Validate = validate
+	kycCfg.Validate = validate
 
 	producer, err := sarama.NewSyncProducer([]string{"localhost:9092"}, pkg.NewKafkaProducerConfig())
 	if err != nil {
@@ -43,6 +78,10 @@ func StartHTTPServer(ctx context.Context) {
 	defer producer.Close()
 	bankCfg.Producer = &producer
 	bankCfg.ProcessTranferTopic = cfg.ProcessTransferTopic
+	bankCfg.MoneyRequestTopic = cfg.MoneyRequestTopic
+	bankCfg.SplitBillTopic = cfg.SplitBillTopic
+	bankCfg.OutboxBatchSize = cfg.Outbox.BatchSize
+	bankCfg.OutboxPollInterval = time.Duration(cfg.Outbox.PollIntervalMs) * time.Millisecond
 
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
@@ -50,6 +89,9 @@ func StartHTTPServer(ctx context.Context) {
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 	})
 
+	// expose runtime and outbox metrics on /debug/vars, to admins only
+	app.Get("/debug/vars", expvar.New(), middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleAdmin), middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+
 	// Health check route
 
 	app.Get("/health", func(c fiber.Ctx) error {
@@ -59,6 +101,7 @@ func StartHTTPServer(ctx context.Context) {
 
 	userCfg.Fiber = app
 	bankCfg.Fiber = app
+	kycCfg.Fiber = app
 
 	if app == nil {
 		fmt.Println("testes1")
@@ -67,8 +110,23 @@ func StartHTTPServer(ctx context.Context) {
 
 	fmt.Println("testes2")
 
+	users := userclient.NewUserClient(userCfg)
+	bankCfg.Users = users
+	kycClient := kycclient.NewKycClient(kycCfg)
+	userCfg.Kyc = kycClient
+
 	user.NewRest(userCfg)
 	bank.NewRest(bankCfg)
+	kyc.NewRest(kycCfg)
+	admin.NewRest(admincfg.AdminConfig{
+		PGx:      pool,
+		Fiber:    app,
+		Validate: validate,
+		Users:    users,
+		Bank:     bankclient.NewBankClient(bankCfg),
+		Kyc:      kycClient,
+	})
+	bank.StartOutboxRelay(ctx, bankCfg)
 
 	go func() {
 
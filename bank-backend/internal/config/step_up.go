@@ -0,0 +1,14 @@
+package config
+
+import "time"
+
+type stepUpConfig struct {
+	// ThresholdAmount is the amount above which transfers and payments need a re-entered PIN;
+	// zero disables the check
+	ThresholdAmount int  `yaml:"threshold_amount" json:"threshold_amount"`
+	TokenTTLSeconds uint `yaml:"token_ttl_seconds" json:"token_ttl_seconds"`
+}
+
+func (s stepUpConfig) TokenTTL() time.Duration {
+	return time.Duration(s.TokenTTLSeconds) * time.Second
+}
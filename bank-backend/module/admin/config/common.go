@@ -0,0 +1,21 @@
+package config
+
+import (
+	bankclient "bank-backend/module/bank/client"
+	kycclient "bank-backend/module/kyc/client"
+	userclient "bank-backend/module/user/client"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminConfig struct {
+	PGx      *pgxpool.Pool
+	Fiber    *fiber.App
+	Validate *validator.Validate
+	Users    *userclient.UserClient
+	Bank     *bankclient.BankClient
+	Kyc      *kycclient.KycClient
+}
@@ -0,0 +1,143 @@
+package entity
+
+import (
+	bankentity "bank-backend/module/bank/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FreezeScopeDebit = "DEBIT"
+	FreezeScopeAll   = "ALL"
+)
+
+const (
+	AuditActionFreeze          = "FREEZE_ACCOUNT"
+	AuditActionUnfreeze        = "UNFREEZE_ACCOUNT"
+	AuditActionClose           = "CLOSE_ACCOUNT"
+	AuditActionResetPinLockout = "RESET_PIN_LOCKOUT"
+	AuditActionResetIPLockout  = "RESET_IP_PIN_LOCKOUT"
+	AuditActionAdjustBalance   = "ADJUST_BALANCE"
+	AuditActionRefundPayment   = "REFUND_PAYMENT"
+	AuditActionReverseTransfer = "REVERSE_TRANSFER"
+	AuditActionKycStartReview  = "KYC_START_REVIEW"
+	AuditActionKycApprove      = "KYC_APPROVE"
+	AuditActionKycReject       = "KYC_REJECT"
+)
+
+// AuditLog records an action taken by back-office staff on a customer account. UserID is uuid.Nil
+// for actions that concern no single account.
+type AuditLog struct {
+	ID               uuid.UUID
+	AdminPhoneNumber string
+	Action           string
+	UserID           uuid.UUID
+	Reason           string
+	Detail           string
+	CreatedAt        time.Time
+}
+
+type SearchUsersRequest struct {
+	Query string `query:"q" validate:"required,min=3,max=50"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type AccountStatusRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=255"`
+}
+
+// FreezeAccountRequest freezes outgoing money only (DEBIT) or every movement and sign-in (ALL, the
+// default).
+type FreezeAccountRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=255"`
+	Scope  string `json:"scope" validate:"omitempty,oneof=DEBIT ALL"`
+}
+
+// CloseAccountRequest closes the account. A non-zero balance must be paid out to PayoutUserID.
+type CloseAccountRequest struct {
+	Reason       string `json:"reason" validate:"required,min=5,max=255"`
+	PayoutUserID string `json:"payout_user_id" validate:"omitempty,uuid"`
+}
+
+type UserSummaryResponse struct {
+	UserID      string `json:"user_id"`
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Balance     int    `json:"balance"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type UserProfileResponse struct {
+	UserID      string   `json:"user_id"`
+	PhoneNumber string   `json:"phone_number"`
+	FirstName   string   `json:"first_name"`
+	LastName    string   `json:"last_name"`
+	Address     string   `json:"address"`
+	Balance     int      `json:"balance"`
+	Status      string   `json:"status"`
+	KycTier     string   `json:"kyc_tier"`
+	Roles       []string `json:"roles"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+type AccountStatusResponse struct {
+	UserID    string `json:"user_id"`
+	Status    string `json:"status"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type CloseAccountResponse struct {
+	AccountStatusResponse
+	Payout *bankentity.ClosurePayoutResponse `json:"payout,omitempty"`
+}
+
+type AccountStatusHistoryResponse struct {
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	ChangedBy  string `json:"changed_by"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type PinLockoutResponse struct {
+	UserID  string `json:"user_id"`
+	Cleared bool   `json:"cleared"`
+}
+
+type IPPinLockoutRequest struct {
+	IPAddress string `json:"ip_address" validate:"required,ip"`
+	Reason    string `json:"reason" validate:"required,min=5,max=255"`
+}
+
+type IPPinLockoutResponse struct {
+	IPAddress string `json:"ip_address"`
+	Cleared   bool   `json:"cleared"`
+}
+
+type ListKycSubmissionsRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=SUBMITTED UNDER_REVIEW APPROVED REJECTED"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type RejectKycRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=255"`
+}
+
+// KycSubmissionResponse is the reviewer's view of a submission, with the full ID number.
+type KycSubmissionResponse struct {
+	SubmissionID    string `json:"submission_id"`
+	UserID          string `json:"user_id"`
+	IDNumber        string `json:"id_number"`
+	FullName        string `json:"full_name"`
+	DateOfBirth     string `json:"date_of_birth"`
+	Status          string `json:"status"`
+	RejectionReason string `json:"rejection_reason,omitempty"`
+	ReviewedBy      string `json:"reviewed_by,omitempty"`
+	SubmittedAt     string `json:"submitted_at"`
+	ReviewedAt      string `json:"reviewed_at,omitempty"`
+	UpdatedAt       string `json:"updated_at"`
+}
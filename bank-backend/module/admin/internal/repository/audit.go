@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"bank-backend/module/admin/entity"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AdminRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAdminRepository(db *pgxpool.Pool) *AdminRepository {
+	return &AdminRepository{db: db}
+}
+
+func (a *AdminRepository) InsertAuditLog(ctx context.Context, log entity.AuditLog) error {
+	query := `
+		INSERT INTO admin_audit_log (id, admin_phone_number, action, user_id, reason, detail, created_at)
+		VALUES ($1, $2, $3, $4, nullif($5, ''), nullif($6, ''), $7)
+	`
+	var userID *uuid.UUID
+	if log.UserID != uuid.Nil {
+		userID = &log.UserID
+	}
+	_, err := a.db.Exec(ctx, query,
+		log.ID,
+		log.AdminPhoneNumber,
+		log.Action,
+		userID,
+		log.Reason,
+		log.Detail,
+		log.CreatedAt,
+	)
+	return err
+}
@@ -0,0 +1,722 @@
+package usecase
+
+import (
+	"bank-backend/module/admin/entity"
+	"bank-backend/module/admin/internal/repository"
+	"bank-backend/module/admin/utils"
+	bankclient "bank-backend/module/bank/client"
+	bankentity "bank-backend/module/bank/entity"
+	kycclient "bank-backend/module/kyc/client"
+	kycentity "bank-backend/module/kyc/entity"
+	userclient "bank-backend/module/user/client"
+	userentity "bank-backend/module/user/entity"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"bank-backend/utils/response"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+type AdminUseCase interface {
+	SearchUsers(ctx fiber.Ctx, request entity.SearchUsersRequest) ([]entity.UserSummaryResponse, error)
+	GetUser(ctx fiber.Ctx, userID string) (entity.UserProfileResponse, error)
+	UserTransactions(ctx fiber.Ctx, userID string, request bankentity.TransactionHistoryRequest) (*response.ListResponse, error)
+	FreezeAccount(ctx fiber.Ctx, userID string, request entity.FreezeAccountRequest, adminPhoneNumber string) (entity.AccountStatusResponse, error)
+	UnfreezeAccount(ctx fiber.Ctx, userID string, request entity.AccountStatusRequest, adminPhoneNumber string) (entity.AccountStatusResponse, error)
+	CloseAccount(ctx fiber.Ctx, userID string, request entity.CloseAccountRequest, adminPhoneNumber string) (entity.CloseAccountResponse, error)
+	AccountStatusHistory(ctx fiber.Ctx, userID string) ([]entity.AccountStatusHistoryResponse, error)
+	ResetPinLockout(ctx fiber.Ctx, userID string, adminPhoneNumber string) (entity.PinLockoutResponse, error)
+	ResetIPPinLockout(ctx fiber.Ctx, request entity.IPPinLockoutRequest, adminPhoneNumber string) (entity.IPPinLockoutResponse, error)
+	AdjustBalance(ctx fiber.Ctx, userID string, request bankentity.BalanceAdjustmentRequest, adminPhoneNumber string) (bankentity.BalanceAdjustmentResponse, error)
+	RefundPayment(ctx fiber.Ctx, paymentID string, request bankentity.RefundRequest, adminPhoneNumber string) (bankentity.RefundResponse, error)
+	ReverseTransfer(ctx fiber.Ctx, transferID string, request bankentity.TransferReversalRequest, adminPhoneNumber string) (bankentity.TransferReversalResponse, error)
+	ListKycSubmissions(ctx fiber.Ctx, request entity.ListKycSubmissionsRequest) ([]entity.KycSubmissionResponse, error)
+	GetKycSubmission(ctx fiber.Ctx, submissionID string) (entity.KycSubmissionResponse, error)
+	KycPhoto(ctx fiber.Ctx, submissionID string) (io.ReadCloser, string, error)
+	StartKycReview(ctx fiber.Ctx, submissionID string, adminPhoneNumber string) (entity.KycSubmissionResponse, error)
+	ApproveKyc(ctx fiber.Ctx, submissionID string, adminPhoneNumber string) (entity.KycSubmissionResponse, error)
+	RejectKyc(ctx fiber.Ctx, submissionID string, request entity.RejectKycRequest, adminPhoneNumber string) (entity.KycSubmissionResponse, error)
+}
+
+const (
+	defaultSearchUsersLimit    = 20
+	defaultKycSubmissionsLimit = 20
+)
+
+type AdminUC struct {
+	adminRepo repository.AdminRepository
+	users     *userclient.UserClient
+	bank      *bankclient.BankClient
+	kyc       *kycclient.KycClient
+}
+
+func NewAdminUseCase(adminRepo repository.AdminRepository, users *userclient.UserClient, bank *bankclient.BankClient, kyc *kycclient.KycClient) *AdminUC {
+	return &AdminUC{adminRepo: adminRepo, users: users, bank: bank, kyc: kyc}
+}
+
+func (a *AdminUC) SearchUsers(ctx fiber.Ctx, request entity.SearchUsersRequest) ([]entity.UserSummaryResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Search Users
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultSearchUsersLimit
+	}
+
+	users, err := a.users.SearchUsers(ctx.Context(), request.Query, limit)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.UserSummaryDTO(users), nil
+}
+
+func (a *AdminUC) GetUser(ctx fiber.Ctx, userID string) (entity.UserProfileResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch User
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.UserProfileResponse{}, err
+	}
+
+	roles, err := a.users.GetUserRoles(ctx.Context(), user.PhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.UserProfileResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.UserProfileDTO(user, roles), nil
+}
+
+func (a *AdminUC) UserTransactions(ctx fiber.Ctx, userID string, request bankentity.TransactionHistoryRequest) (*response.ListResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Transactions
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	res, err := a.bank.TransactionHistory(ctx, request, user.PhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return res, nil
+}
+
+func (a *AdminUC) FreezeAccount(ctx fiber.Ctx, userID string, request entity.FreezeAccountRequest, adminPhoneNumber string) (entity.AccountStatusResponse, error) {
+	status := userentity.AccountStatusFrozenAll
+	if request.Scope == entity.FreezeScopeDebit {
+		status = userentity.AccountStatusFrozenDebit
+	}
+	return a.changeAccountStatus(ctx, userID, status, entity.AuditActionFreeze, request.Reason, adminPhoneNumber)
+}
+
+func (a *AdminUC) UnfreezeAccount(ctx fiber.Ctx, userID string, request entity.AccountStatusRequest, adminPhoneNumber string) (entity.AccountStatusResponse, error) {
+	return a.changeAccountStatus(ctx, userID, userentity.AccountStatusActive, entity.AuditActionUnfreeze, request.Reason, adminPhoneNumber)
+}
+
+// CloseAccount closes the account for good. A remaining balance is first paid out to the designated
+// account: the account is frozen so its balance cannot change, the balance is moved, and the closing
+// transition then checks the balance is zero.
+func (a *AdminUC) CloseAccount(ctx fiber.Ctx, userID string, request entity.CloseAccountRequest, adminPhoneNumber string) (entity.CloseAccountResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Close Account
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.CloseAccountResponse{}, err
+	}
+
+	res := entity.CloseAccountResponse{}
+	if user.Balance != 0 {
+		if request.PayoutUserID == "" {
+			err = pgsql.ErrAccountBalanceNotZero
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.CloseAccountResponse{}, err
+		}
+		payoutUserID, err := uuid.Parse(request.PayoutUserID)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.CloseAccountResponse{}, pgsql.ErrUserNotFound
+		}
+
+		if user.Status != userentity.AccountStatusFrozenAll {
+			_, err = a.changeStatus(ctx, user.ID, userentity.AccountStatusFrozenAll, "closing: "+request.Reason, adminPhoneNumber)
+			if err != nil {
+				lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+				pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+				return entity.CloseAccountResponse{}, err
+			}
+		}
+
+		payout, err := a.bank.PayoutBalance(ctx, user.ID, payoutUserID)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.CloseAccountResponse{}, err
+		}
+		res.Payout = &payout
+	}
+
+	user, err = a.changeStatus(ctx, user.ID, userentity.AccountStatusClosed, request.Reason, adminPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.CloseAccountResponse{}, err
+	}
+
+	detail := ""
+	if res.Payout != nil {
+		detail = "payout " + res.Payout.TransactionID
+	}
+	err = a.audit(ctx, adminPhoneNumber, entity.AuditActionClose, user.ID, request.Reason, detail)
+	if err != nil {
+		// the status history already records the closure and who did it
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(user),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "account closed", lf)
+
+	res.AccountStatusResponse = utils.AccountStatusDTO(user)
+	return res, nil
+}
+
+func (a *AdminUC) AccountStatusHistory(ctx fiber.Ctx, userID string) ([]entity.AccountStatusHistoryResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Status History
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	changes, err := a.users.ListAccountStatusHistory(ctx.Context(), user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.AccountStatusHistoryDTO(changes), nil
+}
+
+func (a *AdminUC) ResetPinLockout(ctx fiber.Ctx, userID string, adminPhoneNumber string) (entity.PinLockoutResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Reset Lockout
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.PinLockoutResponse{}, err
+	}
+
+	cleared, err := a.users.ResetPinLockout(ctx.Context(), user.PhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.PinLockoutResponse{}, err
+	}
+
+	if cleared {
+		err = a.audit(ctx, adminPhoneNumber, entity.AuditActionResetPinLockout, user.ID, "", "")
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.PinLockoutResponse{}, err
+		}
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return entity.PinLockoutResponse{UserID: user.ID.String(), Cleared: cleared}, nil
+}
+
+func (a *AdminUC) ResetIPPinLockout(ctx fiber.Ctx, request entity.IPPinLockoutRequest, adminPhoneNumber string) (entity.IPPinLockoutResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Reset Lockout
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	cleared, err := a.users.ResetIPPinLockout(ctx.Context(), request.IPAddress)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.IPPinLockoutResponse{}, err
+	}
+
+	if cleared {
+		err = a.audit(ctx, adminPhoneNumber, entity.AuditActionResetIPLockout, uuid.Nil, request.Reason, request.IPAddress)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.IPPinLockoutResponse{}, err
+		}
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return entity.IPPinLockoutResponse{IPAddress: request.IPAddress, Cleared: cleared}, nil
+}
+
+// AdjustBalance posts the adjustment through the bank module, which stores it with the acting admin
+// in the same transaction as the wallet update.
+func (a *AdminUC) AdjustBalance(ctx fiber.Ctx, userID string, request bankentity.BalanceAdjustmentRequest, adminPhoneNumber string) (bankentity.BalanceAdjustmentResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Adjust Balance
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return bankentity.BalanceAdjustmentResponse{}, pgsql.ErrUserNotFound
+	}
+
+	res, err := a.bank.AdjustBalance(ctx, request, id, adminPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return bankentity.BalanceAdjustmentResponse{}, err
+	}
+
+	err = a.audit(ctx, adminPhoneNumber, entity.AuditActionAdjustBalance, id, request.Reason, res.AdjustmentID)
+	if err != nil {
+		// the adjustment itself is already recorded with the admin, so only log the missing audit row
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return res, nil
+}
+
+// RefundPayment refunds the payment through the bank module, which stores the refund with the acting
+// admin in the same transaction as the wallet credit.
+func (a *AdminUC) RefundPayment(ctx fiber.Ctx, paymentID string, request bankentity.RefundRequest, adminPhoneNumber string) (bankentity.RefundResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Refund Payment
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return bankentity.RefundResponse{}, pgsql.ErrPaymentNotFound
+	}
+
+	res, err := a.bank.RefundPayment(ctx, id, request, adminPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return bankentity.RefundResponse{}, err
+	}
+
+	userID, err := uuid.Parse(res.UserID)
+	if err == nil {
+		err = a.audit(ctx, adminPhoneNumber, entity.AuditActionRefundPayment, userID, request.Reason, res.RefundID)
+	}
+	if err != nil {
+		// the refund itself is already recorded with the admin, so only log the missing audit row
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return res, nil
+}
+
+// ReverseTransfer queues the reversal through the bank module, which stores it with the acting admin
+// along with the transfer carrying it out.
+func (a *AdminUC) ReverseTransfer(ctx fiber.Ctx, transferID string, request bankentity.TransferReversalRequest, adminPhoneNumber string) (bankentity.TransferReversalResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Reverse Transfer
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	id, err := uuid.Parse(transferID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return bankentity.TransferReversalResponse{}, pgsql.ErrTransferNotFound
+	}
+
+	res, err := a.bank.ReverseTransfer(ctx, id, request, adminPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return bankentity.TransferReversalResponse{}, err
+	}
+
+	userID, err := uuid.Parse(res.UserID)
+	if err == nil {
+		err = a.audit(ctx, adminPhoneNumber, entity.AuditActionReverseTransfer, userID, request.Reason, res.ReversalID)
+	}
+	if err != nil {
+		// the reversal itself is already recorded with the admin, so only log the missing audit row
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return res, nil
+}
+
+// ListKycSubmissions returns the review queue, oldest submission first.
+func (a *AdminUC) ListKycSubmissions(ctx fiber.Ctx, request entity.ListKycSubmissionsRequest) ([]entity.KycSubmissionResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Submissions
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = defaultKycSubmissionsLimit
+	}
+
+	submissions, err := a.kyc.ListSubmissions(ctx.Context(), request.Status, limit)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.KycSubmissionsDTO(submissions), nil
+}
+
+func (a *AdminUC) GetKycSubmission(ctx fiber.Ctx, submissionID string) (entity.KycSubmissionResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Submission
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	submission, err := a.getKycSubmission(ctx, submissionID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.KycSubmissionDTO(submission), nil
+}
+
+// KycPhoto opens the ID photo of a submission and returns it with its content type. The caller
+// closes it.
+func (a *AdminUC) KycPhoto(ctx fiber.Ctx, submissionID string) (io.ReadCloser, string, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Open Photo
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	submission, err := a.getKycSubmission(ctx, submissionID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, "", err
+	}
+
+	photo, err := a.kyc.OpenPhoto(ctx.Context(), submission)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, "", err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return photo, submission.PhotoContentType, nil
+}
+
+func (a *AdminUC) StartKycReview(ctx fiber.Ctx, submissionID string, adminPhoneNumber string) (entity.KycSubmissionResponse, error) {
+	return a.reviewKyc(ctx, submissionID, kycentity.KycStatusUnderReview, entity.AuditActionKycStartReview, "", adminPhoneNumber)
+}
+
+// ApproveKyc approves a submission under review, which moves the user to the verified tier.
+func (a *AdminUC) ApproveKyc(ctx fiber.Ctx, submissionID string, adminPhoneNumber string) (entity.KycSubmissionResponse, error) {
+	return a.reviewKyc(ctx, submissionID, kycentity.KycStatusApproved, entity.AuditActionKycApprove, "", adminPhoneNumber)
+}
+
+func (a *AdminUC) RejectKyc(ctx fiber.Ctx, submissionID string, request entity.RejectKycRequest, adminPhoneNumber string) (entity.KycSubmissionResponse, error) {
+	return a.reviewKyc(ctx, submissionID, kycentity.KycStatusRejected, entity.AuditActionKycReject, request.Reason, adminPhoneNumber)
+}
+
+func (a *AdminUC) reviewKyc(ctx fiber.Ctx, submissionID string, status string, action string, reason string, adminPhoneNumber string) (entity.KycSubmissionResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Review Submission
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	id, err := uuid.Parse(submissionID)
+	if err != nil {
+		err = pgsql.ErrKycSubmissionNotFound
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+
+	submission, err := a.kyc.ReviewSubmission(ctx.Context(), id, status, reason, adminPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+
+	err = a.audit(ctx, adminPhoneNumber, action, submission.UserID, reason, submission.ID.String())
+	if err != nil {
+		// the submission already records the reviewer
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(submission.ID),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "kyc submission reviewed", lf)
+
+	return utils.KycSubmissionDTO(submission), nil
+}
+
+func (a *AdminUC) changeAccountStatus(ctx fiber.Ctx, userID string, status string, action string, reason string, adminPhoneNumber string) (entity.AccountStatusResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Update Account Status
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.AccountStatusResponse{}, err
+	}
+	if user.Status == status {
+		lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+		return utils.AccountStatusDTO(user), nil
+	}
+
+	user, err = a.changeStatus(ctx, user.ID, status, reason, adminPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.AccountStatusResponse{}, err
+	}
+
+	err = a.audit(ctx, adminPhoneNumber, action, user.ID, reason, "")
+	if err != nil {
+		// the status history already records the change and who made it
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(user),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "account status changed", lf)
+
+	return utils.AccountStatusDTO(user), nil
+}
+
+// changeStatus moves the account to status, recording adminPhoneNumber as the one who changed it.
+func (a *AdminUC) changeStatus(ctx fiber.Ctx, userID uuid.UUID, status string, reason string, adminPhoneNumber string) (userentity.User, error) {
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return userentity.User{}, err
+	}
+	return a.users.ChangeUserStatus(ctx.Context(), userentity.AccountStatusChange{
+		ID:        id,
+		UserID:    userID,
+		ToStatus:  status,
+		Reason:    reason,
+		ChangedBy: adminPhoneNumber,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (a *AdminUC) getUser(ctx fiber.Ctx, userID string) (userentity.User, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return userentity.User{}, pgsql.ErrUserNotFound
+	}
+	return a.users.GetUser(ctx.Context(), id)
+}
+
+func (a *AdminUC) getKycSubmission(ctx fiber.Ctx, submissionID string) (kycentity.Submission, error) {
+	id, err := uuid.Parse(submissionID)
+	if err != nil {
+		return kycentity.Submission{}, pgsql.ErrKycSubmissionNotFound
+	}
+	return a.kyc.GetSubmission(ctx.Context(), id)
+}
+
+func (a *AdminUC) audit(ctx fiber.Ctx, adminPhoneNumber string, action string, userID uuid.UUID, reason string, detail string) error {
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return err
+	}
+	return a.adminRepo.InsertAuditLog(ctx.Context(), entity.AuditLog{
+		ID:               id,
+		AdminPhoneNumber: adminPhoneNumber,
+		Action:           action,
+		UserID:           userID,
+		Reason:           reason,
+		Detail:           detail,
+		CreatedAt:        time.Now(),
+	})
+}
@@ -0,0 +1,880 @@
+package transport
+
+import (
+	"bank-backend/module/admin/config"
+	"bank-backend/module/admin/entity"
+	"bank-backend/module/admin/internal/repository"
+	"bank-backend/module/admin/internal/usecase"
+	bankentity "bank-backend/module/bank/entity"
+	"bank-backend/module/middleware"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+type Rest struct {
+	adminUC  usecase.AdminUseCase
+	validate *validator.Validate
+}
+
+func NewRest(cfg config.AdminConfig) {
+	adminRepo := repository.NewAdminRepository(cfg.PGx)
+	adminUsecase := usecase.NewAdminUseCase(*adminRepo, cfg.Users, cfg.Bank, cfg.Kyc)
+	transport := Rest{adminUC: adminUsecase, validate: cfg.Validate}
+
+	transport.mountAdmin(cfg.Fiber)
+}
+
+// mountAdmin registers the back-office routes. Support staff can read, changes need an admin.
+func (r *Rest) mountAdmin(app *fiber.App) {
+	admin := app.Group("/api/v1/admin", middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleAdmin, pkg.RoleSupport))
+
+	admin.Get("/users", r.SearchUsers, middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+	admin.Get("/users/:user_id", r.GetUser, middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+	admin.Get("/users/:user_id/transactions", r.UserTransactions, middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+	admin.Post("/users/:user_id/freeze", r.FreezeAccount, middleware.PermissionMiddleware(pkg.PermissionUsersWrite))
+	admin.Post("/users/:user_id/unfreeze", r.UnfreezeAccount, middleware.PermissionMiddleware(pkg.PermissionUsersWrite))
+	admin.Post("/users/:user_id/close", r.CloseAccount, middleware.PermissionMiddleware(pkg.PermissionUsersWrite), middleware.IdempotencyMiddleware())
+	admin.Get("/users/:user_id/status-history", r.AccountStatusHistory, middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+	admin.Post("/users/:user_id/pin-lockout/reset", r.ResetPinLockout, middleware.PermissionMiddleware(pkg.PermissionUsersWrite))
+	admin.Post("/pin-lockout/ip/reset", r.ResetIPPinLockout, middleware.PermissionMiddleware(pkg.PermissionUsersWrite))
+	admin.Post("/users/:user_id/adjustments", r.AdjustBalance, middleware.PermissionMiddleware(pkg.PermissionUsersWrite), middleware.IdempotencyMiddleware())
+	admin.Get("/kyc", r.ListKycSubmissions, middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+	admin.Get("/kyc/:submission_id", r.GetKycSubmission, middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+	admin.Get("/kyc/:submission_id/photo", r.KycPhoto, middleware.PermissionMiddleware(pkg.PermissionUsersRead))
+	admin.Post("/kyc/:submission_id/review", r.StartKycReview, middleware.PermissionMiddleware(pkg.PermissionUsersWrite))
+	admin.Post("/kyc/:submission_id/approve", r.ApproveKyc, middleware.PermissionMiddleware(pkg.PermissionUsersWrite))
+	admin.Post("/kyc/:submission_id/reject", r.RejectKyc, middleware.PermissionMiddleware(pkg.PermissionUsersWrite))
+
+	// refunds and reversals live next to the payment and transfer they undo, but only an admin may
+	// issue them
+	app.Post("/api/v1/payments/:payment_id/refund", r.RefundPayment, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleAdmin), middleware.PermissionMiddleware(pkg.PermissionUsersWrite), middleware.IdempotencyMiddleware())
+	app.Post("/api/v1/transfers/:transfer_id/reverse", r.ReverseTransfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleAdmin), middleware.PermissionMiddleware(pkg.PermissionUsersWrite), middleware.IdempotencyMiddleware())
+}
+
+func (r *Rest) SearchUsers(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState1))
+	searchPayload := new(entity.SearchUsersRequest)
+	err := ctx.Bind().Query(searchPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(searchPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(searchPayload),
+	)
+
+	res, err := r.adminUC.SearchUsers(ctx, *searchPayload)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) GetUser(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+
+	res, err := r.adminUC.GetUser(ctx, ctx.Params("user_id"))
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) UserTransactions(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState1))
+	historyPayload := new(bankentity.TransactionHistoryRequest)
+	err := ctx.Bind().Query(historyPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(historyPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(historyPayload),
+	)
+
+	res, err := r.adminUC.UserTransactions(ctx, ctx.Params("user_id"), *historyPayload)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) FreezeAccount(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	freezePayload := new(entity.FreezeAccountRequest)
+	err := ctx.Bind().JSON(freezePayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(freezePayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(freezePayload),
+	)
+
+	res, err := r.adminUC.FreezeAccount(ctx, ctx.Params("user_id"), *freezePayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) UnfreezeAccount(ctx fiber.Ctx) error {
+	return r.accountStatusAction(ctx, r.adminUC.UnfreezeAccount)
+}
+
+func (r *Rest) CloseAccount(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	closePayload := new(entity.CloseAccountRequest)
+	err := ctx.Bind().JSON(closePayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(closePayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(closePayload),
+	)
+
+	res, err := r.adminUC.CloseAccount(ctx, ctx.Params("user_id"), *closePayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) AccountStatusHistory(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+
+	res, err := r.adminUC.AccountStatusHistory(ctx, ctx.Params("user_id"))
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ResetPinLockout(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.adminUC.ResetPinLockout(ctx, ctx.Params("user_id"), adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ResetIPPinLockout(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	lockoutPayload := new(entity.IPPinLockoutRequest)
+	err := ctx.Bind().JSON(lockoutPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(lockoutPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(lockoutPayload),
+	)
+
+	res, err := r.adminUC.ResetIPPinLockout(ctx, *lockoutPayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) AdjustBalance(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	adjustmentPayload := new(bankentity.BalanceAdjustmentRequest)
+	err := ctx.Bind().JSON(adjustmentPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(adjustmentPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(adjustmentPayload),
+	)
+
+	res, err := r.adminUC.AdjustBalance(ctx, ctx.Params("user_id"), *adjustmentPayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) RefundPayment(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	refundPayload := new(bankentity.RefundRequest)
+	err := ctx.Bind().JSON(refundPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(refundPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(refundPayload),
+	)
+
+	res, err := r.adminUC.RefundPayment(ctx, ctx.Params("payment_id"), *refundPayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ReverseTransfer(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	reversalPayload := new(bankentity.TransferReversalRequest)
+	err := ctx.Bind().JSON(reversalPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(reversalPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(reversalPayload),
+	)
+
+	res, err := r.adminUC.ReverseTransfer(ctx, ctx.Params("transfer_id"), *reversalPayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// accountStatusAction decodes the reason for a status change and runs action for the :user_id
+// route parameter.
+func (r *Rest) accountStatusAction(ctx fiber.Ctx, action func(fiber.Ctx, string, entity.AccountStatusRequest, string) (entity.AccountStatusResponse, error)) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	statusPayload := new(entity.AccountStatusRequest)
+	err := ctx.Bind().JSON(statusPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(statusPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(statusPayload),
+	)
+
+	res, err := action(ctx, ctx.Params("user_id"), *statusPayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ListKycSubmissions(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState1))
+	listPayload := new(entity.ListKycSubmissionsRequest)
+	err := ctx.Bind().Query(listPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(listPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(listPayload),
+	)
+
+	res, err := r.adminUC.ListKycSubmissions(ctx, *listPayload)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) GetKycSubmission(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+
+	res, err := r.adminUC.GetKycSubmission(ctx, ctx.Params("submission_id"))
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// KycPhoto streams the ID photo of a submission. It is never cached by intermediaries.
+func (r *Rest) KycPhoto(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+
+	photo, contentType, err := r.adminUC.KycPhoto(ctx, ctx.Params("submission_id"))
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	// the stream is closed once it has been sent
+	return ctx.Status(http.StatusOK).SendStream(photo)
+}
+
+func (r *Rest) StartKycReview(ctx fiber.Ctx) error {
+	return r.kycReviewAction(ctx, r.adminUC.StartKycReview)
+}
+
+func (r *Rest) ApproveKyc(ctx fiber.Ctx) error {
+	return r.kycReviewAction(ctx, r.adminUC.ApproveKyc)
+}
+
+func (r *Rest) RejectKyc(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	rejectPayload := new(entity.RejectKycRequest)
+	err := ctx.Bind().JSON(rejectPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(rejectPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(rejectPayload),
+	)
+
+	res, err := r.adminUC.RejectKyc(ctx, ctx.Params("submission_id"), *rejectPayload, adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// kycReviewAction runs a review step that takes no request body.
+func (r *Rest) kycReviewAction(ctx fiber.Ctx, action func(fiber.Ctx, string, string) (entity.KycSubmissionResponse, error)) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("admin-service"),
+		}
+	)
+	// Retrieve the admin phoneNumber from the context
+	adminPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := action(ctx, ctx.Params("submission_id"), adminPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(adminErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgsql.ErrUserNotFound), errors.Is(err, pgsql.ErrKycSubmissionNotFound), errors.Is(err, pkg.ErrBlobNotFound),
+		errors.Is(err, pgsql.ErrPaymentNotFound), errors.Is(err, pgsql.ErrTransferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pgsql.ErrConcurrentModification), errors.Is(err, pgsql.ErrAccountInvalidTransition),
+		errors.Is(err, pgsql.ErrAccountBalanceNotZero), errors.Is(err, pgsql.ErrKycInvalidTransition),
+		errors.Is(err, pgsql.ErrTransferNotReversible):
+		return http.StatusConflict
+	case errors.Is(err, pgsql.ErrSearchQueryEmpty):
+		return http.StatusBadRequest
+	case errors.Is(err, pgsql.ErrAccountFrozen), errors.Is(err, pgsql.ErrAccountClosed):
+		return http.StatusForbidden
+	case errors.Is(err, pgsql.ErrBalanceNotEnough), errors.Is(err, pgsql.ErrRefundExceedsPayment),
+		errors.Is(err, pgsql.ErrReversalExceedsTransfer):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"bank-backend/module/admin/entity"
+	kycentity "bank-backend/module/kyc/entity"
+	kycutils "bank-backend/module/kyc/utils"
+	userentity "bank-backend/module/user/entity"
+)
+
+func UserSummaryDTO(users []userentity.User) []entity.UserSummaryResponse {
+	response := make([]entity.UserSummaryResponse, 0, len(users))
+	for _, u := range users {
+		response = append(response, entity.UserSummaryResponse{
+			UserID:      u.ID.String(),
+			PhoneNumber: u.PhoneNumber,
+			FirstName:   u.FirstName,
+			LastName:    u.LastName,
+			Balance:     u.Balance,
+			Status:      u.Status,
+			CreatedAt:   u.CreatedAt.String(),
+		})
+	}
+	return response
+}
+
+func UserProfileDTO(user userentity.User, roles []string) entity.UserProfileResponse {
+	response := entity.UserProfileResponse{
+		UserID:      user.ID.String(),
+		PhoneNumber: user.PhoneNumber,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		Address:     user.Address,
+		Balance:     user.Balance,
+		Status:      user.Status,
+		KycTier:     user.KycTier,
+		Roles:       roles,
+		CreatedAt:   user.CreatedAt.String(),
+		UpdatedAt:   user.UpdatedAt.String(),
+	}
+	return response
+}
+
+func AccountStatusDTO(user userentity.User) entity.AccountStatusResponse {
+	response := entity.AccountStatusResponse{
+		UserID:    user.ID.String(),
+		Status:    user.Status,
+		UpdatedAt: user.UpdatedAt.String(),
+	}
+	return response
+}
+
+func AccountStatusHistoryDTO(changes []userentity.AccountStatusChange) []entity.AccountStatusHistoryResponse {
+	response := make([]entity.AccountStatusHistoryResponse, 0, len(changes))
+	for _, c := range changes {
+		response = append(response, entity.AccountStatusHistoryResponse{
+			FromStatus: c.FromStatus,
+			ToStatus:   c.ToStatus,
+			Reason:     c.Reason,
+			ChangedBy:  c.ChangedBy,
+			CreatedAt:  c.CreatedAt.String(),
+		})
+	}
+	return response
+}
+
+func KycSubmissionDTO(submission kycentity.Submission) entity.KycSubmissionResponse {
+	response := entity.KycSubmissionResponse{
+		SubmissionID:    submission.ID.String(),
+		UserID:          submission.UserID.String(),
+		IDNumber:        submission.IDNumber,
+		FullName:        submission.FullName,
+		DateOfBirth:     submission.DateOfBirth.Format(kycutils.DateOfBirthLayout),
+		Status:          submission.Status,
+		RejectionReason: submission.RejectionReason,
+		ReviewedBy:      submission.ReviewedBy,
+		SubmittedAt:     submission.SubmittedAt.String(),
+		UpdatedAt:       submission.UpdatedAt.String(),
+	}
+	if submission.ReviewedAt != nil {
+		response.ReviewedAt = submission.ReviewedAt.String()
+	}
+	return response
+}
+
+func KycSubmissionsDTO(submissions []kycentity.Submission) []entity.KycSubmissionResponse {
+	response := make([]entity.KycSubmissionResponse, 0, len(submissions))
+	for _, s := range submissions {
+		response = append(response, KycSubmissionDTO(s))
+	}
+	return response
+}
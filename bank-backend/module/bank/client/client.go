@@ -0,0 +1,54 @@
+// Package client is the API other modules use to call into the bank module.
+package client
+
+import (
+	"bank-backend/module/bank/config"
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/queue"
+	"bank-backend/module/bank/internal/repository"
+	"bank-backend/module/bank/internal/usecase"
+	"bank-backend/utils/response"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+type BankClient struct {
+	bankUC usecase.BankUseCase
+}
+
+func NewBankClient(cfg config.BankConfig) *BankClient {
+	bankRepo := repository.NewBankRepository(cfg.PGx, cfg.ConflictRetry)
+	processTransferQueue := queue.NewProcessTransferQueue(cfg.ProcessTranferTopic, bankRepo)
+	moneyRequestQueue := queue.NewMoneyRequestQueue(cfg.MoneyRequestTopic, processTransferQueue, bankRepo)
+	splitBillQueue := queue.NewSplitBillQueue(cfg.SplitBillTopic, processTransferQueue, bankRepo)
+	return &BankClient{bankUC: usecase.NewBankUseCase(*bankRepo, processTransferQueue, moneyRequestQueue, splitBillQueue, cfg.Users, cfg.StepUpThreshold)}
+}
+
+// TransactionHistory lists the transactions of the user owning userPhoneNumber.
+func (c *BankClient) TransactionHistory(ctx fiber.Ctx, request entity.TransactionHistoryRequest, userPhoneNumber string) (*response.ListResponse, error) {
+	return c.bankUC.TransactionHistory(ctx, request, userPhoneNumber)
+}
+
+// AdjustBalance posts a manual credit or debit to the user's wallet, recording adminPhoneNumber as
+// the acting admin.
+func (c *BankClient) AdjustBalance(ctx fiber.Ctx, request entity.BalanceAdjustmentRequest, userID uuid.UUID, adminPhoneNumber string) (entity.BalanceAdjustmentResponse, error) {
+	return c.bankUC.AdjustBalance(ctx, request, userID, adminPhoneNumber)
+}
+
+// PayoutBalance moves the whole balance of a FROZEN_ALL wallet that is being closed to payoutUserID.
+func (c *BankClient) PayoutBalance(ctx fiber.Ctx, userID uuid.UUID, payoutUserID uuid.UUID) (entity.ClosurePayoutResponse, error) {
+	return c.bankUC.PayoutBalance(ctx, userID, payoutUserID)
+}
+
+// RefundPayment credits part or all of a payment back to its wallet, recording adminPhoneNumber as
+// the acting admin.
+func (c *BankClient) RefundPayment(ctx fiber.Ctx, paymentID uuid.UUID, request entity.RefundRequest, adminPhoneNumber string) (entity.RefundResponse, error) {
+	return c.bankUC.RefundPayment(ctx, paymentID, request, adminPhoneNumber)
+}
+
+// ReverseTransfer queues a transfer moving part or all of a completed transfer back to its sender,
+// recording adminPhoneNumber as the acting admin.
+func (c *BankClient) ReverseTransfer(ctx fiber.Ctx, transferID uuid.UUID, request entity.TransferReversalRequest, adminPhoneNumber string) (entity.TransferReversalResponse, error) {
+	return c.bankUC.ReverseTransfer(ctx, transferID, request, adminPhoneNumber)
+}
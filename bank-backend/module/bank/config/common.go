@@ -1,6 +1,10 @@
 package config
 
 import (
+	userclient "bank-backend/module/user/client"
+	"bank-backend/utils/pgsql"
+	"time"
+
 	"github.com/IBM/sarama"
 	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -14,4 +18,13 @@ type BankConfig struct {
 	Fiber               *fiber.App
 	Validate            *validator.Validate
 	ProcessTranferTopic string
+	MoneyRequestTopic   string
+	SplitBillTopic      string
+	OutboxBatchSize     int
+	OutboxPollInterval  time.Duration
+	ConflictRetry       pgsql.RetryPolicy
+	// StepUpThreshold is the amount above which transfers and payments need a transaction
+	// authorization token from the user module; zero disables the check
+	StepUpThreshold int
+	Users           *userclient.UserClient
 }
@@ -15,8 +15,39 @@ type User struct {
 	Version     int
 	PhoneNumber string
 	Balance     int
+	// HeldBalance is reserved by active holds, only Balance - HeldBalance can be spent
+	HeldBalance int
 	Address     string
 	Pin         string
+	Status      string
+}
+
+// AvailableBalance is what the wallet can spend, its balance less what active holds reserve.
+func (u User) AvailableBalance() int {
+	return u.Balance - u.HeldBalance
+}
+
+const (
+	AccountStatusActive      = "ACTIVE"
+	AccountStatusFrozenDebit = "FROZEN_DEBIT"
+	AccountStatusFrozenAll   = "FROZEN_ALL"
+	AccountStatusClosed      = "CLOSED"
+)
+
+// AccountAllowsDebit reports whether money may leave a wallet in status.
+func AccountAllowsDebit(status string) bool {
+	return status == AccountStatusActive
+}
+
+// AccountAllowsCredit reports whether money may enter a wallet in status.
+func AccountAllowsCredit(status string) bool {
+	return status == AccountStatusActive || status == AccountStatusFrozenDebit
+}
+
+// AccountAllowsAdjustment reports whether back-office staff may adjust a wallet in status. Frozen
+// wallets can still be corrected, closed ones cannot.
+func AccountAllowsAdjustment(status string) bool {
+	return status != AccountStatusClosed
 }
 
 type Transaction struct {
@@ -28,12 +59,14 @@ type Transaction struct {
 	TransactionType string
 	UserID          uuid.UUID
 	TargetUserID    uuid.UUID
-	CreatedDate     time.Time
-	Version         int
+	// ReferenceID is the transaction a refund pays back
+	ReferenceID *uuid.UUID
+	CreatedDate time.Time
+	Version     int
 }
 
 type TopUpRequest struct {
-	Amount int `json:"amount"validate:"required,min=1,numeric"`
+	Amount int `json:"amount" validate:"required,min=1,numeric"`
 }
 
 type TopUpResponse struct {
@@ -45,9 +78,30 @@ type TopUpResponse struct {
 	CreatedAt     string `json:"created_at"`
 }
 
+// Balance is the wallet balance of a user. HeldBalance is reserved and cannot be spent.
+type Balance struct {
+	UserID      uuid.UUID
+	Balance     int
+	HeldBalance int
+	Status      string
+	UpdatedAt   time.Time
+}
+
+type BalanceResponse struct {
+	Balance          int    `json:"balance"`
+	AvailableBalance int    `json:"available_balance"`
+	HeldBalance      int    `json:"held_balance,omitempty"`
+	Status           string `json:"status"`
+	UpdatedAt        string `json:"updated_at"`
+}
+
 type PaymentRequest struct {
-	Amount  int    `json:"amount"validate:"required,min=1,numeric"`
+	Amount  int    `json:"amount" validate:"required,min=1,numeric"`
 	Remarks string `json:"remarks" validate:"required,max=50"`
+	// PaymentReference is the merchant's order or invoice reference. It can be paid once per wallet
+	// and is required above the step-up threshold, where it is the target of the authorization token.
+	PaymentReference   string `json:"payment_reference" validate:"omitempty,max=50"`
+	AuthorizationToken string `json:"authorization_token" validate:"omitempty,max=100"`
 }
 
 type PaymentResponse struct {
@@ -59,10 +113,31 @@ type PaymentResponse struct {
 	CreatedAt     string `json:"created_at"`
 }
 
+// TransferRequest addresses the recipient by exactly one of TargetUser, TargetPhoneNumber or
+// BeneficiaryID. BankUC.Transfer resolves it and sets TargetUser to the recipient's id.
 type TransferRequest struct {
-	Amount     int    `json:"amount"validate:"required,min=1,numeric"`
-	TargetUser string `json:"target_user"validate:"required"`
-	Remarks    string `json:"remarks" validate:"required,max=50"`
+	Amount            int    `json:"amount" validate:"required,min=1,numeric"`
+	TargetUser        string `json:"target_user" validate:"required_without_all=TargetPhoneNumber BeneficiaryID,excluded_with=TargetPhoneNumber BeneficiaryID,omitempty,uuid"`
+	TargetPhoneNumber string `json:"target_phone_number" validate:"excluded_with=BeneficiaryID,omitempty,indonesianphone"`
+	BeneficiaryID     string `json:"beneficiary_id" validate:"omitempty,uuid"`
+	Remarks           string `json:"remarks" validate:"required,max=50"`
+	// AuthorizationToken is required above the step-up threshold, issued for StepUpTarget as target
+	AuthorizationToken string `json:"authorization_token" validate:"omitempty,max=100"`
+	// ReversalOf is set by BankUC.ReverseTransfer only, for the transfer it reverses
+	ReversalOf string `json:"-"`
+}
+
+// StepUpTarget is the recipient as the client addressed it, which is the target the authorization
+// token has to be issued for.
+func (r TransferRequest) StepUpTarget() string {
+	switch {
+	case r.TargetPhoneNumber != "":
+		return r.TargetPhoneNumber
+	case r.BeneficiaryID != "":
+		return r.BeneficiaryID
+	default:
+		return r.TargetUser
+	}
 }
 
 type TransferResponse struct {
@@ -72,6 +147,7 @@ type TransferResponse struct {
 	TargetTransfer string `json:"target_transfer"`
 	Amount         int    `json:"amount"`
 	Remarks        string `json:"remarks,omitempty"`
+	Status         string `json:"status"`
 	CreatedAt      string `json:"created_at"`
 }
 
@@ -81,5 +157,133 @@ type TransferEvent struct {
 	PhoneNumberOriginUser string `json:"phone_number_origin_user"`
 	TargetUser            string `json:"target_user"`
 	Remarks               string `json:"remarks"`
-	CreatedAt             string `json:"created_at"`
+	// ReversalOf is the transfer this one reverses. bank-worker posts it as a REVERSAL, outside the
+	// transaction limits.
+	ReversalOf string `json:"reversal_of,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+const (
+	AdjustmentTypeCredit = "CREDIT"
+	AdjustmentTypeDebit  = "DEBIT"
+)
+
+type BalanceAdjustmentRequest struct {
+	Type   string `json:"type" validate:"required,oneof=CREDIT DEBIT"`
+	Amount int    `json:"amount" validate:"required,min=1,numeric"`
+	Reason string `json:"reason" validate:"required,min=5,max=100"`
+}
+
+// BalanceAdjustment is a manual credit or debit posted by back-office staff. It is stored alongside
+// the wallet transaction it produced, together with the acting admin.
+type BalanceAdjustment struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	TransactionID    uuid.UUID
+	AdminPhoneNumber string
+	Type             string
+	Amount           int
+	Reason           string
+	CreatedAt        time.Time
+}
+
+type BalanceAdjustmentResponse struct {
+	AdjustmentID  string `json:"adjustment_id"`
+	TransactionID string `json:"transaction_id"`
+	Type          string `json:"type"`
+	Amount        int    `json:"amount"`
+	BalanceBefore int    `json:"balance_before"`
+	BalanceAfter  int    `json:"balance_after"`
+	Reason        string `json:"reason"`
+	AdjustedBy    string `json:"adjusted_by"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type ClosurePayoutResponse struct {
+	TransactionID string `json:"transaction_id,omitempty"`
+	PayoutUser    string `json:"payout_user"`
+	Amount        int    `json:"amount"`
+	CreatedAt     string `json:"created_at,omitempty"`
+}
+
+type TransactionHistoryRequest struct {
+	TransactionType string `query:"transaction_type" validate:"omitempty,oneof=CREDIT DEBIT"`
+	StartDate       string `query:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate         string `query:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	MinAmount       int    `query:"min_amount" validate:"omitempty,min=1"`
+	MaxAmount       int    `query:"max_amount" validate:"omitempty,min=1,gtefield=MinAmount"`
+	Cursor          string `query:"cursor" validate:"omitempty,uuid"`
+	Limit           int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// TransactionFilter is the decoded form of TransactionHistoryRequest used by the repository.
+// Zero values mean the filter is not applied.
+type TransactionFilter struct {
+	UserID          uuid.UUID
+	TransactionType string
+	StartDate       time.Time
+	EndDate         time.Time
+	MinAmount       int
+	MaxAmount       int
+	Cursor          uuid.UUID
+	Limit           int
+}
+
+type TransactionHistoryResponse struct {
+	TransactionID   string `json:"transaction_id"`
+	TransactionType string `json:"transaction_type"`
+	Amount          int    `json:"amount"`
+	BalanceBefore   int    `json:"balance_before"`
+	BalanceAfter    int    `json:"balance_after"`
+	Remarks         string `json:"remarks,omitempty"`
+	ReferenceID     string `json:"reference_id,omitempty"`
+	CreatedAt       string `json:"created_at"`
+}
+
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+const (
+	TransferStatusPending   = "PENDING"
+	TransferStatusCompleted = "COMPLETED"
+	TransferStatusFailed    = "FAILED"
+)
+
+// Transfer tracks the lifecycle of an asynchronous transfer processed by bank-worker.
+type Transfer struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	TargetUserID  uuid.UUID
+	Amount        int
+	Remarks       string
+	Status        string
+	FailureReason string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type TransferStatusResponse struct {
+	TransferID    string `json:"transfer_id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	Amount        int    `json:"amount"`
+	TargetUser    string `json:"target_user"`
+	Remarks       string `json:"remarks,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// OutboxMessage is a Kafka message persisted in the same database transaction as the state change it
+// announces. The outbox relay publishes it afterwards.
+type OutboxMessage struct {
+	ID          uuid.UUID
+	AggregateID uuid.UUID
+	Topic       string
+	Key         string
+	Payload     string
+	Attempts    int
+	CreatedAt   time.Time
 }
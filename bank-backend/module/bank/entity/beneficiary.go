@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Beneficiary is a recipient a user saved under a nickname. The recipient's phone number and name
+// are read from "user" so they stay current.
+type Beneficiary struct {
+	ID                uuid.UUID
+	UserID            uuid.UUID
+	BeneficiaryUserID uuid.UUID
+	Nickname          string
+	PhoneNumber       string
+	FirstName         string
+	LastName          string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+type BeneficiaryRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,indonesianphone"`
+	Nickname    string `json:"nickname" validate:"required,max=30"`
+}
+
+type UpdateBeneficiaryRequest struct {
+	Nickname string `json:"nickname" validate:"required,max=30"`
+}
+
+type BeneficiaryResponse struct {
+	BeneficiaryID string `json:"beneficiary_id"`
+	Nickname      string `json:"nickname"`
+	PhoneNumber   string `json:"phone_number"`
+	RecipientName string `json:"recipient_name"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type RecipientPreviewRequest struct {
+	PhoneNumber string `query:"phone_number" validate:"required,indonesianphone"`
+}
+
+type RecipientPreviewResponse struct {
+	PhoneNumber   string `json:"phone_number"`
+	RecipientName string `json:"recipient_name"`
+}
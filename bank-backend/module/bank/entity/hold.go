@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HoldStatusActive   = "ACTIVE"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusVoided   = "VOIDED"
+	// HoldStatusExpired is set by bank-worker once ExpiresAt has passed
+	HoldStatusExpired = "EXPIRED"
+)
+
+// Hold reserves Amount of a wallet. While ACTIVE the amount counts in the wallet's held balance, so it
+// lowers the available balance but not the ledger balance. Capturing it debits CapturedAmount as a
+// payment, TransactionID, and frees the rest. Voiding or expiring it frees everything.
+type Hold struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Amount         int
+	CapturedAmount int
+	Remarks        string
+	Status         string
+	TransactionID  *uuid.UUID
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type HoldRequest struct {
+	Amount  int    `json:"amount" validate:"required,min=1,numeric"`
+	Remarks string `json:"remarks" validate:"required,max=50"`
+	// ExpiresAt defaults to 7 days from now
+	ExpiresAt *time.Time `json:"expires_at"`
+	// AuthorizationToken is required above the step-up threshold, issued for the remarks as target
+	AuthorizationToken string `json:"authorization_token" validate:"omitempty,max=100"`
+}
+
+// CaptureHoldRequest captures Amount of a hold, or all of it when Amount is zero.
+type CaptureHoldRequest struct {
+	Amount int `json:"amount" validate:"omitempty,min=1"`
+}
+
+type HoldListRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=ACTIVE CAPTURED VOIDED EXPIRED"`
+	Cursor string `query:"cursor" validate:"omitempty,uuid"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// HoldFilter is the decoded form of HoldListRequest used by the repository.
+type HoldFilter struct {
+	UserID uuid.UUID
+	Status string
+	Cursor uuid.UUID
+	Limit  int
+}
+
+type HoldResponse struct {
+	HoldID         string `json:"hold_id"`
+	Amount         int    `json:"amount"`
+	CapturedAmount int    `json:"captured_amount"`
+	Remarks        string `json:"remarks"`
+	Status         string `json:"status"`
+	PaymentID      string `json:"payment_id,omitempty"`
+	ExpiresAt      string `json:"expires_at"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
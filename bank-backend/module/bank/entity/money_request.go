@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MoneyRequestStatusPending  = "PENDING"
+	MoneyRequestStatusAccepted = "ACCEPTED"
+	MoneyRequestStatusDeclined = "DECLINED"
+	// MoneyRequestStatusExpired is set by bank-worker once ExpiresAt has passed
+	MoneyRequestStatusExpired = "EXPIRED"
+)
+
+const (
+	MoneyRequestEventCreated  = "MONEY_REQUEST_CREATED"
+	MoneyRequestEventAccepted = "MONEY_REQUEST_ACCEPTED"
+	MoneyRequestEventDeclined = "MONEY_REQUEST_DECLINED"
+)
+
+const (
+	MoneyRequestDirectionIncoming = "INCOMING"
+	MoneyRequestDirectionOutgoing = "OUTGOING"
+)
+
+// MoneyRequest asks the payer to transfer Amount to the requester. Accepting it creates a transfer
+// processed by bank-worker, whose id is kept in TransferID.
+type MoneyRequest struct {
+	ID                   uuid.UUID
+	RequesterUserID      uuid.UUID
+	RequesterPhoneNumber string
+	RequesterFirstName   string
+	RequesterLastName    string
+	PayerUserID          uuid.UUID
+	PayerPhoneNumber     string
+	PayerFirstName       string
+	PayerLastName        string
+	Amount               int
+	Remarks              string
+	Status               string
+	TransferID           *uuid.UUID
+	ExpiresAt            time.Time
+	RespondedAt          *time.Time
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+type MoneyRequestRequest struct {
+	PayerPhoneNumber string    `json:"payer_phone_number" validate:"required,indonesianphone"`
+	Amount           int       `json:"amount" validate:"required,min=1,numeric"`
+	Remarks          string    `json:"remarks" validate:"required,max=50"`
+	ExpiresAt        time.Time `json:"expires_at" validate:"required"`
+}
+
+type AcceptMoneyRequestRequest struct {
+	// AuthorizationToken is required above the step-up threshold, issued for the money request id as target
+	AuthorizationToken string `json:"authorization_token" validate:"omitempty,max=100"`
+}
+
+type MoneyRequestListRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=PENDING ACCEPTED DECLINED EXPIRED"`
+	Cursor string `query:"cursor" validate:"omitempty,uuid"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// MoneyRequestFilter is the decoded form of MoneyRequestListRequest used by the repository. Direction
+// tells whether UserID is the payer or the requester.
+type MoneyRequestFilter struct {
+	UserID    uuid.UUID
+	Direction string
+	Status    string
+	Cursor    uuid.UUID
+	Limit     int
+}
+
+type MoneyRequestResponse struct {
+	MoneyRequestID       string `json:"money_request_id"`
+	RequesterPhoneNumber string `json:"requester_phone_number"`
+	RequesterName        string `json:"requester_name"`
+	PayerPhoneNumber     string `json:"payer_phone_number"`
+	PayerName            string `json:"payer_name"`
+	Amount               int    `json:"amount"`
+	Remarks              string `json:"remarks"`
+	Status               string `json:"status"`
+	TransferID           string `json:"transfer_id,omitempty"`
+	ExpiresAt            string `json:"expires_at"`
+	RespondedAt          string `json:"responded_at,omitempty"`
+	CreatedAt            string `json:"created_at"`
+}
+
+// MoneyRequestEvent is published on every state change of a money request.
+type MoneyRequestEvent struct {
+	EventType     string `json:"event_type"`
+	MoneyRequest  string `json:"money_request_id"`
+	RequesterUser string `json:"requester_user"`
+	PayerUser     string `json:"payer_user"`
+	Amount        int    `json:"amount"`
+	Remarks       string `json:"remarks"`
+	Status        string `json:"status"`
+	TransferID    string `json:"transfer_id,omitempty"`
+	ExpiresAt     string `json:"expires_at"`
+	CreatedAt     string `json:"created_at"`
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payment is a DEBIT transaction posted by a payment, with what has been refunded of it so far.
+type Payment struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	PhoneNumber    string
+	Amount         int
+	RefundedAmount int
+	Remarks        string
+	CreatedAt      time.Time
+}
+
+// RefundRequest refunds Amount of a payment, or everything not refunded yet when Amount is zero.
+type RefundRequest struct {
+	Amount int    `json:"amount" validate:"omitempty,min=1"`
+	Reason string `json:"reason" validate:"required,min=5,max=100"`
+}
+
+// PaymentRefund pays part or all of a payment back to its wallet from the payment settlement
+// account. TransactionID is the CREDIT transaction it posted, referencing PaymentID.
+type PaymentRefund struct {
+	ID               uuid.UUID
+	PaymentID        uuid.UUID
+	TransactionID    uuid.UUID
+	UserID           uuid.UUID
+	Amount           int
+	Reason           string
+	AdminPhoneNumber string
+	CreatedAt        time.Time
+}
+
+type RefundResponse struct {
+	RefundID       string `json:"refund_id"`
+	PaymentID      string `json:"payment_id"`
+	TransactionID  string `json:"transaction_id"`
+	UserID         string `json:"user_id"`
+	Amount         int    `json:"amount"`
+	PaymentAmount  int    `json:"payment_amount"`
+	RefundedAmount int    `json:"refunded_amount"`
+	BalanceBefore  int    `json:"balance_before"`
+	BalanceAfter   int    `json:"balance_after"`
+	Reason         string `json:"reason"`
+	RefundedBy     string `json:"refunded_by"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// TransferReversalRequest reverses Amount of a transfer, or everything not reversed yet when Amount
+// is zero.
+type TransferReversalRequest struct {
+	Amount int    `json:"amount" validate:"omitempty,min=1"`
+	Reason string `json:"reason" validate:"required,min=5,max=100"`
+}
+
+// TransferReversal is a transfer moving funds of a completed transfer back from its recipient,
+// UserID, to its sender, TargetUserID. ID is the id of that transfer, run by bank-worker like any
+// other.
+type TransferReversal struct {
+	ID               uuid.UUID
+	TransferID       uuid.UUID
+	UserID           uuid.UUID
+	TargetUserID     uuid.UUID
+	Amount           int
+	Reason           string
+	AdminPhoneNumber string
+	CreatedAt        time.Time
+}
+
+type TransferReversalResponse struct {
+	ReversalID     string `json:"reversal_id"`
+	TransferID     string `json:"transfer_id"`
+	UserID         string `json:"user_id"`
+	Amount         int    `json:"amount"`
+	TransferAmount int    `json:"transfer_amount"`
+	ReversedAmount int    `json:"reversed_amount"`
+	Status         string `json:"status"`
+	Reason         string `json:"reason"`
+	ReversedBy     string `json:"reversed_by"`
+	CreatedAt      string `json:"created_at"`
+}
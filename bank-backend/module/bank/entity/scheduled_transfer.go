@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScheduleFrequencyOnce    = "ONCE"
+	ScheduleFrequencyDaily   = "DAILY"
+	ScheduleFrequencyWeekly  = "WEEKLY"
+	ScheduleFrequencyMonthly = "MONTHLY"
+)
+
+const (
+	ScheduleStatusActive = "ACTIVE"
+	ScheduleStatusPaused = "PAUSED"
+	// ScheduleStatusCompleted means there are no occurrences left; failed runs may still be retried
+	ScheduleStatusCompleted = "COMPLETED"
+	ScheduleStatusCancelled = "CANCELLED"
+)
+
+// ScheduledTransfer is a transfer bank-worker emits at NextRunAt and then on every recurrence.
+type ScheduledTransfer struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	TargetUserID uuid.UUID
+	Amount       int
+	Remarks      string
+	Frequency    string
+	DayOfMonth   int
+	StartAt      time.Time
+	EndAt        *time.Time
+	NextRunAt    *time.Time
+	LastRunAt    *time.Time
+	Status       string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// ScheduledTransferRun is one attempt at an occurrence of a schedule and the transfer it created.
+type ScheduledTransferRun struct {
+	ID            uuid.UUID
+	TransferID    uuid.UUID
+	ScheduledFor  time.Time
+	Attempt       int
+	Status        string
+	FailureReason string
+	NextRetryAt   *time.Time
+	CreatedAt     time.Time
+}
+
+type ScheduledTransferRequest struct {
+	Amount     int        `json:"amount" validate:"required,min=1,numeric"`
+	TargetUser string     `json:"target_user" validate:"required,uuid"`
+	Remarks    string     `json:"remarks" validate:"required,max=50"`
+	Frequency  string     `json:"frequency" validate:"required,oneof=ONCE DAILY WEEKLY MONTHLY"`
+	DayOfMonth int        `json:"day_of_month" validate:"required_if=Frequency MONTHLY,omitempty,min=1,max=31"`
+	StartAt    time.Time  `json:"start_at" validate:"required"`
+	EndAt      *time.Time `json:"end_at" validate:"omitempty,gtfield=StartAt"`
+	// AuthorizationToken is required above the step-up threshold when a schedule is created or its
+	// amount or target changes, issued for operation TRANSFER with the target user as target
+	AuthorizationToken string `json:"authorization_token" validate:"omitempty,max=100"`
+}
+
+type ScheduledTransferResponse struct {
+	ScheduledTransferID string                         `json:"scheduled_transfer_id"`
+	TargetUser          string                         `json:"target_user"`
+	Amount              int                            `json:"amount"`
+	Remarks             string                         `json:"remarks"`
+	Frequency           string                         `json:"frequency"`
+	DayOfMonth          int                            `json:"day_of_month,omitempty"`
+	StartAt             string                         `json:"start_at"`
+	EndAt               string                         `json:"end_at,omitempty"`
+	NextRunAt           string                         `json:"next_run_at,omitempty"`
+	LastRunAt           string                         `json:"last_run_at,omitempty"`
+	Status              string                         `json:"status"`
+	CreatedAt           string                         `json:"created_at"`
+	UpdatedAt           string                         `json:"updated_at"`
+	Runs                []ScheduledTransferRunResponse `json:"runs,omitempty"`
+}
+
+type ScheduledTransferRunResponse struct {
+	RunID         string `json:"run_id"`
+	TransferID    string `json:"transfer_id"`
+	ScheduledFor  string `json:"scheduled_for"`
+	Attempt       int    `json:"attempt"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	NextRetryAt   string `json:"next_retry_at,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
@@ -0,0 +1,144 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SplitTypeEqual  = "EQUAL"
+	SplitTypeCustom = "CUSTOM"
+)
+
+const (
+	SplitBillStatusOpen = "OPEN"
+	// SplitBillStatusSettled is set by bank-worker once every share is paid
+	SplitBillStatusSettled = "SETTLED"
+)
+
+const (
+	SplitShareStatusPending = "PENDING"
+	// SplitShareStatusProcessing means the transfer paying the share is waiting for bank-worker
+	SplitShareStatusProcessing = "PROCESSING"
+	SplitShareStatusPaid       = "PAID"
+)
+
+const (
+	SplitBillEventCreated  = "SPLIT_BILL_CREATED"
+	SplitBillEventReminder = "SPLIT_BILL_REMINDER"
+)
+
+// SplitBill is a total the organizer paid and splits between participants. Each participant owes a
+// share, paid by a transfer to the organizer.
+type SplitBill struct {
+	ID                   uuid.UUID
+	OrganizerUserID      uuid.UUID
+	OrganizerPhoneNumber string
+	OrganizerFirstName   string
+	OrganizerLastName    string
+	Title                string
+	TotalAmount          int
+	SplitType            string
+	Status               string
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	SettledAt            *time.Time
+	Participants         []SplitBillParticipant
+}
+
+// SplitBillParticipant is the share one participant owes. The organizer's own share, when they list
+// themselves, is PAID from the start.
+type SplitBillParticipant struct {
+	ID                uuid.UUID
+	SplitBillID       uuid.UUID
+	UserID            uuid.UUID
+	PhoneNumber       string
+	FirstName         string
+	LastName          string
+	ShareAmount       int
+	Status            string
+	TransferID        *uuid.UUID
+	LastFailureReason string
+	LastRemindedAt    *time.Time
+	PaidAt            *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+type SplitBillRequest struct {
+	Title        string                        `json:"title" validate:"required,max=50"`
+	TotalAmount  int                           `json:"total_amount" validate:"required,min=1,numeric"`
+	SplitType    string                        `json:"split_type" validate:"required,oneof=EQUAL CUSTOM"`
+	Participants []SplitBillParticipantRequest `json:"participants" validate:"required,min=1,max=20,dive"`
+}
+
+type SplitBillParticipantRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,indonesianphone"`
+	// ShareAmount is set for CUSTOM splits only
+	ShareAmount int `json:"share_amount" validate:"omitempty,min=1"`
+}
+
+type PaySplitBillRequest struct {
+	// AuthorizationToken is required above the step-up threshold, issued for the split bill id as target
+	AuthorizationToken string `json:"authorization_token" validate:"omitempty,max=100"`
+}
+
+type SplitBillListRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=OPEN SETTLED"`
+	Cursor string `query:"cursor" validate:"omitempty,uuid"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// SplitBillFilter is the decoded form of SplitBillListRequest used by the repository. It matches the
+// bills UserID organizes or takes part in.
+type SplitBillFilter struct {
+	UserID uuid.UUID
+	Status string
+	Cursor uuid.UUID
+	Limit  int
+}
+
+type SplitBillResponse struct {
+	SplitBillID          string                         `json:"split_bill_id"`
+	Title                string                         `json:"title"`
+	OrganizerPhoneNumber string                         `json:"organizer_phone_number"`
+	OrganizerName        string                         `json:"organizer_name"`
+	TotalAmount          int                            `json:"total_amount"`
+	PaidAmount           int                            `json:"paid_amount"`
+	SplitType            string                         `json:"split_type"`
+	Status               string                         `json:"status"`
+	Participants         []SplitBillParticipantResponse `json:"participants"`
+	CreatedAt            string                         `json:"created_at"`
+	SettledAt            string                         `json:"settled_at,omitempty"`
+}
+
+type SplitBillParticipantResponse struct {
+	PhoneNumber       string `json:"phone_number"`
+	Name              string `json:"name"`
+	ShareAmount       int    `json:"share_amount"`
+	Status            string `json:"status"`
+	TransferID        string `json:"transfer_id,omitempty"`
+	LastFailureReason string `json:"last_failure_reason,omitempty"`
+	LastRemindedAt    string `json:"last_reminded_at,omitempty"`
+	PaidAt            string `json:"paid_at,omitempty"`
+}
+
+type SplitBillReminderResponse struct {
+	Reminded []string `json:"reminded"`
+}
+
+// SplitBillEvent is published when a bill is created, when a participant is reminded and, by
+// bank-worker, when the bill is settled. Participant fields are set for the events about one share.
+type SplitBillEvent struct {
+	EventType              string `json:"event_type"`
+	SplitBill              string `json:"split_bill_id"`
+	Title                  string `json:"title"`
+	OrganizerUser          string `json:"organizer_user"`
+	OrganizerPhoneNumber   string `json:"organizer_phone_number"`
+	TotalAmount            int    `json:"total_amount"`
+	ParticipantUser        string `json:"participant_user,omitempty"`
+	ParticipantPhoneNumber string `json:"participant_phone_number,omitempty"`
+	ShareAmount            int    `json:"share_amount,omitempty"`
+	CreatedAt              string `json:"created_at"`
+}
@@ -0,0 +1,203 @@
+// Package ledger implements the double-entry ledger behind wallet balances. Every money movement is a
+// journal entry whose postings sum to zero; account balances are a cache of their postings.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"bank-backend/pkg"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	AccountTypeWallet = "WALLET"
+	AccountTypeSystem = "SYSTEM"
+
+	TopUpClearingAccount     = "SYSTEM:TOPUP_CLEARING"
+	PaymentSettlementAccount = "SYSTEM:PAYMENT_SETTLEMENT"
+	ManualAdjustmentAccount  = "SYSTEM:MANUAL_ADJUSTMENT"
+
+	EntryTypeTopUp      = "TOPUP"
+	EntryTypePayment    = "PAYMENT"
+	EntryTypeTransfer   = "TRANSFER"
+	EntryTypeAdjustment = "ADJUSTMENT"
+	// EntryTypeClosurePayout pays the remaining balance of a closing wallet out to another wallet
+	EntryTypeClosurePayout = "CLOSURE_PAYOUT"
+	// EntryTypeRefund pays a payment back from the payment settlement account
+	EntryTypeRefund = "REFUND"
+	// EntryTypeReversal moves the funds of a transfer back, posted by bank-worker
+	EntryTypeReversal = "REVERSAL"
+)
+
+var (
+	ErrUnbalancedEntry = errors.New("ledger: journal entry is not balanced")
+	ErrAccountNotFound = errors.New("ledger: account not found")
+)
+
+// Posting moves Amount into the account, a negative Amount moves it out.
+type Posting struct {
+	AccountID uuid.UUID
+	Amount    int
+}
+
+type Entry struct {
+	ID          uuid.UUID
+	ReferenceID uuid.UUID
+	EntryType   string
+	Description string
+	CreatedAt   time.Time
+	Postings    []Posting
+}
+
+type PostingResult struct {
+	AccountID     uuid.UUID
+	BalanceBefore int
+	BalanceAfter  int
+}
+
+// WalletAccount returns the wallet account of userID, opening it on first use.
+func WalletAccount(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (uuid.UUID, error) {
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	insertWallet := `
+		INSERT INTO ledger_account (id, code, account_type, user_id, balance, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, 1, $5, $5) ON CONFLICT (user_id) DO NOTHING
+	`
+	selectWallet := `SELECT id FROM ledger_account WHERE user_id = $1`
+
+	_, err = tx.Exec(ctx, insertWallet, id, fmt.Sprintf("WALLET:%s", userID), AccountTypeWallet, userID, time.Now())
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	var accountID uuid.UUID
+	err = tx.QueryRow(ctx, selectWallet, userID).Scan(&accountID)
+	return accountID, err
+}
+
+// SystemAccount returns the id of the system account identified by code.
+func SystemAccount(ctx context.Context, tx pgx.Tx, code string) (uuid.UUID, error) {
+	query := `SELECT id FROM ledger_account WHERE code = $1 AND account_type = $2`
+
+	var accountID uuid.UUID
+	err := tx.QueryRow(ctx, query, code, AccountTypeSystem).Scan(&accountID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = ErrAccountNotFound
+		}
+		return uuid.UUID{}, err
+	}
+	return accountID, nil
+}
+
+// Move posts a two-legged entry taking amount out of from and into to. It returns the resulting
+// balances of both accounts.
+func Move(ctx context.Context, tx pgx.Tx, entryType string, referenceID uuid.UUID, description string, from uuid.UUID, to uuid.UUID, amount int) (PostingResult, PostingResult, error) {
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return PostingResult{}, PostingResult{}, err
+	}
+
+	results, err := Post(ctx, tx, Entry{
+		ID:          id,
+		ReferenceID: referenceID,
+		EntryType:   entryType,
+		Description: description,
+		CreatedAt:   time.Now(),
+		Postings: []Posting{
+			{AccountID: from, Amount: -amount},
+			{AccountID: to, Amount: amount},
+		},
+	})
+	if err != nil {
+		return PostingResult{}, PostingResult{}, err
+	}
+	return results[0], results[1], nil
+}
+
+// Post writes the journal entry and its postings and updates the cached account balances. Wallet
+// accounts may not go below zero. Accounts are updated in id order so concurrent entries touching the
+// same accounts cannot deadlock. Results are returned in the order of entry.Postings.
+func Post(ctx context.Context, tx pgx.Tx, entry Entry) ([]PostingResult, error) {
+	if len(entry.Postings) < 2 {
+		return nil, ErrUnbalancedEntry
+	}
+	sum := 0
+	for _, p := range entry.Postings {
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return nil, ErrUnbalancedEntry
+	}
+
+	journalQuery := `
+		INSERT INTO journal_entry (id, reference_id, entry_type, description, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	updateAccount := `
+		update ledger_account set balance = balance + $1, version = version + 1, updated_at = $2
+		where id = $3 RETURNING balance, account_type
+	`
+	postingQuery := `
+		INSERT INTO posting (id, journal_entry_id, account_id, amount, balance_after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := tx.Exec(ctx, journalQuery, entry.ID, entry.ReferenceID, entry.EntryType, entry.Description, entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(entry.Postings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return entry.Postings[order[a]].AccountID.String() < entry.Postings[order[b]].AccountID.String()
+	})
+
+	results := make([]PostingResult, len(entry.Postings))
+	for _, i := range order {
+		p := entry.Postings[i]
+
+		var balance int
+		var accountType string
+		err = tx.QueryRow(ctx, updateAccount, p.Amount, entry.CreatedAt, p.AccountID).Scan(&balance, &accountType)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				err = ErrAccountNotFound
+			}
+			return nil, err
+		}
+		if accountType == AccountTypeWallet && balance < 0 {
+			return nil, pgsql.ErrBalanceNotEnough
+		}
+
+		postingID, err := pkg.GenerateId()
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, postingQuery, postingID, entry.ID, p.AccountID, p.Amount, balance, entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i] = PostingResult{
+			AccountID:     p.AccountID,
+			BalanceBefore: balance - p.Amount,
+			BalanceAfter:  balance,
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,148 @@
+// Package limits enforces the transaction limits of the user's KYC tier. Checks run inside the
+// database transaction that moves the balance, after the user row was read with its version, so a
+// concurrent movement fails the version check and the checks run again on retry.
+package limits
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/ledger"
+	"bank-backend/module/bank/utils"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	LimitMaxBalance      = "MAX_BALANCE"
+	LimitPerTransaction  = "PER_TRANSACTION"
+	LimitDailyOutgoing   = "DAILY_OUTGOING"
+	LimitMonthlyOutgoing = "MONTHLY_OUTGOING"
+
+	// outgoing sums are rolling windows ending now
+	dailyWindow   = 24 * time.Hour
+	monthlyWindow = 30 * 24 * time.Hour
+)
+
+// outgoingEntryTypes are the movements the user makes themselves. Admin adjustments and closure
+// payouts do not count towards the limits.
+var outgoingEntryTypes = []string{ledger.EntryTypePayment, ledger.EntryTypeTransfer}
+
+// Tier holds the limits of a KYC tier.
+type Tier struct {
+	Tier            string
+	MaxBalance      int
+	PerTransaction  int
+	DailyOutgoing   int
+	MonthlyOutgoing int
+}
+
+// querier is satisfied by a transaction and by the pool, for checks made before the transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// ForUser returns the limits of the tier userID is in.
+func ForUser(ctx context.Context, q querier, userID uuid.UUID) (Tier, error) {
+	query := `
+		SELECT t.tier, t.max_balance, t.per_transaction, t.daily_outgoing, t.monthly_outgoing
+		FROM "user" u JOIN kyc_tier_limit t ON t.tier = u.kyc_tier
+		WHERE u.id = $1
+	`
+
+	tier := Tier{}
+	err := q.QueryRow(ctx, query, userID).Scan(&tier.Tier, &tier.MaxBalance, &tier.PerTransaction, &tier.DailyOutgoing, &tier.MonthlyOutgoing)
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrUserNotFound
+	}
+	return tier, err
+}
+
+// CheckCredit rejects a credit that would take the wallet of userID to balanceAfter, above the
+// wallet cap of its tier.
+func CheckCredit(ctx context.Context, q querier, userID uuid.UUID, amount int, balanceAfter int) error {
+	tier, err := ForUser(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if balanceAfter > tier.MaxBalance {
+		return &utils.LimitExceededError{
+			Limit:       LimitMaxBalance,
+			LimitAmount: tier.MaxBalance,
+			Remaining:   max(tier.MaxBalance-(balanceAfter-amount), 0),
+		}
+	}
+	return nil
+}
+
+// CheckDebit rejects amount leaving the wallet of userID when it is above the per-transaction limit
+// of its tier or would take the outgoing sum of the last day or month above its limit.
+func CheckDebit(ctx context.Context, q querier, userID uuid.UUID, amount int, now time.Time) error {
+	tier, err := ForUser(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+	if amount > tier.PerTransaction {
+		return &utils.LimitExceededError{
+			Limit:       LimitPerTransaction,
+			LimitAmount: tier.PerTransaction,
+			Remaining:   tier.PerTransaction,
+		}
+	}
+
+	daily, monthly, err := outgoing(ctx, q, userID, now)
+	if err != nil {
+		return err
+	}
+	if daily+amount > tier.DailyOutgoing {
+		return &utils.LimitExceededError{
+			Limit:       LimitDailyOutgoing,
+			LimitAmount: tier.DailyOutgoing,
+			Remaining:   max(tier.DailyOutgoing-daily, 0),
+		}
+	}
+	if monthly+amount > tier.MonthlyOutgoing {
+		return &utils.LimitExceededError{
+			Limit:       LimitMonthlyOutgoing,
+			LimitAmount: tier.MonthlyOutgoing,
+			Remaining:   max(tier.MonthlyOutgoing-monthly, 0),
+		}
+	}
+	return nil
+}
+
+// outgoing sums what left the wallet of userID through its own payments and transfers over the last
+// day and the last month. Active holds count towards both, since capturing one is not checked again.
+func outgoing(ctx context.Context, q querier, userID uuid.UUID, now time.Time) (int, int, error) {
+	query := `
+		SELECT coalesce(-sum(p.amount) FILTER (WHERE p.created_at > $3), 0), coalesce(-sum(p.amount), 0)
+		FROM posting p
+			JOIN ledger_account a ON a.id = p.account_id
+			JOIN journal_entry j ON j.id = p.journal_entry_id
+		WHERE a.user_id = $1 AND p.amount < 0 AND j.entry_type = ANY($2) AND p.created_at > $4
+	`
+
+	var daily, monthly int
+	err := q.QueryRow(ctx, query, userID, outgoingEntryTypes, now.Add(-dailyWindow), now.Add(-monthlyWindow)).Scan(&daily, &monthly)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	held, err := activeHolds(ctx, q, userID, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	return daily + held, monthly + held, nil
+}
+
+// activeHolds sums the holds of userID that can still be captured.
+func activeHolds(ctx context.Context, q querier, userID uuid.UUID, now time.Time) (int, error) {
+	query := `SELECT coalesce(sum(amount), 0) FROM hold WHERE user_id = $1 AND status = $2 AND expires_at > $3`
+
+	var held int
+	err := q.QueryRow(ctx, query, userID, entity.HoldStatusActive, now).Scan(&held)
+	return held, err
+}
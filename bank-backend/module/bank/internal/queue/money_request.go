@@ -0,0 +1,175 @@
+package queue
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/repository"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// MoneyRequestQueue stores money request state changes together with the MoneyRequestEvent announcing
+// them in the outbox. Accepting a request also records its transfer the way ProcessTransferQueue
+// does, so bank-worker processes it like any other transfer.
+type MoneyRequestQueue struct {
+	Topic     string
+	transfers *ProcessTransferQueue
+	bankRepo  *repository.BankRepository
+}
+
+func NewMoneyRequestQueue(topic string, transfers *ProcessTransferQueue, bankRepo *repository.BankRepository) *MoneyRequestQueue {
+	return &MoneyRequestQueue{Topic: topic, transfers: transfers, bankRepo: bankRepo}
+}
+
+func (q *MoneyRequestQueue) PublishMoneyRequestCreated(ctx context.Context, request entity.MoneyRequest) error {
+	var (
+		lvState3       = utils.LogEventStateKafkaPublish
+		lfState3Status = "state_3_kafka_publish_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 3 : Publish MoneyRequestEvent
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	message, err := q.newEventMessage(request, entity.MoneyRequestEventCreated, request.CreatedAt)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "build money request event error", err, lf)
+		return err
+	}
+	err = q.bankRepo.CreateMoneyRequest(ctx, request, message)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "insert money request outbox error", err, lf)
+		return err
+	}
+	return nil
+}
+
+// PublishMoneyRequestDeclined moves the request to DECLINED and returns it as stored.
+func (q *MoneyRequestQueue) PublishMoneyRequestDeclined(ctx context.Context, request entity.MoneyRequest) (entity.MoneyRequest, error) {
+	var (
+		lvState3       = utils.LogEventStateKafkaPublish
+		lfState3Status = "state_3_kafka_publish_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 3 : Publish MoneyRequestEvent
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	now := time.Now()
+	request.Status = entity.MoneyRequestStatusDeclined
+	request.RespondedAt = &now
+	request.UpdatedAt = now
+
+	message, err := q.newEventMessage(request, entity.MoneyRequestEventDeclined, now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "build money request event error", err, lf)
+		return entity.MoneyRequest{}, err
+	}
+	err = q.bankRepo.DeclineMoneyRequest(ctx, request, message)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "update money request outbox error", err, lf)
+		return entity.MoneyRequest{}, err
+	}
+	return request, nil
+}
+
+// PublishMoneyRequestAccepted moves the request to ACCEPTED and records the PENDING transfer from the
+// payer to the requester with its TransferEvent. It returns the request as stored.
+func (q *MoneyRequestQueue) PublishMoneyRequestAccepted(ctx context.Context, request entity.MoneyRequest) (entity.MoneyRequest, error) {
+	var (
+		lvState3       = utils.LogEventStateKafkaPublish
+		lfState3Status = "state_3_kafka_publish_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 3 : Publish TransferEvent and MoneyRequestEvent
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	now := time.Now()
+	transferRequest := entity.TransferRequest{
+		Amount:     request.Amount,
+		TargetUser: request.RequesterUserID.String(),
+		Remarks:    request.Remarks,
+	}
+	transfer, transferMessage, _, err := q.transfers.newTransferJob(transferRequest, request.PayerPhoneNumber, request.PayerUserID, now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "build transfer event error", err, lf)
+		return entity.MoneyRequest{}, err
+	}
+
+	request.Status = entity.MoneyRequestStatusAccepted
+	request.TransferID = &transfer.ID
+	request.RespondedAt = &now
+	request.UpdatedAt = now
+
+	message, err := q.newEventMessage(request, entity.MoneyRequestEventAccepted, now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "build money request event error", err, lf)
+		return entity.MoneyRequest{}, err
+	}
+	err = q.bankRepo.AcceptMoneyRequest(ctx, request, transfer, transferMessage, message)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "insert money request transfer outbox error", err, lf)
+		return entity.MoneyRequest{}, err
+	}
+	return request, nil
+}
+
+// newEventMessage builds the outbox message of a MoneyRequestEvent, keyed by the request id so the
+// events of one request stay in order.
+func (q *MoneyRequestQueue) newEventMessage(request entity.MoneyRequest, eventType string, now time.Time) (entity.OutboxMessage, error) {
+	event := entity.MoneyRequestEvent{
+		EventType:     eventType,
+		MoneyRequest:  request.ID.String(),
+		RequesterUser: request.RequesterUserID.String(),
+		PayerUser:     request.PayerUserID.String(),
+		Amount:        request.Amount,
+		Remarks:       request.Remarks,
+		Status:        request.Status,
+		ExpiresAt:     request.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:     now.Format("2006-01-02 15:04:05.000000"),
+	}
+	if request.TransferID != nil {
+		event.TransferID = request.TransferID.String()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return entity.OutboxMessage{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return entity.OutboxMessage{}, err
+	}
+	message := entity.OutboxMessage{
+		ID:          id,
+		AggregateID: request.ID,
+		Topic:       q.Topic,
+		Key:         request.ID.String(),
+		Payload:     string(payload),
+		CreatedAt:   now,
+	}
+	return message, nil
+}
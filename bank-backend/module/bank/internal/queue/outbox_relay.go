@@ -0,0 +1,102 @@
+package queue
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/repository"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"context"
+	"expvar"
+	"log/slog"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	outboxBacklog          = expvar.NewInt("outbox_backlog")
+	outboxOldestAgeSeconds = expvar.NewFloat("outbox_oldest_age_seconds")
+	outboxPublished        = expvar.NewInt("outbox_published_total")
+	outboxPublishFailed    = expvar.NewInt("outbox_publish_failed_total")
+)
+
+// OutboxRelay publishes the rows written to the outbox table to Kafka. Backlog size and publish
+// counters are exported through expvar on /debug/vars.
+type OutboxRelay struct {
+	Producer  sarama.SyncProducer
+	BatchSize int
+	Interval  time.Duration
+	bankRepo  *repository.BankRepository
+}
+
+const (
+	defaultOutboxBatchSize    = 100
+	defaultOutboxPollInterval = 500 * time.Millisecond
+)
+
+func NewOutboxRelay(producer sarama.SyncProducer, bankRepo *repository.BankRepository, batchSize int, interval time.Duration) *OutboxRelay {
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultOutboxPollInterval
+	}
+	return &OutboxRelay{Producer: producer, BatchSize: batchSize, Interval: interval, bankRepo: bankRepo}
+}
+
+// Run relays until ctx is cancelled. A full batch is followed immediately by the next one so a
+// backlog drains without waiting for the ticker.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		sent := r.relay(ctx)
+		if sent == r.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *OutboxRelay) relay(ctx context.Context) int {
+	var (
+		lvState1       = utils.LogEventStateKafkaPublish
+		lfState1Status = "state_1_relay_outbox_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("outbox-relay"),
+			pkg.LogEventState(lvState1),
+		}
+	)
+
+	sent, failed, err := r.bankRepo.RelayOutbox(ctx, r.BatchSize, func(m entity.OutboxMessage) error {
+		return pkg.PublishMessageWithKey(r.Producer, m.Topic, m.Key, m.Payload)
+	})
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx, "relay outbox error", err, lf)
+		return 0
+	}
+	outboxPublished.Add(int64(sent))
+	outboxPublishFailed.Add(int64(failed))
+
+	backlog, oldest, err := r.bankRepo.OutboxBacklog(ctx)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx, "count outbox backlog error", err, lf)
+		return sent
+	}
+	outboxBacklog.Set(int64(backlog))
+	outboxOldestAgeSeconds.Set(oldest.Seconds())
+
+	return sent
+}
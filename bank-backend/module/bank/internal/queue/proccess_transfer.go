@@ -2,30 +2,30 @@ package queue
 
 import (
 	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/repository"
 	"bank-backend/pkg"
 	"bank-backend/utils"
 	"context"
 	"encoding/json"
-	"fmt"
 	"log/slog"
 	"time"
 
-	"github.com/IBM/sarama"
 	"github.com/google/uuid"
 )
 
 type ProcessTransferQueue struct {
-	Producer sarama.SyncProducer
 	Topic    string
+	bankRepo *repository.BankRepository
 }
 
-func NewProcessTransferQueue(producer sarama.SyncProducer, topic string) *ProcessTransferQueue {
-	return &ProcessTransferQueue{Producer: producer, Topic: topic}
+func NewProcessTransferQueue(topic string, bankRepo *repository.BankRepository) *ProcessTransferQueue {
+	return &ProcessTransferQueue{Topic: topic, bankRepo: bankRepo}
 }
 
-func (q *ProcessTransferQueue) PublishProcessTransferJob(ctx context.Context, request entity.TransferRequest, userPhoneNumber string) (uuid.UUID, string, error) {
-	fmt.Println("q.Topic nih:")
-	fmt.Println(q.Topic)
+// PublishProcessTransferJob records the transfer as PENDING together with its TransferEvent in the
+// outbox. The event reaches Kafka through OutboxRelay, and bank-worker then moves the transfer to
+// COMPLETED or FAILED.
+func (q *ProcessTransferQueue) PublishProcessTransferJob(ctx context.Context, request entity.TransferRequest, userPhoneNumber string, originUserID uuid.UUID) (uuid.UUID, string, error) {
 	var (
 		lvState3       = utils.LogEventStateKafkaPublish
 		lfState3Status = "state_2_kafka_publish_status"
@@ -39,13 +39,32 @@ func (q *ProcessTransferQueue) PublishProcessTransferJob(ctx context.Context, re
 	* ----------------------------------*/
 	lf = append(lf, pkg.LogEventState(lvState3))
 
-	id, err := pkg.GenerateId()
+	transfer, message, createdAt, err := q.newTransferJob(request, userPhoneNumber, originUserID, time.Now())
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "build transfer event error", err, lf)
+		return uuid.UUID{}, "", err
+	}
+	err = q.bankRepo.CreateTransfer(ctx, transfer, message)
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
-		pkg.LogWarnWithContext(ctx, "generate uuid error", err, lf)
+		pkg.LogWarnWithContext(ctx, "insert transfer outbox error", err, lf)
 		return uuid.UUID{}, "", err
 	}
-	now := time.Now()
+	return transfer.ID, createdAt, nil
+}
+
+// newTransferJob builds the PENDING transfer and the outbox message carrying its TransferEvent. It
+// also returns the formatted creation time sent in the event.
+func (q *ProcessTransferQueue) newTransferJob(request entity.TransferRequest, userPhoneNumber string, originUserID uuid.UUID, now time.Time) (entity.Transfer, entity.OutboxMessage, string, error) {
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return entity.Transfer{}, entity.OutboxMessage{}, "", err
+	}
+	targetUserID, err := uuid.Parse(request.TargetUser)
+	if err != nil {
+		return entity.Transfer{}, entity.OutboxMessage{}, "", err
+	}
 	// Format the time
 	formatted := now.Format("2006-01-02 15:04:05.000000")
 
@@ -55,19 +74,75 @@ func (q *ProcessTransferQueue) PublishProcessTransferJob(ctx context.Context, re
 		PhoneNumberOriginUser: userPhoneNumber,
 		TargetUser:            request.TargetUser,
 		Remarks:               request.Remarks,
+		ReversalOf:            request.ReversalOf,
 		CreatedAt:             formatted,
 	}
 	messageByte, err := json.Marshal(event)
+	if err != nil {
+		return entity.Transfer{}, entity.OutboxMessage{}, "", err
+	}
+
+	messageID, err := pkg.GenerateId()
+	if err != nil {
+		return entity.Transfer{}, entity.OutboxMessage{}, "", err
+	}
+
+	transfer := entity.Transfer{
+		ID:           id,
+		UserID:       originUserID,
+		TargetUserID: targetUserID,
+		Amount:       request.Amount,
+		Remarks:      request.Remarks,
+		Status:       entity.TransferStatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	message := entity.OutboxMessage{
+		ID:          messageID,
+		AggregateID: id,
+		Topic:       q.Topic,
+		Key:         id.String(),
+		Payload:     string(messageByte),
+		CreatedAt:   now,
+	}
+	return transfer, message, formatted, nil
+}
+
+// PublishTransferReversal records the PENDING transfer moving the reversed funds back to the sender,
+// together with the reversal and its TransferEvent. It returns the reversal with its id set.
+func (q *ProcessTransferQueue) PublishTransferReversal(ctx context.Context, reversal entity.TransferReversal, userPhoneNumber string) (entity.TransferReversal, error) {
+	var (
+		lvState3       = utils.LogEventStateKafkaPublish
+		lfState3Status = "state_3_kafka_publish_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 3 : Publish TransferEvent
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	request := entity.TransferRequest{
+		Amount:     reversal.Amount,
+		TargetUser: reversal.TargetUserID.String(),
+		Remarks:    "transfer reversal",
+		ReversalOf: reversal.TransferID.String(),
+	}
+	transfer, message, _, err := q.newTransferJob(request, userPhoneNumber, reversal.UserID, reversal.CreatedAt)
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
-		pkg.LogWarnWithContext(ctx, "kafka publish error", err, lf)
-		return uuid.UUID{}, "", err
+		pkg.LogWarnWithContext(ctx, "build transfer event error", err, lf)
+		return entity.TransferReversal{}, err
 	}
-	err = pkg.PublishMessage(q.Producer, q.Topic, string(messageByte))
+	reversal.ID = transfer.ID
+
+	err = q.bankRepo.ReverseTransfer(ctx, reversal, transfer, message)
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
-		pkg.LogWarnWithContext(ctx, "kafka publish error", err, lf)
-		return uuid.UUID{}, "", err
+		pkg.LogWarnWithContext(ctx, "insert transfer reversal outbox error", err, lf)
+		return entity.TransferReversal{}, err
 	}
-	return id, event.CreatedAt, nil
+	return reversal, nil
 }
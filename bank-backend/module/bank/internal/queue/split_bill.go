@@ -0,0 +1,182 @@
+package queue
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/repository"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SplitBillQueue stores split bill changes together with the SplitBillEvent announcing them in the
+// outbox. Reminders only exist as these events, for a notification service to deliver. Paying a share
+// records its transfer the way ProcessTransferQueue does.
+type SplitBillQueue struct {
+	Topic     string
+	transfers *ProcessTransferQueue
+	bankRepo  *repository.BankRepository
+}
+
+func NewSplitBillQueue(topic string, transfers *ProcessTransferQueue, bankRepo *repository.BankRepository) *SplitBillQueue {
+	return &SplitBillQueue{Topic: topic, transfers: transfers, bankRepo: bankRepo}
+}
+
+// PublishSplitBillCreated stores the bill with a SPLIT_BILL_CREATED event for every share still to pay.
+func (q *SplitBillQueue) PublishSplitBillCreated(ctx context.Context, bill entity.SplitBill) error {
+	var (
+		lvState3       = utils.LogEventStateKafkaPublish
+		lfState3Status = "state_3_kafka_publish_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 3 : Publish SplitBillEvent
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	messages := make([]entity.OutboxMessage, 0, len(bill.Participants))
+	for _, p := range bill.Participants {
+		if p.Status != entity.SplitShareStatusPending {
+			continue
+		}
+		message, err := q.newEventMessage(bill, p, entity.SplitBillEventCreated, bill.CreatedAt)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+			pkg.LogWarnWithContext(ctx, "build split bill event error", err, lf)
+			return err
+		}
+		messages = append(messages, message)
+	}
+
+	err := q.bankRepo.CreateSplitBill(ctx, bill, messages)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "insert split bill outbox error", err, lf)
+		return err
+	}
+	return nil
+}
+
+// PublishSplitBillReminders stores a SPLIT_BILL_REMINDER event for every given share and stamps them
+// as reminded.
+func (q *SplitBillQueue) PublishSplitBillReminders(ctx context.Context, bill entity.SplitBill, participants []entity.SplitBillParticipant) error {
+	var (
+		lvState3       = utils.LogEventStateKafkaPublish
+		lfState3Status = "state_3_kafka_publish_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 3 : Publish SplitBillEvent
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	now := time.Now()
+	ids := make([]uuid.UUID, 0, len(participants))
+	messages := make([]entity.OutboxMessage, 0, len(participants))
+	for _, p := range participants {
+		message, err := q.newEventMessage(bill, p, entity.SplitBillEventReminder, now)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+			pkg.LogWarnWithContext(ctx, "build split bill event error", err, lf)
+			return err
+		}
+		ids = append(ids, p.ID)
+		messages = append(messages, message)
+	}
+
+	err := q.bankRepo.RecordSplitBillReminders(ctx, ids, now, messages)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "insert split bill reminder outbox error", err, lf)
+		return err
+	}
+	return nil
+}
+
+// PublishSplitBillShareTransfer records the PENDING transfer paying the share to the organizer with
+// its TransferEvent and moves the share to PROCESSING. bank-worker marks it PAID once the transfer
+// completes. It returns the share as stored.
+func (q *SplitBillQueue) PublishSplitBillShareTransfer(ctx context.Context, bill entity.SplitBill, participant entity.SplitBillParticipant) (entity.SplitBillParticipant, error) {
+	var (
+		lvState3       = utils.LogEventStateKafkaPublish
+		lfState3Status = "state_3_kafka_publish_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 3 : Publish TransferEvent
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	now := time.Now()
+	transferRequest := entity.TransferRequest{
+		Amount:     participant.ShareAmount,
+		TargetUser: bill.OrganizerUserID.String(),
+		Remarks:    bill.Title,
+	}
+	transfer, transferMessage, _, err := q.transfers.newTransferJob(transferRequest, participant.PhoneNumber, participant.UserID, now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "build transfer event error", err, lf)
+		return entity.SplitBillParticipant{}, err
+	}
+
+	participant.Status = entity.SplitShareStatusProcessing
+	participant.TransferID = &transfer.ID
+	participant.UpdatedAt = now
+
+	err = q.bankRepo.PaySplitBillShare(ctx, participant, transfer, transferMessage)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx, "insert split bill transfer outbox error", err, lf)
+		return entity.SplitBillParticipant{}, err
+	}
+	return participant, nil
+}
+
+// newEventMessage builds the outbox message of a SplitBillEvent about one share, keyed by the bill id
+// so the events of one bill stay in order.
+func (q *SplitBillQueue) newEventMessage(bill entity.SplitBill, participant entity.SplitBillParticipant, eventType string, now time.Time) (entity.OutboxMessage, error) {
+	event := entity.SplitBillEvent{
+		EventType:              eventType,
+		SplitBill:              bill.ID.String(),
+		Title:                  bill.Title,
+		OrganizerUser:          bill.OrganizerUserID.String(),
+		OrganizerPhoneNumber:   bill.OrganizerPhoneNumber,
+		TotalAmount:            bill.TotalAmount,
+		ParticipantUser:        participant.UserID.String(),
+		ParticipantPhoneNumber: participant.PhoneNumber,
+		ShareAmount:            participant.ShareAmount,
+		CreatedAt:              now.Format("2006-01-02 15:04:05.000000"),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return entity.OutboxMessage{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return entity.OutboxMessage{}, err
+	}
+	message := entity.OutboxMessage{
+		ID:          id,
+		AggregateID: bill.ID,
+		Topic:       q.Topic,
+		Key:         bill.ID.String(),
+		Payload:     string(payload),
+		CreatedAt:   now,
+	}
+	return message, nil
+}
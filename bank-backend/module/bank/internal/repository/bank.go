@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/ledger"
+	"bank-backend/module/bank/internal/limits"
+	"bank-backend/module/bank/utils"
 	"bank-backend/pkg"
 	"bank-backend/utils/pgsql"
 
@@ -14,19 +18,41 @@ import (
 	"github.com/google/uuid"
 )
 
+// walletEntry describes how a wallet movement is posted: the ledger entry type, the system account on
+// the other side and the descriptions. allow decides from the account status whether the wallet may
+// be moved, it is checked inside the transaction. limits enforces the KYC tier limits of the wallets
+// in the same transaction, it is left off for back-office movements. record, when set, writes the
+// caller's own rows in the same database transaction once the wallet transaction id is known. Debits
+// can only spend the available balance, what active holds reserve is off limits unless the debit
+// releases it.
+type walletEntry struct {
+	entryType   string
+	account     string
+	description string
+	remarks     string
+	allow       func(status string) bool
+	limits      bool
+	// release is the held balance a debit capturing a hold frees, see CaptureHold
+	release int
+	// reference is stored as the transaction's reference_id, see entity.Transaction
+	reference *uuid.UUID
+	record    func(ctx context.Context, tx pgx.Tx, transactionID uuid.UUID) error
+}
+
 type BankRepository struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	retry pgsql.RetryPolicy
 }
 
-func NewBankRepository(db *pgxpool.Pool) *BankRepository {
-	return &BankRepository{db: db}
+func NewBankRepository(db *pgxpool.Pool, retry pgsql.RetryPolicy) *BankRepository {
+	return &BankRepository{db: db, retry: retry}
 }
 
 func (b *BankRepository) CheckIfUserExistByPhoneNumber(ctx context.Context, phoneNumber string) (entity.User, error) {
 	user := entity.User{}
-	query := `SELECT id, balance FROM "user" where phone_number = $1`
+	query := `SELECT id, balance, held_balance, status FROM "user" where phone_number = $1`
 
-	err := b.db.QueryRow(ctx, query, phoneNumber).Scan(&user.ID, &user.Balance)
+	err := b.db.QueryRow(ctx, query, phoneNumber).Scan(&user.ID, &user.Balance, &user.HeldBalance, &user.Status)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			err = pgsql.ErrUserNotFound
@@ -37,11 +63,26 @@ func (b *BankRepository) CheckIfUserExistByPhoneNumber(ctx context.Context, phon
 	return user, nil
 }
 
+// GetBalance returns the wallet balance of the user owning phoneNumber.
+func (b *BankRepository) GetBalance(ctx context.Context, phoneNumber string) (entity.Balance, error) {
+	balance := entity.Balance{}
+	query := `SELECT id, coalesce(balance, 0), held_balance, status, updated_at FROM "user" where phone_number = $1`
+
+	err := b.db.QueryRow(ctx, query, phoneNumber).Scan(&balance.UserID, &balance.Balance, &balance.HeldBalance, &balance.Status, &balance.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return balance, err
+	}
+	return balance, nil
+}
+
 func (b *BankRepository) CheckIfUserExistByID(ctx context.Context, id uuid.UUID) (entity.User, error) {
 	user := entity.User{}
-	query := `SELECT id, balance FROM "user" where id = $1`
+	query := `SELECT id, phone_number, balance, held_balance, status FROM "user" where id = $1`
 
-	err := b.db.QueryRow(ctx, query, id).Scan(&user.ID, &user.Balance)
+	err := b.db.QueryRow(ctx, query, id).Scan(&user.ID, &user.PhoneNumber, &user.Balance, &user.HeldBalance, &user.Status)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			err = pgsql.ErrUserNotFound
@@ -52,7 +93,152 @@ func (b *BankRepository) CheckIfUserExistByID(ctx context.Context, id uuid.UUID)
 	return user, nil
 }
 
-func (b *BankRepository) UpdateTopUpt(ctx context.Context, user entity.User) (entity.User, int, uuid.UUID, time.Time, error) {
+// UpdateTopUpt credits the user's wallet, re-running the transaction when a concurrent write wins
+// the version check.
+func (b *BankRepository) UpdateTopUpt(ctx context.Context, user entity.User) (returningUser entity.User, prevBalance int, transactionId uuid.UUID, createdAt time.Time, err error) {
+	err = pgsql.RetryOnConflict(ctx, b.retry, "topup", func() error {
+		returningUser, prevBalance, transactionId, createdAt, err = b.updateTopUp(ctx, user, walletEntry{
+			entryType:   ledger.EntryTypeTopUp,
+			account:     ledger.TopUpClearingAccount,
+			description: "top up",
+			allow:       entity.AccountAllowsCredit,
+			limits:      true,
+		})
+		return err
+	})
+	return returningUser, prevBalance, transactionId, createdAt, err
+}
+
+// UpdatePayment debits the user's wallet, re-running the transaction when a concurrent write wins
+// the version check. A non-empty reference is stored with the payment and cannot be paid again from
+// the same wallet.
+func (b *BankRepository) UpdatePayment(ctx context.Context, user entity.User, remarks string, reference string) (returningUser entity.User, prevBalance int, transactionId uuid.UUID, createdAt time.Time, err error) {
+	entry := walletEntry{
+		entryType:   ledger.EntryTypePayment,
+		account:     ledger.PaymentSettlementAccount,
+		description: remarks,
+		remarks:     remarks,
+		allow:       entity.AccountAllowsDebit,
+		limits:      true,
+	}
+	if reference != "" {
+		entry.record = func(ctx context.Context, tx pgx.Tx, transactionID uuid.UUID) error {
+			return insertPaymentReference(ctx, tx, reference, transactionID)
+		}
+	}
+
+	err = pgsql.RetryOnConflict(ctx, b.retry, "payment", func() error {
+		returningUser, prevBalance, transactionId, createdAt, err = b.updatePayment(ctx, user, entry)
+		return err
+	})
+	return returningUser, prevBalance, transactionId, createdAt, err
+}
+
+func insertPaymentReference(ctx context.Context, tx pgx.Tx, reference string, transactionID uuid.UUID) error {
+	query := `
+		INSERT INTO payment_reference (user_id, reference, transaction_id, created_at)
+		SELECT user_id, $1, id, created_at FROM transaction WHERE id = $2
+		ON CONFLICT (user_id, reference) DO NOTHING
+	`
+	tag, err := tx.Exec(ctx, query, reference, transactionID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrPaymentReferenceUsed
+	}
+	return nil
+}
+
+// PaymentReferenceExists reports whether the wallet already paid the reference, so the step-up token
+// is not spent on a payment UpdatePayment would reject.
+func (b *BankRepository) PaymentReferenceExists(ctx context.Context, userID uuid.UUID, reference string) (bool, error) {
+	var exists bool
+	err := b.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM payment_reference WHERE user_id = $1 AND reference = $2)`,
+		userID, reference,
+	).Scan(&exists)
+	return exists, err
+}
+
+// AdjustBalance posts a manual credit or debit against the manual adjustment account through the
+// same transaction as a top-up or payment, storing the adjustment and the acting admin with it.
+func (b *BankRepository) AdjustBalance(ctx context.Context, user entity.User, adjustment entity.BalanceAdjustment) (returningUser entity.User, prevBalance int, transactionId uuid.UUID, createdAt time.Time, err error) {
+	entry := walletEntry{
+		entryType:   ledger.EntryTypeAdjustment,
+		account:     ledger.ManualAdjustmentAccount,
+		description: adjustment.Reason,
+		remarks:     "manual adjustment",
+		allow:       entity.AccountAllowsAdjustment,
+		record: func(ctx context.Context, tx pgx.Tx, transactionID uuid.UUID) error {
+			adjustment.TransactionID = transactionID
+			return insertBalanceAdjustment(ctx, tx, adjustment)
+		},
+	}
+
+	err = pgsql.RetryOnConflict(ctx, b.retry, "adjustment", func() error {
+		if adjustment.Type == entity.AdjustmentTypeDebit {
+			returningUser, prevBalance, transactionId, createdAt, err = b.updatePayment(ctx, user, entry)
+		} else {
+			returningUser, prevBalance, transactionId, createdAt, err = b.updateTopUp(ctx, user, entry)
+		}
+		return err
+	})
+	return returningUser, prevBalance, transactionId, createdAt, err
+}
+
+func insertBalanceAdjustment(ctx context.Context, tx pgx.Tx, adjustment entity.BalanceAdjustment) error {
+	query := `
+		INSERT INTO balance_adjustment (id, user_id, transaction_id, admin_phone_number, adjustment_type, amount, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := tx.Exec(ctx, query,
+		adjustment.ID,
+		adjustment.UserID,
+		adjustment.TransactionID,
+		adjustment.AdminPhoneNumber,
+		adjustment.Type,
+		adjustment.Amount,
+		adjustment.Reason,
+		adjustment.CreatedAt,
+	)
+	return err
+}
+
+// TransferTX moves balance between two wallets, re-running the transaction when a concurrent write
+// wins the version check on either side.
+func (b *BankRepository) TransferTX(ctx context.Context, user entity.User, targetUser uuid.UUID, remarks string) (returningUser entity.User, prevBalance int, transactionId uuid.UUID, createdAt time.Time, err error) {
+	err = pgsql.RetryOnConflict(ctx, b.retry, "transfer", func() error {
+		returningUser, prevBalance, transactionId, createdAt, err = b.transferTX(ctx, user, targetUser, walletEntry{
+			entryType:   ledger.EntryTypeTransfer,
+			description: remarks,
+			remarks:     remarks,
+			allow:       entity.AccountAllowsDebit,
+			limits:      true,
+		})
+		return err
+	})
+	return returningUser, prevBalance, transactionId, createdAt, err
+}
+
+// PayoutBalance moves user.Balance from a wallet being closed to the designated payout wallet. The
+// closing wallet must be FROZEN_ALL, so nothing else can change its balance in the meantime.
+func (b *BankRepository) PayoutBalance(ctx context.Context, user entity.User, payoutUser uuid.UUID) (returningUser entity.User, prevBalance int, transactionId uuid.UUID, createdAt time.Time, err error) {
+	err = pgsql.RetryOnConflict(ctx, b.retry, "closure_payout", func() error {
+		returningUser, prevBalance, transactionId, createdAt, err = b.transferTX(ctx, user, payoutUser, walletEntry{
+			entryType:   ledger.EntryTypeClosurePayout,
+			description: "account closure payout",
+			remarks:     "account closure payout",
+			allow: func(status string) bool {
+				return status == entity.AccountStatusFrozenAll
+			},
+		})
+		return err
+	})
+	return returningUser, prevBalance, transactionId, createdAt, err
+}
+
+func (b *BankRepository) updateTopUp(ctx context.Context, user entity.User, entry walletEntry) (entity.User, int, uuid.UUID, time.Time, error) {
 	returningUser := entity.User{}
 	tx, err := b.db.Begin(ctx)
 	if err != nil {
@@ -60,20 +246,22 @@ func (b *BankRepository) UpdateTopUpt(ctx context.Context, user entity.User) (en
 	}
 	defer tx.Rollback(ctx)
 
-	query := `update "user" set balance = balance + $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
+	query := `update "user" set balance = $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
 
-	selectUser := `select phone_number, balance, version from "user" where phone_number = $1`
+	selectUser := `select id, phone_number, balance, status, version from "user" where phone_number = $1`
 
 	transactionQuery := `
-		INSERT INTO transaction (id, amount, balance_before, balance_after, transaction_type, user_id, created_at, version)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at
+		INSERT INTO transaction (id, amount, balance_before, balance_after, transaction_type, user_id, created_at, version, remarks, reference_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, nullif($9, ''), $10) RETURNING id, created_at
 	`
 
+	var UserID uuid.UUID
 	var PhoneNumber string
 	var prevBalance int
+	var Status string
 	var Version int
 
-	err = tx.QueryRow(ctx, selectUser, user.PhoneNumber).Scan(&PhoneNumber, &prevBalance, &Version)
+	err = tx.QueryRow(ctx, selectUser, user.PhoneNumber).Scan(&UserID, &PhoneNumber, &prevBalance, &Status, &Version)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -82,24 +270,54 @@ func (b *BankRepository) UpdateTopUpt(ctx context.Context, user entity.User) (en
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	err = tx.QueryRow(ctx, query, user.Balance, time.Now(), PhoneNumber, Version).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
+	if !entry.allow(Status) {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, utils.AccountStatusError(Status)
+	}
+
+	if entry.limits {
+		if err = limits.CheckCredit(ctx, tx, UserID, user.Balance, prevBalance+user.Balance); err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
+	}
 
+	id, err := pkg.GenerateId()
 	if err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	id, err := pkg.GenerateId()
+	// post the top-up from the clearing account into the wallet
+	wallet, err := ledger.WalletAccount(ctx, tx, UserID)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	clearing, err := ledger.SystemAccount(ctx, tx, entry.account)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	_, walletPosting, err := ledger.Move(ctx, tx, entry.entryType, id, entry.description, clearing, wallet, user.Balance)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	err = tx.QueryRow(ctx, query, walletPosting.BalanceAfter, time.Now(), PhoneNumber, Version).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
+
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrConcurrentModification
+		}
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
+
 	// Sample transaction data
 	transaction := entity.Transaction{
 		ID:              id,
 		Amount:          user.Balance,
-		BalanceBefore:   prevBalance,
+		BalanceBefore:   walletPosting.BalanceBefore,
+		Remarks:         entry.remarks,
 		BalanceAfter:    returningUser.Balance,
 		TransactionType: "CREDIT",
 		UserID:          returningUser.ID,
+		ReferenceID:     entry.reference,
 		CreatedDate:     time.Now(),
 		Version:         1,
 	}
@@ -116,22 +334,30 @@ func (b *BankRepository) UpdateTopUpt(ctx context.Context, user entity.User) (en
 		transaction.UserID,
 		transaction.CreatedDate,
 		transaction.Version,
+		transaction.Remarks,
+		transaction.ReferenceID,
 	).Scan(&transactionId, &createdAt)
 
 	if err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
+	if entry.record != nil {
+		if err = entry.record(ctx, tx, transactionId); err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
+	}
+
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	return returningUser, prevBalance, transactionId, createdAt, nil
+	return returningUser, walletPosting.BalanceBefore, transactionId, createdAt, nil
 
 }
 
-func (b *BankRepository) UpdatePayment(ctx context.Context, user entity.User, remarks string) (entity.User, int, uuid.UUID, time.Time, error) {
+func (b *BankRepository) updatePayment(ctx context.Context, user entity.User, entry walletEntry) (entity.User, int, uuid.UUID, time.Time, error) {
 
 	returningUser := entity.User{}
 	tx, err := b.db.Begin(ctx)
@@ -140,20 +366,23 @@ func (b *BankRepository) UpdatePayment(ctx context.Context, user entity.User, re
 	}
 	defer tx.Rollback(ctx)
 
-	query := `update "user" set balance = balance - $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
+	query := `update "user" set balance = $1, held_balance = held_balance - $5, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
 
-	selectUser := `select phone_number, balance, version from "user" where phone_number = $1`
+	selectUser := `select id, phone_number, balance, held_balance, status, version from "user" where phone_number = $1`
 
 	transactionQuery := `
-		INSERT INTO transaction (id, amount, balance_before, balance_after, transaction_type, user_id, created_at, version)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at
+		INSERT INTO transaction (id, amount, balance_before, balance_after, transaction_type, user_id, created_at, version, remarks)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at
 	`
 
+	var UserID uuid.UUID
 	var PhoneNumber string
 	var prevBalance int
+	var HeldBalance int
+	var Status string
 	var Version int
 
-	err = tx.QueryRow(ctx, selectUser, user.PhoneNumber).Scan(&PhoneNumber, &prevBalance, &Version)
+	err = tx.QueryRow(ctx, selectUser, user.PhoneNumber).Scan(&UserID, &PhoneNumber, &prevBalance, &HeldBalance, &Status, &Version)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -162,27 +391,55 @@ func (b *BankRepository) UpdatePayment(ctx context.Context, user entity.User, re
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	if prevBalance < user.Balance {
+	if !entry.allow(Status) {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, utils.AccountStatusError(Status)
+	}
+
+	if prevBalance-(HeldBalance-entry.release) < user.Balance {
 		err = pgsql.ErrBalanceNotEnough
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	err = tx.QueryRow(ctx, query, user.Balance, time.Now(), PhoneNumber, Version).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
+	if entry.limits {
+		if err = limits.CheckDebit(ctx, tx, UserID, user.Balance, time.Now()); err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
+	}
 
+	id, err := pkg.GenerateId()
 	if err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	id, err := pkg.GenerateId()
+	// post the payment from the wallet into the settlement account
+	wallet, err := ledger.WalletAccount(ctx, tx, UserID)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	settlement, err := ledger.SystemAccount(ctx, tx, entry.account)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	walletPosting, _, err := ledger.Move(ctx, tx, entry.entryType, id, entry.description, wallet, settlement, user.Balance)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	err = tx.QueryRow(ctx, query, walletPosting.BalanceAfter, time.Now(), PhoneNumber, Version, entry.release).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
+
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrConcurrentModification
+		}
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
+
 	// Sample transaction data
 	transaction := entity.Transaction{
 		ID:              id,
 		Amount:          user.Balance,
-		BalanceBefore:   prevBalance,
-		Remarks:         remarks,
+		BalanceBefore:   walletPosting.BalanceBefore,
+		Remarks:         entry.remarks,
 		BalanceAfter:    returningUser.Balance,
 		TransactionType: "DEBIT",
 		UserID:          returningUser.ID,
@@ -202,21 +459,30 @@ func (b *BankRepository) UpdatePayment(ctx context.Context, user entity.User, re
 		transaction.UserID,
 		transaction.CreatedDate,
 		transaction.Version,
+		transaction.Remarks,
 	).Scan(&transactionId, &createdAt)
 
 	if err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
+	if entry.record != nil {
+		if err = entry.record(ctx, tx, transactionId); err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
+	}
+
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	return returningUser, prevBalance, transactionId, createdAt, nil
+	return returningUser, walletPosting.BalanceBefore, transactionId, createdAt, nil
 }
 
-func (b *BankRepository) TransferTX(ctx context.Context, user entity.User, targetUser uuid.UUID, remarks string) (entity.User, int, uuid.UUID, time.Time, error) {
+// transferTX moves user.Balance between two wallets. entry.allow is checked against the origin, the
+// destination must accept credits.
+func (b *BankRepository) transferTX(ctx context.Context, user entity.User, targetUser uuid.UUID, entry walletEntry) (entity.User, int, uuid.UUID, time.Time, error) {
 	returningUser := entity.User{}
 	tx, err := b.db.Begin(ctx)
 	if err != nil {
@@ -224,20 +490,25 @@ func (b *BankRepository) TransferTX(ctx context.Context, user entity.User, targe
 	}
 	defer tx.Rollback(ctx)
 
-	updateOriginBalance := `update "user" set balance = balance - $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
+	updateBalance := `update "user" set balance = $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
+
+	selectUserOrigin := `select id, phone_number, balance, held_balance, status, version from "user" where phone_number = $1`
 
-	selectUserOrigin := `select phone_number, balance, version from "user" where phone_number = $1`
+	selectUserDestination := `select id, phone_number, balance, status, version from "user" where id = $1`
 
 	transactionQuery := `
-		INSERT INTO transaction (id, amount, balance_before, balance_after, transaction_type, user_id, created_at, user_id_destination, version)
+		INSERT INTO transaction (id, amount, balance_before, balance_after, transaction_type, user_id, created_at, version, remarks)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at
 	`
 
+	var UserIDOrigin uuid.UUID
 	var PhoneNumberOrigin string
 	var prevBalanceOrigin int
+	var HeldBalanceOrigin int
+	var StatusOrigin string
 	var VersionOrigin int
 
-	err = tx.QueryRow(ctx, selectUserOrigin, user.PhoneNumber).Scan(&PhoneNumberOrigin, &prevBalanceOrigin, &VersionOrigin)
+	err = tx.QueryRow(ctx, selectUserOrigin, user.PhoneNumber).Scan(&UserIDOrigin, &PhoneNumberOrigin, &prevBalanceOrigin, &HeldBalanceOrigin, &StatusOrigin, &VersionOrigin)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			err = pgsql.ErrUserNotFound
@@ -245,28 +516,22 @@ func (b *BankRepository) TransferTX(ctx context.Context, user entity.User, targe
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	if prevBalanceOrigin < user.Balance {
-		err = pgsql.ErrBalanceNotEnough
-		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	if !entry.allow(StatusOrigin) {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, utils.AccountStatusError(StatusOrigin)
 	}
-	err = tx.QueryRow(ctx, updateOriginBalance, user.Balance, time.Now(), PhoneNumberOrigin, VersionOrigin).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
 
-	if err != nil {
+	if prevBalanceOrigin-HeldBalanceOrigin < user.Balance {
+		err = pgsql.ErrBalanceNotEnough
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	// update destination user
-	returningDestUser := entity.User{}
-
-	updateQueryDestination := `update "user" set balance = balance + $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
-
-	selectUserDestination := `select phone_number, balance, version from "user" where id = $1`
-
+	var UserIDDestination uuid.UUID
 	var PhoneNumberDestination string
 	var prevBalanceDestination int
+	var StatusDestination string
 	var VersionDestination int
 
-	err = tx.QueryRow(ctx, selectUserDestination, targetUser).Scan(&PhoneNumberDestination, &prevBalanceDestination, &VersionDestination)
+	err = tx.QueryRow(ctx, selectUserDestination, targetUser).Scan(&UserIDDestination, &PhoneNumberDestination, &prevBalanceDestination, &StatusDestination, &VersionDestination)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -275,48 +540,103 @@ func (b *BankRepository) TransferTX(ctx context.Context, user entity.User, targe
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	err = tx.QueryRow(ctx, updateQueryDestination, user.Balance, time.Now(), PhoneNumberDestination, VersionDestination).Scan(&returningDestUser.ID, &returningDestUser.Balance, &returningDestUser.UpdatedAt)
+	if !entity.AccountAllowsCredit(StatusDestination) {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, utils.AccountStatusError(StatusDestination)
+	}
 
+	if entry.limits {
+		if err = limits.CheckDebit(ctx, tx, UserIDOrigin, user.Balance, time.Now()); err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
+		if err = limits.CheckCredit(ctx, tx, UserIDDestination, user.Balance, prevBalanceDestination+user.Balance); err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
+	}
+
+	id, err := pkg.GenerateId()
 	if err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	id, err := pkg.GenerateId()
+	// post the transfer between both wallets
+	walletOrigin, err := ledger.WalletAccount(ctx, tx, UserIDOrigin)
 	if err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
-	// insert transaction
-	// Sample transaction data
+	walletDestination, err := ledger.WalletAccount(ctx, tx, UserIDDestination)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	originPosting, destinationPosting, err := ledger.Move(ctx, tx, entry.entryType, id, entry.description, walletOrigin, walletDestination, user.Balance)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	err = tx.QueryRow(ctx, updateBalance, originPosting.BalanceAfter, time.Now(), PhoneNumberOrigin, VersionOrigin).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrConcurrentModification
+		}
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	// update destination user
+	returningDestUser := entity.User{}
+
+	err = tx.QueryRow(ctx, updateBalance, destinationPosting.BalanceAfter, time.Now(), PhoneNumberDestination, VersionDestination).Scan(&returningDestUser.ID, &returningDestUser.Balance, &returningDestUser.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrConcurrentModification
+		}
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	// insert one transaction row per side, the same way bank-worker does
 	transaction := entity.Transaction{
 		ID:              id,
 		Amount:          user.Balance,
-		BalanceBefore:   prevBalanceOrigin,
-		Remarks:         remarks,
+		BalanceBefore:   originPosting.BalanceBefore,
+		Remarks:         entry.remarks,
 		BalanceAfter:    returningUser.Balance,
 		TransactionType: "DEBIT",
-		TargetUserID:    returningDestUser.ID,
 		UserID:          returningUser.ID,
 		CreatedDate:     time.Now(),
 		Version:         1,
 	}
 
+	transactionDestination := entity.Transaction{
+		ID:              id,
+		Amount:          user.Balance,
+		BalanceBefore:   destinationPosting.BalanceBefore,
+		Remarks:         entry.remarks,
+		BalanceAfter:    returningDestUser.Balance,
+		TransactionType: "CREDIT",
+		UserID:          returningDestUser.ID,
+		CreatedDate:     transaction.CreatedDate,
+		Version:         1,
+	}
+
 	var transactionId uuid.UUID
 	var createdAt time.Time
 
-	err = tx.QueryRow(context.Background(), transactionQuery,
-		transaction.ID,
-		transaction.Amount,
-		transaction.BalanceBefore,
-		transaction.BalanceAfter,
-		transaction.TransactionType,
-		transaction.UserID,
-		transaction.CreatedDate,
-		transaction.TargetUserID,
-		transaction.Version,
-	).Scan(&transactionId, &createdAt)
-
-	if err != nil {
-		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	for _, t := range []entity.Transaction{transactionDestination, transaction} {
+		err = tx.QueryRow(context.Background(), transactionQuery,
+			t.ID,
+			t.Amount,
+			t.BalanceBefore,
+			t.BalanceAfter,
+			t.TransactionType,
+			t.UserID,
+			t.CreatedDate,
+			t.Version,
+			t.Remarks,
+		).Scan(&transactionId, &createdAt)
+
+		if err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
 	}
 
 	// Commit transaction
@@ -324,6 +644,140 @@ func (b *BankRepository) TransferTX(ctx context.Context, user entity.User, targe
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	return returningUser, prevBalanceOrigin, transactionId, createdAt, nil
+	return returningUser, originPosting.BalanceBefore, transactionId, createdAt, nil
+
+}
+
+// CheckPaymentLimits checks a payment against the tier limits of the wallet before the step-up token
+// is spent. UpdatePayment checks again inside its transaction.
+func (b *BankRepository) CheckPaymentLimits(ctx context.Context, userID uuid.UUID, amount int) error {
+	return limits.CheckDebit(ctx, b.db, userID, amount, time.Now())
+}
+
+// CheckTransferLimits checks a transfer against the tier limits of both wallets before it is queued,
+// so the caller gets the exceeded limit right away. The worker checks again when it runs the transfer.
+func (b *BankRepository) CheckTransferLimits(ctx context.Context, origin uuid.UUID, destination uuid.UUID, amount int, destinationBalance int) error {
+	if err := limits.CheckDebit(ctx, b.db, origin, amount, time.Now()); err != nil {
+		return err
+	}
+	return limits.CheckCredit(ctx, b.db, destination, amount, destinationBalance+amount)
+}
+
+// ListTransactions returns the user's transactions newest first. Pagination is keyset based on the
+// UUIDv7 transaction id, so Cursor is the id of the last row of the previous page.
+func (b *BankRepository) ListTransactions(ctx context.Context, filter entity.TransactionFilter) ([]entity.Transaction, error) {
+	transactions := []entity.Transaction{}
+
+	query := `SELECT id, coalesce(remarks, ''), amount, balance_before, balance_after, transaction_type, user_id, reference_id, created_at FROM transaction WHERE user_id = $1`
+	args := []interface{}{filter.UserID}
+
+	if filter.Cursor != uuid.Nil {
+		args = append(args, filter.Cursor)
+		query += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	if filter.TransactionType != "" {
+		args = append(args, filter.TransactionType)
+		query += fmt.Sprintf(" AND transaction_type = $%d", len(args))
+	}
+	if !filter.StartDate.IsZero() {
+		args = append(args, filter.StartDate)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !filter.EndDate.IsZero() {
+		args = append(args, filter.EndDate)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+	if filter.MinAmount > 0 {
+		args = append(args, filter.MinAmount)
+		query += fmt.Sprintf(" AND amount >= $%d", len(args))
+	}
+	if filter.MaxAmount > 0 {
+		args = append(args, filter.MaxAmount)
+		query += fmt.Sprintf(" AND amount <= $%d", len(args))
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := b.db.Query(ctx, query, args...)
+	if err != nil {
+		return transactions, err
+	}
+	defer rows.Close()
 
+	for rows.Next() {
+		t := entity.Transaction{}
+		err = rows.Scan(&t.ID, &t.Remarks, &t.Amount, &t.BalanceBefore, &t.BalanceAfter, &t.TransactionType, &t.UserID, &t.ReferenceID, &t.CreatedDate)
+		if err != nil {
+			return transactions, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
+
+// CreateTransfer stores the PENDING transfer and the outbox message announcing it in one transaction,
+// so the transfer is only visible to the client when its event is guaranteed to be published.
+func (b *BankRepository) CreateTransfer(ctx context.Context, transfer entity.Transfer, message entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = insertTransfer(ctx, tx, transfer); err != nil {
+		return err
+	}
+
+	if err = insertOutboxMessage(ctx, tx, message); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func insertTransfer(ctx context.Context, tx pgx.Tx, transfer entity.Transfer) error {
+	query := `
+		INSERT INTO transfer (id, user_id, target_user_id, amount, remarks, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := tx.Exec(ctx, query,
+		transfer.ID,
+		transfer.UserID,
+		transfer.TargetUserID,
+		transfer.Amount,
+		transfer.Remarks,
+		transfer.Status,
+		transfer.CreatedAt,
+		transfer.UpdatedAt,
+	)
+	return err
+}
+
+// GetTransfer returns the transfer when userID is either the sender or the recipient.
+func (b *BankRepository) GetTransfer(ctx context.Context, id uuid.UUID, userID uuid.UUID) (entity.Transfer, error) {
+	transfer := entity.Transfer{}
+	query := `
+		SELECT id, user_id, target_user_id, amount, coalesce(remarks, ''), status, coalesce(failure_reason, ''), created_at, updated_at
+		FROM transfer WHERE id = $1 AND (user_id = $2 OR target_user_id = $2)
+	`
+
+	err := b.db.QueryRow(ctx, query, id, userID).Scan(
+		&transfer.ID,
+		&transfer.UserID,
+		&transfer.TargetUserID,
+		&transfer.Amount,
+		&transfer.Remarks,
+		&transfer.Status,
+		&transfer.FailureReason,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrTransferNotFound
+		}
+		return transfer, err
+	}
+	return transfer, nil
 }
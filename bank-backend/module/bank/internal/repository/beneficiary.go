@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/bank/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const beneficiaryColumns = `
+	b.id, b.user_id, b.beneficiary_user_id, b.nickname, u.phone_number, coalesce(u.first_name, ''),
+	coalesce(u.last_name, ''), b.created_at, b.updated_at
+`
+
+func scanBeneficiary(row pgx.Row) (entity.Beneficiary, error) {
+	b := entity.Beneficiary{}
+	err := row.Scan(
+		&b.ID,
+		&b.UserID,
+		&b.BeneficiaryUserID,
+		&b.Nickname,
+		&b.PhoneNumber,
+		&b.FirstName,
+		&b.LastName,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	)
+	return b, err
+}
+
+// GetRecipientByPhoneNumber returns the user owning phoneNumber with the fields needed to address a
+// transfer to them.
+func (b *BankRepository) GetRecipientByPhoneNumber(ctx context.Context, phoneNumber string) (entity.User, error) {
+	user := entity.User{}
+	query := `
+		SELECT id, phone_number, coalesce(first_name, ''), coalesce(last_name, ''), balance, status
+		FROM "user" where phone_number = $1
+	`
+
+	err := b.db.QueryRow(ctx, query, phoneNumber).Scan(&user.ID, &user.PhoneNumber, &user.FirstName, &user.LastName, &user.Balance, &user.Status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+// CreateBeneficiary saves a recipient and returns ErrBeneficiaryExists when the user already saved it.
+func (b *BankRepository) CreateBeneficiary(ctx context.Context, beneficiary entity.Beneficiary) error {
+	query := `
+		INSERT INTO beneficiary (id, user_id, beneficiary_user_id, nickname, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, beneficiary_user_id) DO NOTHING
+	`
+	tag, err := b.db.Exec(ctx, query,
+		beneficiary.ID,
+		beneficiary.UserID,
+		beneficiary.BeneficiaryUserID,
+		beneficiary.Nickname,
+		beneficiary.CreatedAt,
+		beneficiary.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrBeneficiaryExists
+	}
+	return nil
+}
+
+// ListBeneficiaries returns the saved recipients of a user ordered by nickname.
+func (b *BankRepository) ListBeneficiaries(ctx context.Context, userID uuid.UUID) ([]entity.Beneficiary, error) {
+	query := `
+		SELECT ` + beneficiaryColumns + ` FROM beneficiary b JOIN "user" u ON u.id = b.beneficiary_user_id
+		WHERE b.user_id = $1 ORDER BY lower(b.nickname), b.id
+	`
+
+	rows, err := b.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	beneficiaries := make([]entity.Beneficiary, 0)
+	for rows.Next() {
+		beneficiary, err := scanBeneficiary(rows)
+		if err != nil {
+			return nil, err
+		}
+		beneficiaries = append(beneficiaries, beneficiary)
+	}
+	return beneficiaries, rows.Err()
+}
+
+func (b *BankRepository) GetBeneficiary(ctx context.Context, id uuid.UUID, userID uuid.UUID) (entity.Beneficiary, error) {
+	query := `
+		SELECT ` + beneficiaryColumns + ` FROM beneficiary b JOIN "user" u ON u.id = b.beneficiary_user_id
+		WHERE b.id = $1 AND b.user_id = $2
+	`
+
+	beneficiary, err := scanBeneficiary(b.db.QueryRow(ctx, query, id, userID))
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrBeneficiaryNotFound
+	}
+	return beneficiary, err
+}
+
+func (b *BankRepository) UpdateBeneficiaryNickname(ctx context.Context, id uuid.UUID, userID uuid.UUID, nickname string) error {
+	query := `update beneficiary set nickname = $1, updated_at = $2 where id = $3 and user_id = $4`
+
+	tag, err := b.db.Exec(ctx, query, nickname, time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrBeneficiaryNotFound
+	}
+	return nil
+}
+
+func (b *BankRepository) DeleteBeneficiary(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	query := `delete from beneficiary where id = $1 and user_id = $2`
+
+	tag, err := b.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrBeneficiaryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/ledger"
+	"bank-backend/module/bank/internal/limits"
+	"bank-backend/module/bank/utils"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Every hold change takes the wallet row before the hold row, so two of them never wait on each
+// other. bank-worker's expiry sweep locks both with SKIP LOCKED and never waits at all.
+
+const holdColumns = `id, user_id, amount, captured_amount, remarks, status, transaction_id, expires_at, created_at, updated_at`
+
+func scanHold(row pgx.Row) (entity.Hold, error) {
+	h := entity.Hold{}
+	err := row.Scan(
+		&h.ID,
+		&h.UserID,
+		&h.Amount,
+		&h.CapturedAmount,
+		&h.Remarks,
+		&h.Status,
+		&h.TransactionID,
+		&h.ExpiresAt,
+		&h.CreatedAt,
+		&h.UpdatedAt,
+	)
+	return h, err
+}
+
+// CreateHold reserves hold.Amount of the wallet, re-running the transaction when a concurrent write
+// wins the version check. The wallet must allow debits, have the amount available and stay within its
+// KYC tier limits.
+func (b *BankRepository) CreateHold(ctx context.Context, hold entity.Hold) error {
+	return pgsql.RetryOnConflict(ctx, b.retry, "hold", func() error {
+		return b.createHold(ctx, hold)
+	})
+}
+
+func (b *BankRepository) createHold(ctx context.Context, hold entity.Hold) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	selectUser := `select balance, held_balance, status, version from "user" where id = $1`
+	updateHeld := `update "user" set held_balance = held_balance + $1, version = version+1, updated_at = $2 where id = $3 and version = $4`
+	insertHold := `
+		INSERT INTO hold (id, user_id, amount, captured_amount, remarks, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, $4, $5, $6, $7, $8)
+	`
+
+	var balance, held, version int
+	var status string
+	err = tx.QueryRow(ctx, selectUser, hold.UserID).Scan(&balance, &held, &status, &version)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return err
+	}
+
+	if !entity.AccountAllowsDebit(status) {
+		return utils.AccountStatusError(status)
+	}
+	if balance-held < hold.Amount {
+		return pgsql.ErrBalanceNotEnough
+	}
+	if err = limits.CheckDebit(ctx, tx, hold.UserID, hold.Amount, hold.CreatedAt); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, updateHeld, hold.Amount, hold.CreatedAt, hold.UserID, version)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrConcurrentModification
+	}
+
+	_, err = tx.Exec(ctx, insertHold,
+		hold.ID,
+		hold.UserID,
+		hold.Amount,
+		hold.Remarks,
+		hold.Status,
+		hold.ExpiresAt,
+		hold.CreatedAt,
+		hold.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetHold returns the hold when it belongs to userID.
+func (b *BankRepository) GetHold(ctx context.Context, id uuid.UUID, userID uuid.UUID) (entity.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM hold WHERE id = $1 AND user_id = $2`
+
+	h, err := scanHold(b.db.QueryRow(ctx, query, id, userID))
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrHoldNotFound
+	}
+	return h, err
+}
+
+// ListHolds returns the holds of the user, newest first.
+func (b *BankRepository) ListHolds(ctx context.Context, filter entity.HoldFilter) ([]entity.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM hold WHERE user_id = $1`
+	args := []interface{}{filter.UserID}
+
+	if filter.Cursor != uuid.Nil {
+		args = append(args, filter.Cursor)
+		query += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := b.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holds := make([]entity.Hold, 0)
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, h)
+	}
+	return holds, rows.Err()
+}
+
+// CaptureHold debits user.Balance, at most hold.Amount, as a payment and frees the whole hold in the
+// same transaction, so the part not captured becomes available again. It returns ErrHoldInvalidState
+// or ErrHoldExpired when the hold stopped being ACTIVE in the meantime.
+func (b *BankRepository) CaptureHold(ctx context.Context, user entity.User, hold entity.Hold) (returningUser entity.User, prevBalance int, transactionId uuid.UUID, createdAt time.Time, err error) {
+	entry := walletEntry{
+		entryType:   ledger.EntryTypePayment,
+		account:     ledger.PaymentSettlementAccount,
+		description: hold.Remarks,
+		remarks:     hold.Remarks,
+		allow:       entity.AccountAllowsDebit,
+		release:     hold.Amount,
+		record: func(ctx context.Context, tx pgx.Tx, transactionID uuid.UUID) error {
+			return captureHold(ctx, tx, hold.ID, user.Balance, transactionID)
+		},
+	}
+
+	err = pgsql.RetryOnConflict(ctx, b.retry, "capture", func() error {
+		returningUser, prevBalance, transactionId, createdAt, err = b.updatePayment(ctx, user, entry)
+		return err
+	})
+	return returningUser, prevBalance, transactionId, createdAt, err
+}
+
+func captureHold(ctx context.Context, tx pgx.Tx, id uuid.UUID, amount int, transactionID uuid.UUID) error {
+	query := `
+		update hold set status = $1, captured_amount = $2, transaction_id = $3, updated_at = $4
+		where id = $5 and status = $6
+		returning expires_at
+	`
+
+	now := time.Now()
+	var expiresAt time.Time
+	err := tx.QueryRow(ctx, query, entity.HoldStatusCaptured, amount, transactionID, now, id, entity.HoldStatusActive).Scan(&expiresAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrHoldInvalidState
+		}
+		return err
+	}
+	if !now.Before(expiresAt) {
+		return pgsql.ErrHoldExpired
+	}
+	return nil
+}
+
+// VoidHold frees an ACTIVE hold of userID without moving any money.
+func (b *BankRepository) VoidHold(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `SELECT 1 FROM "user" WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return err
+	}
+
+	query := `
+		update hold set status = $1, updated_at = $2
+		where id = $3 and user_id = $4 and status = $5
+		returning amount
+	`
+	now := time.Now()
+	var amount int
+	err = tx.QueryRow(ctx, query, entity.HoldStatusVoided, now, id, userID, entity.HoldStatusActive).Scan(&amount)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrHoldInvalidState
+		}
+		return err
+	}
+
+	if err = releaseHeldBalance(ctx, tx, userID, amount, now); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// VoidHolds frees every ACTIVE hold of userID, for a wallet about to be closed.
+func (b *BankRepository) VoidHolds(ctx context.Context, userID uuid.UUID) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `SELECT 1 FROM "user" WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return err
+	}
+
+	query := `
+		with voided as (
+			update hold set status = $1, updated_at = $2
+			where user_id = $3 and status = $4
+			returning amount
+		)
+		SELECT coalesce(sum(amount), 0) FROM voided
+	`
+	now := time.Now()
+	var amount int
+	err = tx.QueryRow(ctx, query, entity.HoldStatusVoided, now, userID, entity.HoldStatusActive).Scan(&amount)
+	if err != nil {
+		return err
+	}
+
+	if err = releaseHeldBalance(ctx, tx, userID, amount, now); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// releaseHeldBalance takes amount off the wallet's held balance. The version moves on, so a debit
+// that read the wallet before re-checks the available balance.
+func releaseHeldBalance(ctx context.Context, tx pgx.Tx, userID uuid.UUID, amount int, now time.Time) error {
+	query := `update "user" set held_balance = held_balance - $1, version = version+1, updated_at = $2 where id = $3`
+
+	_, err := tx.Exec(ctx, query, amount, now, userID)
+	return err
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"bank-backend/module/bank/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const moneyRequestColumns = `
+	m.id, m.requester_user_id, r.phone_number, coalesce(r.first_name, ''), coalesce(r.last_name, ''),
+	m.payer_user_id, p.phone_number, coalesce(p.first_name, ''), coalesce(p.last_name, ''),
+	m.amount, m.remarks, m.status, m.transfer_id, m.expires_at, m.responded_at, m.created_at, m.updated_at
+`
+
+const moneyRequestJoins = `
+	money_request m
+		JOIN "user" r ON r.id = m.requester_user_id
+		JOIN "user" p ON p.id = m.payer_user_id
+`
+
+func scanMoneyRequest(row pgx.Row) (entity.MoneyRequest, error) {
+	m := entity.MoneyRequest{}
+	err := row.Scan(
+		&m.ID,
+		&m.RequesterUserID,
+		&m.RequesterPhoneNumber,
+		&m.RequesterFirstName,
+		&m.RequesterLastName,
+		&m.PayerUserID,
+		&m.PayerPhoneNumber,
+		&m.PayerFirstName,
+		&m.PayerLastName,
+		&m.Amount,
+		&m.Remarks,
+		&m.Status,
+		&m.TransferID,
+		&m.ExpiresAt,
+		&m.RespondedAt,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+	)
+	return m, err
+}
+
+// CreateMoneyRequest stores a PENDING money request together with the outbox message announcing it.
+func (b *BankRepository) CreateMoneyRequest(ctx context.Context, request entity.MoneyRequest, message entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO money_request (id, requester_user_id, payer_user_id, amount, remarks, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = tx.Exec(ctx, query,
+		request.ID,
+		request.RequesterUserID,
+		request.PayerUserID,
+		request.Amount,
+		request.Remarks,
+		request.Status,
+		request.ExpiresAt,
+		request.CreatedAt,
+		request.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = insertOutboxMessage(ctx, tx, message); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeclineMoneyRequest moves a pending money request to DECLINED and stores the outbox message
+// announcing it.
+func (b *BankRepository) DeclineMoneyRequest(ctx context.Context, request entity.MoneyRequest, message entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = respondMoneyRequest(ctx, tx, request); err != nil {
+		return err
+	}
+
+	if err = insertOutboxMessage(ctx, tx, message); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AcceptMoneyRequest moves a pending money request to ACCEPTED and stores the PENDING transfer paying
+// it, the TransferEvent and the outbox message announcing the acceptance in one transaction.
+func (b *BankRepository) AcceptMoneyRequest(ctx context.Context, request entity.MoneyRequest, transfer entity.Transfer, transferMessage entity.OutboxMessage, message entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = respondMoneyRequest(ctx, tx, request); err != nil {
+		return err
+	}
+
+	if err = insertTransfer(ctx, tx, transfer); err != nil {
+		return err
+	}
+
+	if err = insertOutboxMessage(ctx, tx, transferMessage); err != nil {
+		return err
+	}
+
+	if err = insertOutboxMessage(ctx, tx, message); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// respondMoneyRequest writes the payer's answer when the request is still pending and not expired,
+// and returns ErrMoneyRequestInvalidState otherwise.
+func respondMoneyRequest(ctx context.Context, tx pgx.Tx, request entity.MoneyRequest) error {
+	query := `
+		update money_request set status = $1, transfer_id = $2, responded_at = $3, updated_at = $3
+		where id = $4 and payer_user_id = $5 and status = $6 and expires_at > $3
+	`
+	tag, err := tx.Exec(ctx, query,
+		request.Status,
+		request.TransferID,
+		request.RespondedAt,
+		request.ID,
+		request.PayerUserID,
+		entity.MoneyRequestStatusPending,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrMoneyRequestInvalidState
+	}
+	return nil
+}
+
+// GetMoneyRequest returns the money request when userID is either the requester or the payer.
+func (b *BankRepository) GetMoneyRequest(ctx context.Context, id uuid.UUID, userID uuid.UUID) (entity.MoneyRequest, error) {
+	query := `SELECT ` + moneyRequestColumns + ` FROM ` + moneyRequestJoins + ` WHERE m.id = $1 AND (m.requester_user_id = $2 OR m.payer_user_id = $2)`
+
+	m, err := scanMoneyRequest(b.db.QueryRow(ctx, query, id, userID))
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrMoneyRequestNotFound
+	}
+	return m, err
+}
+
+// ListMoneyRequests returns the money requests the user received or sent, newest first.
+func (b *BankRepository) ListMoneyRequests(ctx context.Context, filter entity.MoneyRequestFilter) ([]entity.MoneyRequest, error) {
+	query := `SELECT ` + moneyRequestColumns + ` FROM ` + moneyRequestJoins
+	if filter.Direction == entity.MoneyRequestDirectionIncoming {
+		query += ` WHERE m.payer_user_id = $1`
+	} else {
+		query += ` WHERE m.requester_user_id = $1`
+	}
+	args := []interface{}{filter.UserID}
+
+	if filter.Cursor != uuid.Nil {
+		args = append(args, filter.Cursor)
+		query += fmt.Sprintf(" AND m.id < $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND m.status = $%d", len(args))
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY m.id DESC LIMIT $%d", len(args))
+
+	rows, err := b.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := make([]entity.MoneyRequest, 0)
+	for rows.Next() {
+		m, err := scanMoneyRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, m)
+	}
+	return requests, rows.Err()
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"bank-backend/module/bank/entity"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func insertOutboxMessage(ctx context.Context, tx pgx.Tx, message entity.OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (id, aggregate_id, topic, message_key, payload, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6)
+	`
+	_, err := tx.Exec(ctx, query,
+		message.ID,
+		message.AggregateID,
+		message.Topic,
+		message.Key,
+		message.Payload,
+		message.CreatedAt,
+	)
+	return err
+}
+
+// outboxClaimLease is how long a relay owns the messages it claimed. Messages claimed by a relay that
+// died are picked up again once it has passed.
+const outboxClaimLease = time.Minute
+
+// RelayOutbox claims up to limit unsent messages, hands each one to publish and marks it sent. Rows
+// are claimed with SKIP LOCKED and the claim is committed before publishing, so no row lock is held
+// while Kafka is slow and several backend instances can relay concurrently. A message is only marked
+// sent after publish succeeds, which gives at-least-once delivery.
+func (b *BankRepository) RelayOutbox(ctx context.Context, limit int, publish func(entity.OutboxMessage) error) (int, int, error) {
+	messages, err := b.claimOutbox(ctx, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	markSent := `update outbox set sent_at = $1, attempts = attempts + 1, last_error = null, claimed_until = null where id = $2`
+	markFailed := `update outbox set attempts = attempts + 1, last_error = $1, claimed_until = null where id = $2`
+
+	var sent, failed int
+	for _, m := range messages {
+		if publishErr := publish(m); publishErr != nil {
+			failed++
+			if _, err = b.db.Exec(ctx, markFailed, publishErr.Error(), m.ID); err != nil {
+				return sent, failed, err
+			}
+			continue
+		}
+		sent++
+		if _, err = b.db.Exec(ctx, markSent, time.Now(), m.ID); err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, nil
+}
+
+// claimOutbox stamps up to limit unsent messages that nobody holds a claim on with a claim expiring
+// after outboxClaimLease and returns them, oldest first.
+func (b *BankRepository) claimOutbox(ctx context.Context, limit int) ([]entity.OutboxMessage, error) {
+	query := `
+		update outbox set claimed_until = $1
+		where id in (
+			SELECT id FROM outbox
+			WHERE sent_at IS NULL AND (claimed_until IS NULL OR claimed_until < $2)
+			ORDER BY created_at LIMIT $3 FOR UPDATE SKIP LOCKED
+		)
+		returning id, aggregate_id, topic, message_key, payload, attempts, created_at
+	`
+
+	now := time.Now()
+	rows, err := b.db.Query(ctx, query, now.Add(outboxClaimLease), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []entity.OutboxMessage{}
+	for rows.Next() {
+		m := entity.OutboxMessage{}
+		err = rows.Scan(&m.ID, &m.AggregateID, &m.Topic, &m.Key, &m.Payload, &m.Attempts, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// returning does not keep the subquery order
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	return messages, nil
+}
+
+// OutboxBacklog returns the number of unsent messages and the age of the oldest one.
+func (b *BankRepository) OutboxBacklog(ctx context.Context) (int, time.Duration, error) {
+	query := `SELECT count(*), coalesce(extract(epoch from localtimestamp - min(created_at)), 0) FROM outbox WHERE sent_at IS NULL`
+
+	var count int
+	var oldestAge float64
+	err := b.db.QueryRow(ctx, query).Scan(&count, &oldestAge)
+	if err != nil {
+		return 0, 0, err
+	}
+	return count, time.Duration(oldestAge * float64(time.Second)), nil
+}
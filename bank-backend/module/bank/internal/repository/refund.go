@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/internal/ledger"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetPayment returns the DEBIT transaction posted by the payment id with what has been refunded of it.
+// Transactions posted by anything other than a payment are not found.
+func (b *BankRepository) GetPayment(ctx context.Context, id uuid.UUID) (entity.Payment, error) {
+	payment := entity.Payment{}
+	query := `
+		SELECT t.id, t.user_id, u.phone_number, t.amount, coalesce(t.remarks, ''), t.created_at,
+			coalesce((SELECT sum(r.amount) FROM payment_refund r WHERE r.payment_id = t.id), 0)
+		FROM transaction t
+			JOIN journal_entry j ON j.reference_id = t.id AND j.entry_type = $2
+			JOIN "user" u ON u.id = t.user_id
+		WHERE t.id = $1 AND t.transaction_type = 'DEBIT'
+	`
+
+	err := b.db.QueryRow(ctx, query, id, ledger.EntryTypePayment).Scan(
+		&payment.ID,
+		&payment.UserID,
+		&payment.PhoneNumber,
+		&payment.Amount,
+		&payment.Remarks,
+		&payment.CreatedAt,
+		&payment.RefundedAmount,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrPaymentNotFound
+		}
+		return payment, err
+	}
+	return payment, nil
+}
+
+// RefundPayment credits the refund to user's wallet from the payment settlement account through the
+// same transaction as a top-up, storing the refund with it. The payment is locked while its refunds
+// are summed, so concurrent refunds can never exceed it; they return ErrRefundExceedsPayment.
+func (b *BankRepository) RefundPayment(ctx context.Context, user entity.User, refund entity.PaymentRefund) (returningUser entity.User, prevBalance int, transactionId uuid.UUID, createdAt time.Time, err error) {
+	entry := walletEntry{
+		entryType:   ledger.EntryTypeRefund,
+		account:     ledger.PaymentSettlementAccount,
+		description: refund.Reason,
+		remarks:     "refund",
+		allow:       entity.AccountAllowsAdjustment,
+		reference:   &refund.PaymentID,
+		record: func(ctx context.Context, tx pgx.Tx, transactionID uuid.UUID) error {
+			refund.TransactionID = transactionID
+			return insertPaymentRefund(ctx, tx, refund)
+		},
+	}
+
+	err = pgsql.RetryOnConflict(ctx, b.retry, "refund", func() error {
+		returningUser, prevBalance, transactionId, createdAt, err = b.updateTopUp(ctx, user, entry)
+		return err
+	})
+	return returningUser, prevBalance, transactionId, createdAt, err
+}
+
+func insertPaymentRefund(ctx context.Context, tx pgx.Tx, refund entity.PaymentRefund) error {
+	lockPayment := `SELECT amount FROM transaction WHERE id = $1 AND transaction_type = 'DEBIT' FOR UPDATE`
+	sumRefunds := `SELECT coalesce(sum(amount), 0) FROM payment_refund WHERE payment_id = $1`
+	insertRefund := `
+		INSERT INTO payment_refund (id, payment_id, transaction_id, user_id, amount, reason, admin_phone_number, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	var amount, refunded int
+	err := tx.QueryRow(ctx, lockPayment, refund.PaymentID).Scan(&amount)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrPaymentNotFound
+		}
+		return err
+	}
+	if err = tx.QueryRow(ctx, sumRefunds, refund.PaymentID).Scan(&refunded); err != nil {
+		return err
+	}
+	if refunded+refund.Amount > amount {
+		return pgsql.ErrRefundExceedsPayment
+	}
+
+	_, err = tx.Exec(ctx, insertRefund,
+		refund.ID,
+		refund.PaymentID,
+		refund.TransactionID,
+		refund.UserID,
+		refund.Amount,
+		refund.Reason,
+		refund.AdminPhoneNumber,
+		refund.CreatedAt,
+	)
+	return err
+}
+
+// GetReversibleTransfer returns the transfer with the amount of its reversals that have not failed.
+func (b *BankRepository) GetReversibleTransfer(ctx context.Context, id uuid.UUID) (entity.Transfer, int, error) {
+	transfer := entity.Transfer{}
+	query := `
+		SELECT id, user_id, target_user_id, amount, coalesce(remarks, ''), status, coalesce(failure_reason, ''), created_at, updated_at
+		FROM transfer WHERE id = $1
+	`
+
+	err := b.db.QueryRow(ctx, query, id).Scan(
+		&transfer.ID,
+		&transfer.UserID,
+		&transfer.TargetUserID,
+		&transfer.Amount,
+		&transfer.Remarks,
+		&transfer.Status,
+		&transfer.FailureReason,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrTransferNotFound
+		}
+		return transfer, 0, err
+	}
+
+	reversed, err := sumTransferReversals(ctx, b.db, id)
+	if err != nil {
+		return transfer, 0, err
+	}
+	return transfer, reversed, nil
+}
+
+// ReverseTransfer stores the reversal with the PENDING transfer carrying it out and its
+// TransferEvent. The reversed transfer is locked while its reversals are summed, so concurrent
+// reversals can never exceed it. It returns ErrTransferNotReversible unless the transfer is COMPLETED
+// and not a reversal itself.
+func (b *BankRepository) ReverseTransfer(ctx context.Context, reversal entity.TransferReversal, transfer entity.Transfer, message entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	lockTransfer := `
+		SELECT t.amount, t.status, EXISTS (SELECT 1 FROM transfer_reversal r WHERE r.id = t.id)
+		FROM transfer t WHERE t.id = $1
+		FOR UPDATE OF t
+	`
+	insertReversal := `
+		INSERT INTO transfer_reversal (id, transfer_id, amount, reason, admin_phone_number, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	var (
+		amount     int
+		status     string
+		isReversal bool
+	)
+	err = tx.QueryRow(ctx, lockTransfer, reversal.TransferID).Scan(&amount, &status, &isReversal)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrTransferNotFound
+		}
+		return err
+	}
+	if status != entity.TransferStatusCompleted || isReversal {
+		return pgsql.ErrTransferNotReversible
+	}
+
+	reversed, err := sumTransferReversals(ctx, tx, reversal.TransferID)
+	if err != nil {
+		return err
+	}
+	if reversed+reversal.Amount > amount {
+		return pgsql.ErrReversalExceedsTransfer
+	}
+
+	if err = insertTransfer(ctx, tx, transfer); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, insertReversal,
+		reversal.ID,
+		reversal.TransferID,
+		reversal.Amount,
+		reversal.Reason,
+		reversal.AdminPhoneNumber,
+		reversal.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = insertOutboxMessage(ctx, tx, message); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// sumTransferReversals adds up the reversals of transferID that are pending or completed. A failed
+// reversal moved nothing, so its amount can be reversed again.
+func sumTransferReversals(ctx context.Context, q querier, transferID uuid.UUID) (int, error) {
+	query := `
+		SELECT coalesce(sum(t.amount), 0)
+		FROM transfer_reversal r JOIN transfer t ON t.id = r.id
+		WHERE r.transfer_id = $1 AND t.status <> $2
+	`
+
+	var reversed int
+	err := q.QueryRow(ctx, query, transferID, entity.TransferStatusFailed).Scan(&reversed)
+	return reversed, err
+}
+
+// querier accepts either the pool or a running transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
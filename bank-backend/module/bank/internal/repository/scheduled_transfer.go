@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/bank/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const scheduledTransferColumns = `
+	id, user_id, target_user_id, amount, remarks, frequency, coalesce(day_of_month, 0), start_at, end_at,
+	next_run_at, last_run_at, status, created_at, updated_at
+`
+
+func scanScheduledTransfer(row pgx.Row) (entity.ScheduledTransfer, error) {
+	s := entity.ScheduledTransfer{}
+	err := row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.TargetUserID,
+		&s.Amount,
+		&s.Remarks,
+		&s.Frequency,
+		&s.DayOfMonth,
+		&s.StartAt,
+		&s.EndAt,
+		&s.NextRunAt,
+		&s.LastRunAt,
+		&s.Status,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	return s, err
+}
+
+func (b *BankRepository) CreateScheduledTransfer(ctx context.Context, s entity.ScheduledTransfer) error {
+	query := `
+		INSERT INTO scheduled_transfer (id, user_id, target_user_id, amount, remarks, frequency, day_of_month, start_at, end_at,
+			next_run_at, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	_, err := b.db.Exec(ctx, query,
+		s.ID,
+		s.UserID,
+		s.TargetUserID,
+		s.Amount,
+		s.Remarks,
+		s.Frequency,
+		s.DayOfMonth,
+		s.StartAt,
+		s.EndAt,
+		s.NextRunAt,
+		s.Status,
+		s.CreatedAt,
+		s.UpdatedAt,
+	)
+	return err
+}
+
+func (b *BankRepository) ListScheduledTransfers(ctx context.Context, userID uuid.UUID) ([]entity.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfer WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := b.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]entity.ScheduledTransfer, 0)
+	for rows.Next() {
+		s, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+func (b *BankRepository) GetScheduledTransfer(ctx context.Context, id uuid.UUID, userID uuid.UUID) (entity.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfer WHERE id = $1 AND user_id = $2`
+
+	s, err := scanScheduledTransfer(b.db.QueryRow(ctx, query, id, userID))
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrScheduledTransferNotFound
+	}
+	return s, err
+}
+
+// ListScheduledTransferRuns returns the latest runs of a schedule, newest first.
+func (b *BankRepository) ListScheduledTransferRuns(ctx context.Context, scheduleID uuid.UUID, limit int) ([]entity.ScheduledTransferRun, error) {
+	query := `
+		SELECT id, transfer_id, scheduled_for, attempt, status, coalesce(failure_reason, ''), next_retry_at, created_at
+		FROM scheduled_transfer_run WHERE scheduled_transfer_id = $1 ORDER BY created_at DESC LIMIT $2
+	`
+
+	rows, err := b.db.Query(ctx, query, scheduleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := make([]entity.ScheduledTransferRun, 0)
+	for rows.Next() {
+		r := entity.ScheduledTransferRun{}
+		err = rows.Scan(&r.ID, &r.TransferID, &r.ScheduledFor, &r.Attempt, &r.Status, &r.FailureReason, &r.NextRetryAt, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// UpdateScheduledTransfer replaces the definition of an active or paused schedule. It returns
+// ErrScheduledTransferInvalidState when the schedule was cancelled or completed in the meantime.
+func (b *BankRepository) UpdateScheduledTransfer(ctx context.Context, s entity.ScheduledTransfer) error {
+	query := `
+		update scheduled_transfer set target_user_id = $1, amount = $2, remarks = $3, frequency = $4, day_of_month = $5,
+			start_at = $6, end_at = $7, next_run_at = $8, updated_at = $9
+		where id = $10 and user_id = $11 and status in ($12, $13)
+	`
+	tag, err := b.db.Exec(ctx, query,
+		s.TargetUserID,
+		s.Amount,
+		s.Remarks,
+		s.Frequency,
+		s.DayOfMonth,
+		s.StartAt,
+		s.EndAt,
+		s.NextRunAt,
+		s.UpdatedAt,
+		s.ID,
+		s.UserID,
+		entity.ScheduleStatusActive,
+		entity.ScheduleStatusPaused,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrScheduledTransferInvalidState
+	}
+	return nil
+}
+
+// UpdateScheduledTransferStatus moves the schedule to status when it is currently in one of from,
+// and returns ErrScheduledTransferInvalidState otherwise.
+func (b *BankRepository) UpdateScheduledTransferStatus(ctx context.Context, id uuid.UUID, userID uuid.UUID, from []string, status string, nextRunAt *time.Time) error {
+	query := `
+		update scheduled_transfer set status = $1, next_run_at = $2, updated_at = $3
+		where id = $4 and user_id = $5 and status = any($6)
+	`
+	tag, err := b.db.Exec(ctx, query, status, nextRunAt, time.Now(), id, userID, from)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrScheduledTransferInvalidState
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bank-backend/module/bank/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const splitBillColumns = `
+	b.id, b.organizer_user_id, o.phone_number, coalesce(o.first_name, ''), coalesce(o.last_name, ''), b.title,
+	b.total_amount, b.split_type, b.status, b.created_at, b.updated_at, b.settled_at
+`
+
+func scanSplitBill(row pgx.Row) (entity.SplitBill, error) {
+	b := entity.SplitBill{}
+	err := row.Scan(
+		&b.ID,
+		&b.OrganizerUserID,
+		&b.OrganizerPhoneNumber,
+		&b.OrganizerFirstName,
+		&b.OrganizerLastName,
+		&b.Title,
+		&b.TotalAmount,
+		&b.SplitType,
+		&b.Status,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+		&b.SettledAt,
+	)
+	return b, err
+}
+
+// CreateSplitBill stores an OPEN bill with its participants and the outbox messages announcing it.
+func (b *BankRepository) CreateSplitBill(ctx context.Context, bill entity.SplitBill, messages []entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	insertBill := `
+		INSERT INTO split_bill (id, organizer_user_id, title, total_amount, split_type, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	insertParticipant := `
+		INSERT INTO split_bill_participant (id, split_bill_id, user_id, share_amount, status, paid_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = tx.Exec(ctx, insertBill,
+		bill.ID,
+		bill.OrganizerUserID,
+		bill.Title,
+		bill.TotalAmount,
+		bill.SplitType,
+		bill.Status,
+		bill.CreatedAt,
+		bill.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range bill.Participants {
+		_, err = tx.Exec(ctx, insertParticipant, p.ID, bill.ID, p.UserID, p.ShareAmount, p.Status, p.PaidAt, p.CreatedAt, p.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, message := range messages {
+		if err = insertOutboxMessage(ctx, tx, message); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetSplitBill returns the bill with its participants when userID organizes it or takes part in it.
+func (b *BankRepository) GetSplitBill(ctx context.Context, id uuid.UUID, userID uuid.UUID) (entity.SplitBill, error) {
+	query := `
+		SELECT ` + splitBillColumns + ` FROM split_bill b JOIN "user" o ON o.id = b.organizer_user_id
+		WHERE b.id = $1 AND (b.organizer_user_id = $2
+			OR EXISTS (SELECT 1 FROM split_bill_participant p WHERE p.split_bill_id = b.id AND p.user_id = $2))
+	`
+
+	bill, err := scanSplitBill(b.db.QueryRow(ctx, query, id, userID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrSplitBillNotFound
+		}
+		return bill, err
+	}
+
+	bills := []entity.SplitBill{bill}
+	if err = b.loadSplitBillParticipants(ctx, bills); err != nil {
+		return bill, err
+	}
+	return bills[0], nil
+}
+
+// ListSplitBills returns the bills the user organizes or takes part in, newest first.
+func (b *BankRepository) ListSplitBills(ctx context.Context, filter entity.SplitBillFilter) ([]entity.SplitBill, error) {
+	query := `
+		SELECT ` + splitBillColumns + ` FROM split_bill b JOIN "user" o ON o.id = b.organizer_user_id
+		WHERE (b.organizer_user_id = $1
+			OR EXISTS (SELECT 1 FROM split_bill_participant p WHERE p.split_bill_id = b.id AND p.user_id = $1))
+	`
+	args := []interface{}{filter.UserID}
+
+	if filter.Cursor != uuid.Nil {
+		args = append(args, filter.Cursor)
+		query += fmt.Sprintf(" AND b.id < $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND b.status = $%d", len(args))
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY b.id DESC LIMIT $%d", len(args))
+
+	rows, err := b.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	bills := make([]entity.SplitBill, 0)
+	for rows.Next() {
+		bill, err := scanSplitBill(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bills = append(bills, bill)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err = b.loadSplitBillParticipants(ctx, bills); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
+
+// loadSplitBillParticipants fills in the participants of bills with one query.
+func (b *BankRepository) loadSplitBillParticipants(ctx context.Context, bills []entity.SplitBill) error {
+	if len(bills) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT p.id, p.split_bill_id, p.user_id, u.phone_number, coalesce(u.first_name, ''), coalesce(u.last_name, ''),
+			p.share_amount, p.status, p.transfer_id, coalesce(p.last_failure_reason, ''), p.last_reminded_at, p.paid_at,
+			p.created_at, p.updated_at
+		FROM split_bill_participant p JOIN "user" u ON u.id = p.user_id
+		WHERE p.split_bill_id = any($1)
+		ORDER BY p.split_bill_id, p.created_at, p.id
+	`
+
+	ids := make([]uuid.UUID, 0, len(bills))
+	index := make(map[uuid.UUID]int, len(bills))
+	for i, bill := range bills {
+		ids = append(ids, bill.ID)
+		index[bill.ID] = i
+		bills[i].Participants = make([]entity.SplitBillParticipant, 0)
+	}
+
+	rows, err := b.db.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p := entity.SplitBillParticipant{}
+		err = rows.Scan(&p.ID, &p.SplitBillID, &p.UserID, &p.PhoneNumber, &p.FirstName, &p.LastName, &p.ShareAmount,
+			&p.Status, &p.TransferID, &p.LastFailureReason, &p.LastRemindedAt, &p.PaidAt, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		i := index[p.SplitBillID]
+		bills[i].Participants = append(bills[i].Participants, p)
+	}
+	return rows.Err()
+}
+
+// PaySplitBillShare moves a pending share of an open bill to PROCESSING and stores the PENDING
+// transfer paying it with its TransferEvent. It returns ErrSplitBillShareInvalidState when the share
+// is already being paid or the bill is no longer open.
+func (b *BankRepository) PaySplitBillShare(ctx context.Context, participant entity.SplitBillParticipant, transfer entity.Transfer, transferMessage entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		update split_bill_participant p set status = $1, transfer_id = $2, updated_at = $3
+		from split_bill b
+		where p.id = $4 and p.status = $5 and b.id = p.split_bill_id and b.status = $6
+	`
+	tag, err := tx.Exec(ctx, query,
+		entity.SplitShareStatusProcessing,
+		participant.TransferID,
+		participant.UpdatedAt,
+		participant.ID,
+		entity.SplitShareStatusPending,
+		entity.SplitBillStatusOpen,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrSplitBillShareInvalidState
+	}
+
+	if err = insertTransfer(ctx, tx, transfer); err != nil {
+		return err
+	}
+
+	if err = insertOutboxMessage(ctx, tx, transferMessage); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RecordSplitBillReminders stamps the reminded shares and stores the reminder outbox messages.
+func (b *BankRepository) RecordSplitBillReminders(ctx context.Context, participantIDs []uuid.UUID, remindedAt time.Time, messages []entity.OutboxMessage) error {
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `update split_bill_participant set last_reminded_at = $1, updated_at = $1 where id = any($2)`
+	_, err = tx.Exec(ctx, query, remindedAt, participantIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err = insertOutboxMessage(ctx, tx, message); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -4,9 +4,12 @@ import (
 	"bank-backend/module/bank/entity"
 	"bank-backend/module/bank/internal/repository"
 	"bank-backend/module/bank/utils"
+	userclient "bank-backend/module/user/client"
+	userentity "bank-backend/module/user/entity"
 	"bank-backend/pkg"
 	utls "bank-backend/utils"
 	"bank-backend/utils/pgsql"
+	"bank-backend/utils/response"
 	"log/slog"
 	"time"
 
@@ -18,15 +21,56 @@ type BankUseCase interface {
 	Topup(ctx fiber.Ctx, request entity.TopUpRequest, userPhoneNumber string) (entity.TopUpResponse, error)
 	Payment(ctx fiber.Ctx, request entity.PaymentRequest, userPhoneNumber string) (entity.PaymentResponse, error)
 	Transfer(ctx fiber.Ctx, request entity.TransferRequest, userPhoneNumber string) (entity.TransferResponse, error)
+	Balance(ctx fiber.Ctx, userPhoneNumber string) (entity.BalanceResponse, error)
+	TransactionHistory(ctx fiber.Ctx, request entity.TransactionHistoryRequest, userPhoneNumber string) (*response.ListResponse, error)
+	TransferStatus(ctx fiber.Ctx, transferID string, userPhoneNumber string) (entity.TransferStatusResponse, error)
+	CreateScheduledTransfer(ctx fiber.Ctx, request entity.ScheduledTransferRequest, userPhoneNumber string) (entity.ScheduledTransferResponse, error)
+	ListScheduledTransfers(ctx fiber.Ctx, userPhoneNumber string) ([]entity.ScheduledTransferResponse, error)
+	GetScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error)
+	UpdateScheduledTransfer(ctx fiber.Ctx, scheduleID string, request entity.ScheduledTransferRequest, userPhoneNumber string) (entity.ScheduledTransferResponse, error)
+	PauseScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error)
+	ResumeScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error)
+	CancelScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error)
+	RecipientPreview(ctx fiber.Ctx, phoneNumber string) (entity.RecipientPreviewResponse, error)
+	CreateBeneficiary(ctx fiber.Ctx, request entity.BeneficiaryRequest, userPhoneNumber string) (entity.BeneficiaryResponse, error)
+	ListBeneficiaries(ctx fiber.Ctx, userPhoneNumber string) ([]entity.BeneficiaryResponse, error)
+	GetBeneficiary(ctx fiber.Ctx, beneficiaryID string, userPhoneNumber string) (entity.BeneficiaryResponse, error)
+	UpdateBeneficiary(ctx fiber.Ctx, beneficiaryID string, request entity.UpdateBeneficiaryRequest, userPhoneNumber string) (entity.BeneficiaryResponse, error)
+	DeleteBeneficiary(ctx fiber.Ctx, beneficiaryID string, userPhoneNumber string) (entity.BeneficiaryResponse, error)
+	CreateMoneyRequest(ctx fiber.Ctx, request entity.MoneyRequestRequest, userPhoneNumber string) (entity.MoneyRequestResponse, error)
+	ListMoneyRequests(ctx fiber.Ctx, direction string, request entity.MoneyRequestListRequest, userPhoneNumber string) (*response.ListResponse, error)
+	GetMoneyRequest(ctx fiber.Ctx, moneyRequestID string, userPhoneNumber string) (entity.MoneyRequestResponse, error)
+	AcceptMoneyRequest(ctx fiber.Ctx, moneyRequestID string, request entity.AcceptMoneyRequestRequest, userPhoneNumber string) (entity.MoneyRequestResponse, error)
+	DeclineMoneyRequest(ctx fiber.Ctx, moneyRequestID string, userPhoneNumber string) (entity.MoneyRequestResponse, error)
+	CreateSplitBill(ctx fiber.Ctx, request entity.SplitBillRequest, userPhoneNumber string) (entity.SplitBillResponse, error)
+	ListSplitBills(ctx fiber.Ctx, request entity.SplitBillListRequest, userPhoneNumber string) (*response.ListResponse, error)
+	GetSplitBill(ctx fiber.Ctx, splitBillID string, userPhoneNumber string) (entity.SplitBillResponse, error)
+	PaySplitBillShare(ctx fiber.Ctx, splitBillID string, request entity.PaySplitBillRequest, userPhoneNumber string) (entity.SplitBillResponse, error)
+	RemindSplitBill(ctx fiber.Ctx, splitBillID string, userPhoneNumber string) (entity.SplitBillReminderResponse, error)
+	CreateHold(ctx fiber.Ctx, request entity.HoldRequest, userPhoneNumber string) (entity.HoldResponse, error)
+	ListHolds(ctx fiber.Ctx, request entity.HoldListRequest, userPhoneNumber string) (*response.ListResponse, error)
+	GetHold(ctx fiber.Ctx, holdID string, userPhoneNumber string) (entity.HoldResponse, error)
+	CaptureHold(ctx fiber.Ctx, holdID string, request entity.CaptureHoldRequest, userPhoneNumber string) (entity.HoldResponse, error)
+	VoidHold(ctx fiber.Ctx, holdID string, userPhoneNumber string) (entity.HoldResponse, error)
+	AdjustBalance(ctx fiber.Ctx, request entity.BalanceAdjustmentRequest, userID uuid.UUID, adminPhoneNumber string) (entity.BalanceAdjustmentResponse, error)
+	PayoutBalance(ctx fiber.Ctx, userID uuid.UUID, payoutUserID uuid.UUID) (entity.ClosurePayoutResponse, error)
+	RefundPayment(ctx fiber.Ctx, paymentID uuid.UUID, request entity.RefundRequest, adminPhoneNumber string) (entity.RefundResponse, error)
+	ReverseTransfer(ctx fiber.Ctx, transferID uuid.UUID, request entity.TransferReversalRequest, adminPhoneNumber string) (entity.TransferReversalResponse, error)
 }
 
+const defaultTransactionHistoryLimit = 20
+
 type BankUC struct {
 	bankRepo        repository.BankRepository
 	processTransfer ProcessTransferQueue
+	moneyRequests   MoneyRequestQueue
+	splitBills      SplitBillQueue
+	users           *userclient.UserClient
+	stepUpThreshold int
 }
 
-func NewBankUseCase(bankRepo repository.BankRepository, processTransfer ProcessTransferQueue) *BankUC {
-	return &BankUC{bankRepo: bankRepo, processTransfer: processTransfer}
+func NewBankUseCase(bankRepo repository.BankRepository, processTransfer ProcessTransferQueue, moneyRequests MoneyRequestQueue, splitBills SplitBillQueue, users *userclient.UserClient, stepUpThreshold int) *BankUC {
+	return &BankUC{bankRepo: bankRepo, processTransfer: processTransfer, moneyRequests: moneyRequests, splitBills: splitBills, users: users, stepUpThreshold: stepUpThreshold}
 }
 
 func (b *BankUC) Topup(ctx fiber.Ctx, request entity.TopUpRequest, userPhoneNumber string) (entity.TopUpResponse, error) {
@@ -43,6 +87,12 @@ func (b *BankUC) Topup(ctx fiber.Ctx, request entity.TopUpRequest, userPhoneNumb
 	* ----------------------------------*/
 	lf = append(lf, pkg.LogEventState(lvState2))
 
+	if err := b.checkAccountStatus(ctx, userPhoneNumber, entity.AccountAllowsCredit); err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TopUpResponse{}, err
+	}
+
 	u := entity.User{
 		UpdatedAt:   time.Now(),
 		Balance:     request.Amount,
@@ -80,13 +130,64 @@ func (b *BankUC) Payment(ctx fiber.Ctx, request entity.PaymentRequest, userPhone
 	* ----------------------------------*/
 	lf = append(lf, pkg.LogEventState(lvState2))
 
+	payer, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.PaymentResponse{}, err
+	}
+	if !entity.AccountAllowsDebit(payer.Status) {
+		err = utils.AccountStatusError(payer.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "account does not allow debit", err, lf)
+		return entity.PaymentResponse{}, err
+	}
+	if payer.AvailableBalance() < request.Amount {
+		err = pgsql.ErrBalanceNotEnough
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "balance is not enough", err, lf)
+		return entity.PaymentResponse{}, err
+	}
+	err = b.bankRepo.CheckPaymentLimits(ctx.Context(), payer.ID, request.Amount)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "payment exceeds a limit", err, lf)
+		return entity.PaymentResponse{}, err
+	}
+
+	if request.PaymentReference == "" && b.stepUpRequired(request.Amount) {
+		err = pgsql.ErrPaymentReferenceRequired
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.PaymentResponse{}, err
+	}
+	if request.PaymentReference != "" {
+		paid, err := b.bankRepo.PaymentReferenceExists(ctx.Context(), payer.ID, request.PaymentReference)
+		if err == nil && paid {
+			err = pgsql.ErrPaymentReferenceUsed
+		}
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.PaymentResponse{}, err
+		}
+	}
+
+	// the token is spent last, so a payment rejected above can be retried with it
+	err = b.checkStepUp(ctx, userPhoneNumber, request.AuthorizationToken, userentity.TransactionOperationPayment, request.Amount, request.PaymentReference)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.PaymentResponse{}, err
+	}
+
 	u := entity.User{
 		UpdatedAt:   time.Now(),
 		Balance:     request.Amount,
 		PhoneNumber: userPhoneNumber,
 	}
 
-	user, prev, tid, createdAt, err := b.bankRepo.UpdatePayment(ctx.Context(), u, request.Remarks)
+	user, prev, tid, createdAt, err := b.bankRepo.UpdatePayment(ctx.Context(), u, request.Remarks, request.PaymentReference)
 	if err != nil {
 		if err == pgsql.ErrBalanceNotEnough {
 			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
@@ -135,30 +236,55 @@ func (b *BankUC) Transfer(ctx fiber.Ctx, request entity.TransferRequest, userPho
 		return entity.TransferResponse{}, err
 	}
 
-	if originUser.Balance < request.Amount {
+	if !entity.AccountAllowsDebit(originUser.Status) {
+		err = utils.AccountStatusError(originUser.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "account does not allow debit", err, lf)
+		return entity.TransferResponse{}, err
+	}
+
+	if originUser.AvailableBalance() < request.Amount {
+		err = pgsql.ErrBalanceNotEnough
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), "balance is not enough", err, lf)
 		return entity.TransferResponse{}, err
 	}
 
 	//check destination user
-	parse, err := uuid.Parse(request.TargetUser)
+	targetUser, err := b.resolveTransferTarget(ctx, request, originUser.ID)
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
 		return entity.TransferResponse{}, err
 	}
-	_, err = b.bankRepo.CheckIfUserExistByID(ctx.Context(), parse)
+	if targetUser.ID == originUser.ID {
+		err = pgsql.ErrTransferSelf
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferResponse{}, err
+	}
+	if !entity.AccountAllowsCredit(targetUser.Status) {
+		err = utils.AccountStatusError(targetUser.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "target account does not allow credit", err, lf)
+		return entity.TransferResponse{}, err
+	}
+
+	err = b.bankRepo.CheckTransferLimits(ctx.Context(), originUser.ID, targetUser.ID, request.Amount, targetUser.Balance)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "transfer exceeds a limit", err, lf)
+		return entity.TransferResponse{}, err
+	}
+
+	// the token is spent last, so a transfer rejected above can be retried with it
+	err = b.checkStepUp(ctx, userPhoneNumber, request.AuthorizationToken, userentity.TransactionOperationTransfer, request.Amount, request.StepUpTarget())
 	if err != nil {
-		if err == pgsql.ErrUserNotFound {
-			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
-			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
-			return entity.TransferResponse{}, err
-		}
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
 		return entity.TransferResponse{}, err
 	}
+	request.TargetUser = targetUser.ID.String()
 
 	lf = append(lf,
 		pkg.LogStatusSuccess(lfState2Status),
@@ -168,9 +294,324 @@ func (b *BankUC) Transfer(ctx fiber.Ctx, request entity.TransferRequest, userPho
 	/*------------------------------------
 	| Step 3 : Publish TransferEvent
 	* ----------------------------------*/
-	id, created_at, err := b.processTransfer.PublishProcessTransferJob(ctx.Context(), request, userPhoneNumber)
+	id, created_at, err := b.processTransfer.PublishProcessTransferJob(ctx.Context(), request, userPhoneNumber, originUser.ID)
+	if err != nil {
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferResponse{}, err
+	}
 
 	dto := utils.TransferDTO(originUser.Balance-request.Amount, originUser.Balance, id, request.Amount, created_at, request.Remarks, request.TargetUser)
 
 	return dto, nil
 }
+
+func (b *BankUC) Balance(ctx fiber.Ctx, userPhoneNumber string) (entity.BalanceResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Balance
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	balance, err := b.bankRepo.GetBalance(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BalanceResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.BalanceDTO(balance), nil
+}
+
+func (b *BankUC) TransactionHistory(ctx fiber.Ctx, request entity.TransactionHistoryRequest, userPhoneNumber string) (*response.ListResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Transactions
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	filter := entity.TransactionFilter{
+		UserID:          user.ID,
+		TransactionType: request.TransactionType,
+		MinAmount:       request.MinAmount,
+		MaxAmount:       request.MaxAmount,
+		Limit:           request.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultTransactionHistoryLimit
+	}
+	if request.Cursor != "" {
+		filter.Cursor, err = uuid.Parse(request.Cursor)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return nil, err
+		}
+	}
+	if request.StartDate != "" {
+		filter.StartDate, err = time.ParseInLocation(time.DateOnly, request.StartDate, time.Local)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return nil, err
+		}
+	}
+	if request.EndDate != "" {
+		endDate, err := time.ParseInLocation(time.DateOnly, request.EndDate, time.Local)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return nil, err
+		}
+		// end_date is inclusive, the repository filters with created_at < EndDate
+		filter.EndDate = endDate.AddDate(0, 0, 1)
+	}
+
+	// fetch one extra row to know whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	transactions, err := b.bankRepo.ListTransactions(ctx.Context(), filter)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	page := entity.CursorPagination{Limit: limit}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		page.HasMore = true
+		page.NextCursor = transactions[limit-1].ID.String()
+	}
+
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return response.ListRepond(utils.TransactionHistoryDTO(transactions), page), nil
+}
+
+func (b *BankUC) TransferStatus(ctx fiber.Ctx, transferID string, userPhoneNumber string) (entity.TransferStatusResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Transfer
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	id, err := uuid.Parse(transferID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferStatusResponse{}, pgsql.ErrTransferNotFound
+	}
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferStatusResponse{}, err
+	}
+
+	transfer, err := b.bankRepo.GetTransfer(ctx.Context(), id, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferStatusResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(transfer),
+	)
+
+	return utils.TransferStatusDTO(transfer), nil
+}
+
+// AdjustBalance posts a manual credit or debit to the user's wallet on behalf of adminPhoneNumber.
+// Adjustments are allowed on frozen accounts so support can correct them, but not on closed ones.
+func (b *BankUC) AdjustBalance(ctx fiber.Ctx, request entity.BalanceAdjustmentRequest, userID uuid.UUID, adminPhoneNumber string) (entity.BalanceAdjustmentResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Update Balance
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	target, err := b.bankRepo.CheckIfUserExistByID(ctx.Context(), userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BalanceAdjustmentResponse{}, err
+	}
+	if !entity.AccountAllowsAdjustment(target.Status) {
+		err = utils.AccountStatusError(target.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BalanceAdjustmentResponse{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BalanceAdjustmentResponse{}, err
+	}
+
+	adjustment := entity.BalanceAdjustment{
+		ID:               id,
+		UserID:           target.ID,
+		AdminPhoneNumber: adminPhoneNumber,
+		Type:             request.Type,
+		Amount:           request.Amount,
+		Reason:           request.Reason,
+		CreatedAt:        time.Now(),
+	}
+	u := entity.User{
+		UpdatedAt:   adjustment.CreatedAt,
+		Balance:     request.Amount,
+		PhoneNumber: target.PhoneNumber,
+	}
+
+	user, prev, tid, _, err := b.bankRepo.AdjustBalance(ctx.Context(), u, adjustment)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BalanceAdjustmentResponse{}, err
+	}
+	adjustment.TransactionID = tid
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(adjustment),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "balance adjusted", lf)
+
+	return utils.BalanceAdjustmentDTO(adjustment, user, prev), nil
+}
+
+// PayoutBalance moves the whole balance of a wallet that is being closed to payoutUserID. Its active
+// holds are voided first, a FROZEN_ALL wallet could never capture them anyway. A wallet that is already
+// empty is left as is.
+func (b *BankUC) PayoutBalance(ctx fiber.Ctx, userID uuid.UUID, payoutUserID uuid.UUID) (entity.ClosurePayoutResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Pay Out Balance
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	res := entity.ClosurePayoutResponse{PayoutUser: payoutUserID.String()}
+	if userID == payoutUserID {
+		err := pgsql.ErrAccountInvalidTransition
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "payout account is the closing account", err, lf)
+		return res, err
+	}
+
+	user, err := b.bankRepo.CheckIfUserExistByID(ctx.Context(), userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return res, err
+	}
+	if user.HeldBalance > 0 {
+		if err = b.bankRepo.VoidHolds(ctx.Context(), user.ID); err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return res, err
+		}
+	}
+	if user.Balance == 0 {
+		lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+		return res, nil
+	}
+
+	u := entity.User{
+		UpdatedAt:   time.Now(),
+		Balance:     user.Balance,
+		PhoneNumber: user.PhoneNumber,
+	}
+
+	_, _, tid, createdAt, err := b.bankRepo.PayoutBalance(ctx.Context(), u, payoutUserID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return res, err
+	}
+	res.TransactionID = tid.String()
+	res.Amount = user.Balance
+	res.CreatedAt = createdAt.String()
+
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(res),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "closing balance paid out", lf)
+
+	return res, nil
+}
+
+// stepUpRequired reports whether an operation of amount needs a step-up authorization token.
+func (b *BankUC) stepUpRequired(amount int) bool {
+	return b.stepUpThreshold > 0 && amount > b.stepUpThreshold
+}
+
+// checkStepUp spends the transaction authorization token of an operation above the step-up threshold.
+func (b *BankUC) checkStepUp(ctx fiber.Ctx, userPhoneNumber string, token string, operation string, amount int, target string) error {
+	if !b.stepUpRequired(amount) {
+		return nil
+	}
+	if token == "" {
+		return pgsql.ErrTransactionAuthorizationRequired
+	}
+	return b.users.ConsumeTransactionAuthorization(ctx.Context(), userPhoneNumber, token, operation, amount, target)
+}
+
+// checkAccountStatus rejects the operation when the account status does not pass allow. The
+// repository checks again inside the balance transaction.
+func (b *BankUC) checkAccountStatus(ctx fiber.Ctx, userPhoneNumber string, allow func(string) bool) error {
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		return err
+	}
+	if !allow(user.Status) {
+		return utils.AccountStatusError(user.Status)
+	}
+	return nil
+}
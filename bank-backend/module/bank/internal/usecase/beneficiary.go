@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/utils"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// RecipientPreview returns the masked name of the user owning phoneNumber, so the sender can check
+// the recipient before confirming a transfer.
+func (b *BankUC) RecipientPreview(ctx fiber.Ctx, phoneNumber string) (entity.RecipientPreviewResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Recipient
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	recipient, err := b.bankRepo.GetRecipientByPhoneNumber(ctx.Context(), phoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.RecipientPreviewResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "recipient fetched", lf)
+
+	return utils.RecipientPreviewDTO(recipient), nil
+}
+
+func (b *BankUC) CreateBeneficiary(ctx fiber.Ctx, request entity.BeneficiaryRequest, userPhoneNumber string) (entity.BeneficiaryResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateInsertDB
+		lfState2Status = "state_2_insert_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Insert Beneficiary
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+
+	recipient, err := b.bankRepo.GetRecipientByPhoneNumber(ctx.Context(), request.PhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+	if recipient.ID == user.ID {
+		err = pgsql.ErrBeneficiarySelf
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "generate uuid error", err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+	now := time.Now()
+	beneficiary := entity.Beneficiary{
+		ID:                id,
+		UserID:            user.ID,
+		BeneficiaryUserID: recipient.ID,
+		Nickname:          request.Nickname,
+		PhoneNumber:       recipient.PhoneNumber,
+		FirstName:         recipient.FirstName,
+		LastName:          recipient.LastName,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	err = b.bankRepo.CreateBeneficiary(ctx.Context(), beneficiary)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(beneficiary),
+	)
+
+	return utils.BeneficiaryDTO(beneficiary), nil
+}
+
+func (b *BankUC) ListBeneficiaries(ctx fiber.Ctx, userPhoneNumber string) ([]entity.BeneficiaryResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Beneficiaries
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	beneficiaries, err := b.bankRepo.ListBeneficiaries(ctx.Context(), user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "beneficiaries fetched", lf)
+
+	response := make([]entity.BeneficiaryResponse, 0, len(beneficiaries))
+	for _, beneficiary := range beneficiaries {
+		response = append(response, utils.BeneficiaryDTO(beneficiary))
+	}
+	return response, nil
+}
+
+func (b *BankUC) GetBeneficiary(ctx fiber.Ctx, beneficiaryID string, userPhoneNumber string) (entity.BeneficiaryResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Beneficiary
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	beneficiary, err := b.getOwnedBeneficiary(ctx, beneficiaryID, userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "beneficiary fetched", lf)
+
+	return utils.BeneficiaryDTO(beneficiary), nil
+}
+
+func (b *BankUC) UpdateBeneficiary(ctx fiber.Ctx, beneficiaryID string, request entity.UpdateBeneficiaryRequest, userPhoneNumber string) (entity.BeneficiaryResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Update Beneficiary
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	beneficiary, err := b.getOwnedBeneficiary(ctx, beneficiaryID, userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+
+	err = b.bankRepo.UpdateBeneficiaryNickname(ctx.Context(), beneficiary.ID, beneficiary.UserID, request.Nickname)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "beneficiary updated", lf)
+
+	beneficiary.Nickname = request.Nickname
+	beneficiary.UpdatedAt = time.Now()
+	return utils.BeneficiaryDTO(beneficiary), nil
+}
+
+// DeleteBeneficiary removes a saved recipient and returns it as it was.
+func (b *BankUC) DeleteBeneficiary(ctx fiber.Ctx, beneficiaryID string, userPhoneNumber string) (entity.BeneficiaryResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Delete Beneficiary
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	beneficiary, err := b.getOwnedBeneficiary(ctx, beneficiaryID, userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+
+	err = b.bankRepo.DeleteBeneficiary(ctx.Context(), beneficiary.ID, beneficiary.UserID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.BeneficiaryResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "beneficiary deleted", lf)
+
+	return utils.BeneficiaryDTO(beneficiary), nil
+}
+
+// getOwnedBeneficiary returns the beneficiary when it belongs to the user, and ErrBeneficiaryNotFound
+// otherwise, so ids of other users' beneficiaries are not revealed.
+func (b *BankUC) getOwnedBeneficiary(ctx fiber.Ctx, beneficiaryID string, userPhoneNumber string) (entity.Beneficiary, error) {
+	id, err := uuid.Parse(beneficiaryID)
+	if err != nil {
+		return entity.Beneficiary{}, pgsql.ErrBeneficiaryNotFound
+	}
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		return entity.Beneficiary{}, err
+	}
+
+	return b.bankRepo.GetBeneficiary(ctx.Context(), id, user.ID)
+}
+
+// resolveTransferTarget returns the recipient of a transfer addressed by target_user,
+// target_phone_number or one of the sender's beneficiaries.
+func (b *BankUC) resolveTransferTarget(ctx fiber.Ctx, request entity.TransferRequest, originUserID uuid.UUID) (entity.User, error) {
+	switch {
+	case request.TargetPhoneNumber != "":
+		return b.bankRepo.GetRecipientByPhoneNumber(ctx.Context(), request.TargetPhoneNumber)
+	case request.BeneficiaryID != "":
+		id, err := uuid.Parse(request.BeneficiaryID)
+		if err != nil {
+			return entity.User{}, pgsql.ErrBeneficiaryNotFound
+		}
+		beneficiary, err := b.bankRepo.GetBeneficiary(ctx.Context(), id, originUserID)
+		if err != nil {
+			return entity.User{}, err
+		}
+		return b.bankRepo.CheckIfUserExistByID(ctx.Context(), beneficiary.BeneficiaryUserID)
+	default:
+		id, err := uuid.Parse(request.TargetUser)
+		if err != nil {
+			return entity.User{}, pgsql.ErrUserNotFound
+		}
+		return b.bankRepo.CheckIfUserExistByID(ctx.Context(), id)
+	}
+}
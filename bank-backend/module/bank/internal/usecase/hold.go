@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/utils"
+	userentity "bank-backend/module/user/entity"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"bank-backend/utils/response"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultHoldExpiry is used when a hold is placed without expires_at
+	defaultHoldExpiry = 7 * 24 * time.Hour
+	// maxHoldExpiry is how far in the future a hold may expire
+	maxHoldExpiry = 30 * 24 * time.Hour
+)
+
+const defaultHoldListLimit = 20
+
+// CreateHold reserves an amount of the user's wallet for a later capture. It is checked like a
+// payment, including the step-up authorization.
+func (b *BankUC) CreateHold(ctx fiber.Ctx, request entity.HoldRequest, userPhoneNumber string) (entity.HoldResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateInsertDB
+		lfState2Status = "state_2_insert_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Insert Hold
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+	if !entity.AccountAllowsDebit(user.Status) {
+		err = utils.AccountStatusError(user.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "account does not allow debit", err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(defaultHoldExpiry)
+	if request.ExpiresAt != nil {
+		expiresAt = *request.ExpiresAt
+	}
+	if !expiresAt.After(now) || expiresAt.After(now.Add(maxHoldExpiry)) {
+		err = pgsql.ErrHoldExpiryInvalid
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	err = b.checkStepUp(ctx, userPhoneNumber, request.AuthorizationToken, userentity.TransactionOperationPayment, request.Amount, request.Remarks)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "generate uuid error", err, lf)
+		return entity.HoldResponse{}, err
+	}
+	hold := entity.Hold{
+		ID:        id,
+		UserID:    user.ID,
+		Amount:    request.Amount,
+		Remarks:   request.Remarks,
+		Status:    entity.HoldStatusActive,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = b.bankRepo.CreateHold(ctx.Context(), hold)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(hold),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "hold created", lf)
+
+	return utils.HoldDTO(hold), nil
+}
+
+// ListHolds lists the holds of the user, newest first.
+func (b *BankUC) ListHolds(ctx fiber.Ctx, request entity.HoldListRequest, userPhoneNumber string) (*response.ListResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Holds
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	filter := entity.HoldFilter{
+		UserID: user.ID,
+		Status: request.Status,
+		Limit:  request.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultHoldListLimit
+	}
+	if request.Cursor != "" {
+		filter.Cursor, err = uuid.Parse(request.Cursor)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return nil, err
+		}
+	}
+
+	// fetch one extra row to know whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	holds, err := b.bankRepo.ListHolds(ctx.Context(), filter)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	page := entity.CursorPagination{Limit: limit}
+	if len(holds) > limit {
+		holds = holds[:limit]
+		page.HasMore = true
+		page.NextCursor = holds[limit-1].ID.String()
+	}
+
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "holds fetched", lf)
+
+	return response.ListRepond(utils.HoldsDTO(holds), page), nil
+}
+
+func (b *BankUC) GetHold(ctx fiber.Ctx, holdID string, userPhoneNumber string) (entity.HoldResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Hold
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	hold, err := b.getHold(ctx, holdID, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "hold fetched", lf)
+
+	return utils.HoldDTO(hold), nil
+}
+
+// CaptureHold debits the captured amount of an active hold as a payment and frees the rest of it.
+// The limits were checked when the hold was placed, so they are not checked again.
+func (b *BankUC) CaptureHold(ctx fiber.Ctx, holdID string, request entity.CaptureHoldRequest, userPhoneNumber string) (entity.HoldResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Capture Hold
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	hold, err := b.getActiveHold(ctx, holdID, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	amount := request.Amount
+	if amount == 0 {
+		amount = hold.Amount
+	}
+	if amount > hold.Amount {
+		err = pgsql.ErrCaptureExceedsHold
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	u := entity.User{
+		UpdatedAt:   time.Now(),
+		Balance:     amount,
+		PhoneNumber: userPhoneNumber,
+	}
+
+	_, _, tid, createdAt, err := b.bankRepo.CaptureHold(ctx.Context(), u, hold)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+	hold.Status = entity.HoldStatusCaptured
+	hold.CapturedAmount = amount
+	hold.TransactionID = &tid
+	hold.UpdatedAt = createdAt
+
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(hold),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "hold captured", lf)
+
+	return utils.HoldDTO(hold), nil
+}
+
+// VoidHold frees an active hold without moving any money.
+func (b *BankUC) VoidHold(ctx fiber.Ctx, holdID string, userPhoneNumber string) (entity.HoldResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Void Hold
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	hold, err := b.getHold(ctx, holdID, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+	if hold.Status != entity.HoldStatusActive {
+		err = pgsql.ErrHoldInvalidState
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+
+	err = b.bankRepo.VoidHold(ctx.Context(), hold.ID, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.HoldResponse{}, err
+	}
+	hold.Status = entity.HoldStatusVoided
+	hold.UpdatedAt = time.Now()
+
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "hold voided", lf)
+
+	return utils.HoldDTO(hold), nil
+}
+
+// getHold returns the hold when it belongs to userID, and ErrHoldNotFound otherwise.
+func (b *BankUC) getHold(ctx fiber.Ctx, holdID string, userID uuid.UUID) (entity.Hold, error) {
+	id, err := uuid.Parse(holdID)
+	if err != nil {
+		return entity.Hold{}, pgsql.ErrHoldNotFound
+	}
+	return b.bankRepo.GetHold(ctx.Context(), id, userID)
+}
+
+// getActiveHold returns a hold of userID that can still be captured. The repository checks again
+// while capturing.
+func (b *BankUC) getActiveHold(ctx fiber.Ctx, holdID string, userID uuid.UUID) (entity.Hold, error) {
+	hold, err := b.getHold(ctx, holdID, userID)
+	if err != nil {
+		return entity.Hold{}, err
+	}
+	if hold.Status != entity.HoldStatusActive {
+		return entity.Hold{}, pgsql.ErrHoldInvalidState
+	}
+	if !time.Now().Before(hold.ExpiresAt) {
+		return entity.Hold{}, pgsql.ErrHoldExpired
+	}
+	return hold, nil
+}
@@ -0,0 +1,362 @@
+package usecase
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/utils"
+	userentity "bank-backend/module/user/entity"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"bank-backend/utils/response"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// maxMoneyRequestExpiry is how far in the future a money request may expire
+const maxMoneyRequestExpiry = 30 * 24 * time.Hour
+
+const defaultMoneyRequestListLimit = 20
+
+func (b *BankUC) CreateMoneyRequest(ctx fiber.Ctx, request entity.MoneyRequestRequest, userPhoneNumber string) (entity.MoneyRequestResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateInsertDB
+		lfState2Status = "state_2_insert_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Insert Money Request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	requester, err := b.bankRepo.GetRecipientByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+	if !entity.AccountAllowsCredit(requester.Status) {
+		err = utils.AccountStatusError(requester.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "account does not allow credit", err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	payer, err := b.bankRepo.GetRecipientByPhoneNumber(ctx.Context(), request.PayerPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+	if payer.ID == requester.ID {
+		err = pgsql.ErrMoneyRequestSelf
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	now := time.Now()
+	if !request.ExpiresAt.After(now) || request.ExpiresAt.After(now.Add(maxMoneyRequestExpiry)) {
+		err = pgsql.ErrMoneyRequestExpiryInvalid
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "generate uuid error", err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+	moneyRequest := entity.MoneyRequest{
+		ID:                   id,
+		RequesterUserID:      requester.ID,
+		RequesterPhoneNumber: requester.PhoneNumber,
+		RequesterFirstName:   requester.FirstName,
+		RequesterLastName:    requester.LastName,
+		PayerUserID:          payer.ID,
+		PayerPhoneNumber:     payer.PhoneNumber,
+		PayerFirstName:       payer.FirstName,
+		PayerLastName:        payer.LastName,
+		Amount:               request.Amount,
+		Remarks:              request.Remarks,
+		Status:               entity.MoneyRequestStatusPending,
+		ExpiresAt:            request.ExpiresAt,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+
+	err = b.moneyRequests.PublishMoneyRequestCreated(ctx.Context(), moneyRequest)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(moneyRequest),
+	)
+
+	return utils.MoneyRequestDTO(moneyRequest), nil
+}
+
+// ListMoneyRequests lists the requests the user received (INCOMING) or sent (OUTGOING), newest first.
+func (b *BankUC) ListMoneyRequests(ctx fiber.Ctx, direction string, request entity.MoneyRequestListRequest, userPhoneNumber string) (*response.ListResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Money Requests
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	filter := entity.MoneyRequestFilter{
+		UserID:    user.ID,
+		Direction: direction,
+		Status:    request.Status,
+		Limit:     request.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultMoneyRequestListLimit
+	}
+	if request.Cursor != "" {
+		filter.Cursor, err = uuid.Parse(request.Cursor)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return nil, err
+		}
+	}
+
+	// fetch one extra row to know whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	requests, err := b.bankRepo.ListMoneyRequests(ctx.Context(), filter)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	page := entity.CursorPagination{Limit: limit}
+	if len(requests) > limit {
+		requests = requests[:limit]
+		page.HasMore = true
+		page.NextCursor = requests[limit-1].ID.String()
+	}
+
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "money requests fetched", lf)
+
+	return response.ListRepond(utils.MoneyRequestsDTO(requests), page), nil
+}
+
+// GetMoneyRequest returns a money request to its requester or its payer.
+func (b *BankUC) GetMoneyRequest(ctx fiber.Ctx, moneyRequestID string, userPhoneNumber string) (entity.MoneyRequestResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Money Request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	moneyRequest, err := b.getMoneyRequest(ctx, moneyRequestID, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "money request fetched", lf)
+
+	return utils.MoneyRequestDTO(moneyRequest), nil
+}
+
+// AcceptMoneyRequest pays a pending money request. The payment is a transfer from the payer to the
+// requester, checked like any transfer and processed by bank-worker.
+func (b *BankUC) AcceptMoneyRequest(ctx fiber.Ctx, moneyRequestID string, request entity.AcceptMoneyRequestRequest, userPhoneNumber string) (entity.MoneyRequestResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Money Request and Check Balance
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	payer, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	moneyRequest, err := b.getPendingMoneyRequest(ctx, moneyRequestID, payer.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	if !entity.AccountAllowsDebit(payer.Status) {
+		err = utils.AccountStatusError(payer.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "account does not allow debit", err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	if payer.AvailableBalance() < moneyRequest.Amount {
+		err = pgsql.ErrBalanceNotEnough
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "balance is not enough", err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	requester, err := b.bankRepo.CheckIfUserExistByID(ctx.Context(), moneyRequest.RequesterUserID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+	if !entity.AccountAllowsCredit(requester.Status) {
+		err = utils.AccountStatusError(requester.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "target account does not allow credit", err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	err = b.bankRepo.CheckTransferLimits(ctx.Context(), payer.ID, requester.ID, moneyRequest.Amount, requester.Balance)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "transfer exceeds a limit", err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	// the token is spent last, so an acceptance rejected above can be retried with it
+	err = b.checkStepUp(ctx, userPhoneNumber, request.AuthorizationToken, userentity.TransactionOperationTransfer, moneyRequest.Amount, moneyRequest.ID.String())
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(moneyRequest),
+	)
+
+	/*------------------------------------
+	| Step 3 : Publish TransferEvent
+	* ----------------------------------*/
+	moneyRequest, err = b.moneyRequests.PublishMoneyRequestAccepted(ctx.Context(), moneyRequest)
+	if err != nil {
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	return utils.MoneyRequestDTO(moneyRequest), nil
+}
+
+func (b *BankUC) DeclineMoneyRequest(ctx fiber.Ctx, moneyRequestID string, userPhoneNumber string) (entity.MoneyRequestResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Decline Money Request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	payer, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	moneyRequest, err := b.getPendingMoneyRequest(ctx, moneyRequestID, payer.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+
+	moneyRequest, err = b.moneyRequests.PublishMoneyRequestDeclined(ctx.Context(), moneyRequest)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MoneyRequestResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "money request declined", lf)
+
+	return utils.MoneyRequestDTO(moneyRequest), nil
+}
+
+// getMoneyRequest returns the money request when userID is its requester or its payer, and
+// ErrMoneyRequestNotFound otherwise.
+func (b *BankUC) getMoneyRequest(ctx fiber.Ctx, moneyRequestID string, userID uuid.UUID) (entity.MoneyRequest, error) {
+	id, err := uuid.Parse(moneyRequestID)
+	if err != nil {
+		return entity.MoneyRequest{}, pgsql.ErrMoneyRequestNotFound
+	}
+	return b.bankRepo.GetMoneyRequest(ctx.Context(), id, userID)
+}
+
+// getPendingMoneyRequest returns a money request payerID can still answer. The requester cannot
+// answer their own request, so for them it is not found.
+func (b *BankUC) getPendingMoneyRequest(ctx fiber.Ctx, moneyRequestID string, payerID uuid.UUID) (entity.MoneyRequest, error) {
+	moneyRequest, err := b.getMoneyRequest(ctx, moneyRequestID, payerID)
+	if err != nil {
+		return entity.MoneyRequest{}, err
+	}
+	if moneyRequest.PayerUserID != payerID {
+		return entity.MoneyRequest{}, pgsql.ErrMoneyRequestNotFound
+	}
+	if moneyRequest.Status != entity.MoneyRequestStatusPending {
+		return entity.MoneyRequest{}, pgsql.ErrMoneyRequestInvalidState
+	}
+	if !time.Now().Before(moneyRequest.ExpiresAt) {
+		return entity.MoneyRequest{}, pgsql.ErrMoneyRequestExpired
+	}
+	return moneyRequest, nil
+}
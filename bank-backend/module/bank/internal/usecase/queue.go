@@ -8,5 +8,18 @@ import (
 )
 
 type ProcessTransferQueue interface {
-	PublishProcessTransferJob(ctx context.Context, request entity.TransferRequest, userPhoneNumber string) (uuid.UUID, string, error)
+	PublishProcessTransferJob(ctx context.Context, request entity.TransferRequest, userPhoneNumber string, originUserID uuid.UUID) (uuid.UUID, string, error)
+	PublishTransferReversal(ctx context.Context, reversal entity.TransferReversal, userPhoneNumber string) (entity.TransferReversal, error)
+}
+
+type MoneyRequestQueue interface {
+	PublishMoneyRequestCreated(ctx context.Context, request entity.MoneyRequest) error
+	PublishMoneyRequestDeclined(ctx context.Context, request entity.MoneyRequest) (entity.MoneyRequest, error)
+	PublishMoneyRequestAccepted(ctx context.Context, request entity.MoneyRequest) (entity.MoneyRequest, error)
+}
+
+type SplitBillQueue interface {
+	PublishSplitBillCreated(ctx context.Context, bill entity.SplitBill) error
+	PublishSplitBillReminders(ctx context.Context, bill entity.SplitBill, participants []entity.SplitBillParticipant) error
+	PublishSplitBillShareTransfer(ctx context.Context, bill entity.SplitBill, participant entity.SplitBillParticipant) (entity.SplitBillParticipant, error)
 }
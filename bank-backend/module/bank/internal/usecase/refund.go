@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/utils"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// RefundPayment credits part or all of a payment back to the wallet that paid it, as a CREDIT
+// transaction referencing the payment. Refunds of one payment never add up to more than it.
+func (b *BankUC) RefundPayment(ctx fiber.Ctx, paymentID uuid.UUID, request entity.RefundRequest, adminPhoneNumber string) (entity.RefundResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Refund Payment
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	payment, err := b.bankRepo.GetPayment(ctx.Context(), paymentID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.RefundResponse{}, err
+	}
+
+	// no amount refunds whatever is left of the payment
+	amount := request.Amount
+	if amount == 0 {
+		amount = payment.Amount - payment.RefundedAmount
+	}
+	if amount <= 0 || payment.RefundedAmount+amount > payment.Amount {
+		err = pgsql.ErrRefundExceedsPayment
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.RefundResponse{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "generate uuid error", err, lf)
+		return entity.RefundResponse{}, err
+	}
+
+	refund := entity.PaymentRefund{
+		ID:               id,
+		PaymentID:        payment.ID,
+		UserID:           payment.UserID,
+		Amount:           amount,
+		Reason:           request.Reason,
+		AdminPhoneNumber: adminPhoneNumber,
+		CreatedAt:        time.Now(),
+	}
+	u := entity.User{
+		UpdatedAt:   refund.CreatedAt,
+		Balance:     amount,
+		PhoneNumber: payment.PhoneNumber,
+	}
+
+	user, prev, tid, _, err := b.bankRepo.RefundPayment(ctx.Context(), u, refund)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.RefundResponse{}, err
+	}
+	refund.TransactionID = tid
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(refund),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "payment refunded", lf)
+
+	return utils.RefundDTO(refund, payment, user, prev), nil
+}
+
+// ReverseTransfer moves part or all of a completed transfer back from its recipient to its sender.
+// The reversal is a transfer of its own, run by bank-worker, so the response is PENDING. Reversals of
+// one transfer that have not failed never add up to more than it.
+func (b *BankUC) ReverseTransfer(ctx fiber.Ctx, transferID uuid.UUID, request entity.TransferReversalRequest, adminPhoneNumber string) (entity.TransferReversalResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Transfer
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	transfer, reversed, err := b.bankRepo.GetReversibleTransfer(ctx.Context(), transferID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferReversalResponse{}, err
+	}
+	if transfer.Status != entity.TransferStatusCompleted {
+		err = pgsql.ErrTransferNotReversible
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferReversalResponse{}, err
+	}
+
+	// no amount reverses whatever is left of the transfer
+	amount := request.Amount
+	if amount == 0 {
+		amount = transfer.Amount - reversed
+	}
+	if amount <= 0 || reversed+amount > transfer.Amount {
+		err = pgsql.ErrReversalExceedsTransfer
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferReversalResponse{}, err
+	}
+
+	// the recipient of the transfer pays the reversal
+	recipient, err := b.bankRepo.CheckIfUserExistByID(ctx.Context(), transfer.TargetUserID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferReversalResponse{}, err
+	}
+
+	reversal := entity.TransferReversal{
+		TransferID:       transfer.ID,
+		UserID:           transfer.TargetUserID,
+		TargetUserID:     transfer.UserID,
+		Amount:           amount,
+		Reason:           request.Reason,
+		AdminPhoneNumber: adminPhoneNumber,
+		CreatedAt:        time.Now(),
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(reversal),
+	)
+
+	/*------------------------------------
+	| Step 3 : Publish TransferEvent
+	* ----------------------------------*/
+	reversal, err = b.processTransfer.PublishTransferReversal(ctx.Context(), reversal, recipient.PhoneNumber)
+	if err != nil {
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.TransferReversalResponse{}, err
+	}
+
+	return utils.TransferReversalDTO(reversal, transfer, reversed), nil
+}
@@ -0,0 +1,343 @@
+package usecase
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/utils"
+	userentity "bank-backend/module/user/entity"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// scheduledTransferRunsLimit is how many recent runs are returned with a single schedule
+const scheduledTransferRunsLimit = 20
+
+func (b *BankUC) CreateScheduledTransfer(ctx fiber.Ctx, request entity.ScheduledTransferRequest, userPhoneNumber string) (entity.ScheduledTransferResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateInsertDB
+		lfState2Status = "state_2_insert_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Insert Scheduled Transfer
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "generate uuid error", err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+
+	schedule, err := b.scheduledTransferFromRequest(ctx, request, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+
+	// the worker runs the schedule without the user, so the PIN is checked once here
+	err = b.checkStepUp(ctx, userPhoneNumber, request.AuthorizationToken, userentity.TransactionOperationTransfer, schedule.Amount, schedule.TargetUserID.String())
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+	now := time.Now()
+	schedule.ID = id
+	schedule.UserID = user.ID
+	schedule.Status = entity.ScheduleStatusActive
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	err = b.bankRepo.CreateScheduledTransfer(ctx.Context(), schedule)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(schedule),
+	)
+
+	return utils.ScheduledTransferDTO(schedule, nil), nil
+}
+
+func (b *BankUC) ListScheduledTransfers(ctx fiber.Ctx, userPhoneNumber string) ([]entity.ScheduledTransferResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Scheduled Transfers
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	schedules, err := b.bankRepo.ListScheduledTransfers(ctx.Context(), user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "scheduled transfers fetched", lf)
+
+	response := make([]entity.ScheduledTransferResponse, 0, len(schedules))
+	for _, s := range schedules {
+		response = append(response, utils.ScheduledTransferDTO(s, nil))
+	}
+	return response, nil
+}
+
+// GetScheduledTransfer returns the schedule together with its latest runs.
+func (b *BankUC) GetScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Scheduled Transfer
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	schedule, err := b.getOwnedScheduledTransfer(ctx, scheduleID, userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+
+	runs, err := b.bankRepo.ListScheduledTransferRuns(ctx.Context(), schedule.ID, scheduledTransferRunsLimit)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(schedule),
+	)
+
+	return utils.ScheduledTransferDTO(schedule, runs), nil
+}
+
+// UpdateScheduledTransfer replaces the definition of an active or paused schedule and restarts it
+// from the new start_at. The status is kept, so a paused schedule stays paused.
+func (b *BankUC) UpdateScheduledTransfer(ctx fiber.Ctx, scheduleID string, request entity.ScheduledTransferRequest, userPhoneNumber string) (entity.ScheduledTransferResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Update Scheduled Transfer
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	schedule, err := b.getOwnedScheduledTransfer(ctx, scheduleID, userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+
+	updated, err := b.scheduledTransferFromRequest(ctx, request, schedule.UserID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+
+	// a new amount or target moves money the user has not authorized yet
+	if updated.Amount != schedule.Amount || updated.TargetUserID != schedule.TargetUserID {
+		err = b.checkStepUp(ctx, userPhoneNumber, request.AuthorizationToken, userentity.TransactionOperationTransfer, updated.Amount, updated.TargetUserID.String())
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.ScheduledTransferResponse{}, err
+		}
+	}
+	updated.ID = schedule.ID
+	updated.UserID = schedule.UserID
+	updated.Status = schedule.Status
+	updated.LastRunAt = schedule.LastRunAt
+	updated.CreatedAt = schedule.CreatedAt
+	updated.UpdatedAt = time.Now()
+
+	err = b.bankRepo.UpdateScheduledTransfer(ctx.Context(), updated)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(updated),
+	)
+
+	return utils.ScheduledTransferDTO(updated, nil), nil
+}
+
+func (b *BankUC) PauseScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error) {
+	return b.changeScheduledTransferStatus(ctx, scheduleID, userPhoneNumber, entity.ScheduleStatusPaused)
+}
+
+func (b *BankUC) ResumeScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error) {
+	return b.changeScheduledTransferStatus(ctx, scheduleID, userPhoneNumber, entity.ScheduleStatusActive)
+}
+
+// CancelScheduledTransfer stops the schedule for good, including retries of failed runs.
+func (b *BankUC) CancelScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransferResponse, error) {
+	return b.changeScheduledTransferStatus(ctx, scheduleID, userPhoneNumber, entity.ScheduleStatusCancelled)
+}
+
+func (b *BankUC) changeScheduledTransferStatus(ctx fiber.Ctx, scheduleID string, userPhoneNumber string, status string) (entity.ScheduledTransferResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Update Scheduled Transfer Status
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	schedule, err := b.getOwnedScheduledTransfer(ctx, scheduleID, userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+
+	var from []string
+	nextRunAt := schedule.NextRunAt
+	switch status {
+	case entity.ScheduleStatusPaused:
+		from = []string{entity.ScheduleStatusActive}
+	case entity.ScheduleStatusActive:
+		from = []string{entity.ScheduleStatusPaused}
+		// occurrences missed while paused are skipped instead of fired all at once
+		if nextRunAt != nil && schedule.Frequency != entity.ScheduleFrequencyOnce {
+			next, ok := *nextRunAt, true
+			for ok && !next.After(time.Now()) {
+				next, ok = utils.NextScheduledRun(schedule.Frequency, schedule.DayOfMonth, next, schedule.EndAt)
+			}
+			nextRunAt = &next
+			if !ok {
+				status = entity.ScheduleStatusCompleted
+				nextRunAt = nil
+			}
+		}
+	case entity.ScheduleStatusCancelled:
+		from = []string{entity.ScheduleStatusActive, entity.ScheduleStatusPaused, entity.ScheduleStatusCompleted}
+		nextRunAt = nil
+	}
+
+	err = b.bankRepo.UpdateScheduledTransferStatus(ctx.Context(), schedule.ID, schedule.UserID, from, status, nextRunAt)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.ScheduledTransferResponse{}, err
+	}
+	schedule.Status = status
+	schedule.NextRunAt = nextRunAt
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(schedule),
+	)
+
+	return utils.ScheduledTransferDTO(schedule, nil), nil
+}
+
+func (b *BankUC) getOwnedScheduledTransfer(ctx fiber.Ctx, scheduleID string, userPhoneNumber string) (entity.ScheduledTransfer, error) {
+	id, err := uuid.Parse(scheduleID)
+	if err != nil {
+		return entity.ScheduledTransfer{}, pgsql.ErrScheduledTransferNotFound
+	}
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		return entity.ScheduledTransfer{}, err
+	}
+
+	return b.bankRepo.GetScheduledTransfer(ctx.Context(), id, user.ID)
+}
+
+// scheduledTransferFromRequest checks the target user and start time of a schedule owned by
+// ownerID and computes the first run, which must not fall after end_at.
+func (b *BankUC) scheduledTransferFromRequest(ctx fiber.Ctx, request entity.ScheduledTransferRequest, ownerID uuid.UUID) (entity.ScheduledTransfer, error) {
+	if !request.StartAt.After(time.Now()) {
+		return entity.ScheduledTransfer{}, pgsql.ErrScheduledTransferStartInPast
+	}
+
+	targetUserID, err := uuid.Parse(request.TargetUser)
+	if err != nil {
+		return entity.ScheduledTransfer{}, err
+	}
+	if targetUserID == ownerID {
+		return entity.ScheduledTransfer{}, pgsql.ErrScheduledTransferSelf
+	}
+	_, err = b.bankRepo.CheckIfUserExistByID(ctx.Context(), targetUserID)
+	if err != nil {
+		return entity.ScheduledTransfer{}, err
+	}
+
+	dayOfMonth := 0
+	if request.Frequency == entity.ScheduleFrequencyMonthly {
+		dayOfMonth = request.DayOfMonth
+	}
+	nextRunAt := utils.FirstScheduledRun(request.Frequency, dayOfMonth, request.StartAt)
+	// a monthly schedule may first run weeks after start_at
+	if request.EndAt != nil && nextRunAt.After(*request.EndAt) {
+		return entity.ScheduledTransfer{}, pgsql.ErrScheduledTransferEndBeforeFirstRun
+	}
+
+	return entity.ScheduledTransfer{
+		TargetUserID: targetUserID,
+		Amount:       request.Amount,
+		Remarks:      request.Remarks,
+		Frequency:    request.Frequency,
+		DayOfMonth:   dayOfMonth,
+		StartAt:      request.StartAt,
+		EndAt:        request.EndAt,
+		NextRunAt:    &nextRunAt,
+	}, nil
+}
@@ -0,0 +1,463 @@
+package usecase
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/module/bank/utils"
+	userentity "bank-backend/module/user/entity"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"bank-backend/utils/response"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// splitBillReminderCooldown is how long a participant is left alone after a reminder
+const splitBillReminderCooldown = time.Hour
+
+const defaultSplitBillListLimit = 20
+
+// CreateSplitBill opens a bill organized by the user. Participants owe their share of the total, split
+// equally or as given for a CUSTOM split. The organizer may list themselves; their share is paid from
+// the start.
+func (b *BankUC) CreateSplitBill(ctx fiber.Ctx, request entity.SplitBillRequest, userPhoneNumber string) (entity.SplitBillResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateInsertDB
+		lfState2Status = "state_2_insert_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Insert Split Bill
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	organizer, err := b.bankRepo.GetRecipientByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+	if !entity.AccountAllowsCredit(organizer.Status) {
+		err = utils.AccountStatusError(organizer.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "account does not allow credit", err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	shares, err := splitBillShares(request)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "generate uuid error", err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	now := time.Now()
+	bill := entity.SplitBill{
+		ID:                   id,
+		OrganizerUserID:      organizer.ID,
+		OrganizerPhoneNumber: organizer.PhoneNumber,
+		OrganizerFirstName:   organizer.FirstName,
+		OrganizerLastName:    organizer.LastName,
+		Title:                request.Title,
+		TotalAmount:          request.TotalAmount,
+		SplitType:            request.SplitType,
+		Status:               entity.SplitBillStatusOpen,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+		Participants:         make([]entity.SplitBillParticipant, 0, len(request.Participants)),
+	}
+
+	seen := make(map[uuid.UUID]bool, len(request.Participants))
+	owing := 0
+	for i, p := range request.Participants {
+		user, err := b.bankRepo.GetRecipientByPhoneNumber(ctx.Context(), p.PhoneNumber)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.SplitBillResponse{}, err
+		}
+		if seen[user.ID] {
+			err = pgsql.ErrSplitBillParticipantsInvalid
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return entity.SplitBillResponse{}, err
+		}
+		seen[user.ID] = true
+
+		participantID, err := pkg.GenerateId()
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), "generate uuid error", err, lf)
+			return entity.SplitBillResponse{}, err
+		}
+		participant := entity.SplitBillParticipant{
+			ID:          participantID,
+			SplitBillID: bill.ID,
+			UserID:      user.ID,
+			PhoneNumber: user.PhoneNumber,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			ShareAmount: shares[i],
+			Status:      entity.SplitShareStatusPending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		// the organizer already paid the whole bill, so their own share is settled
+		if user.ID == organizer.ID {
+			participant.Status = entity.SplitShareStatusPaid
+			participant.PaidAt = &now
+		} else {
+			owing++
+		}
+		bill.Participants = append(bill.Participants, participant)
+	}
+	if owing == 0 {
+		err = pgsql.ErrSplitBillParticipantsInvalid
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	err = b.splitBills.PublishSplitBillCreated(ctx.Context(), bill)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(bill),
+	)
+
+	return utils.SplitBillDTO(bill), nil
+}
+
+// ListSplitBills lists the bills the user organizes or takes part in, newest first.
+func (b *BankUC) ListSplitBills(ctx fiber.Ctx, request entity.SplitBillListRequest, userPhoneNumber string) (*response.ListResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Split Bills
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	filter := entity.SplitBillFilter{
+		UserID: user.ID,
+		Status: request.Status,
+		Limit:  request.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultSplitBillListLimit
+	}
+	if request.Cursor != "" {
+		filter.Cursor, err = uuid.Parse(request.Cursor)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+			pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+			return nil, err
+		}
+	}
+
+	// fetch one extra row to know whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	bills, err := b.bankRepo.ListSplitBills(ctx.Context(), filter)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return nil, err
+	}
+
+	page := entity.CursorPagination{Limit: limit}
+	if len(bills) > limit {
+		bills = bills[:limit]
+		page.HasMore = true
+		page.NextCursor = bills[limit-1].ID.String()
+	}
+
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "split bills fetched", lf)
+
+	return response.ListRepond(utils.SplitBillsDTO(bills), page), nil
+}
+
+// GetSplitBill returns a bill with the status of every share to its organizer or a participant.
+func (b *BankUC) GetSplitBill(ctx fiber.Ctx, splitBillID string, userPhoneNumber string) (entity.SplitBillResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Split Bill
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	bill, err := b.getSplitBill(ctx, splitBillID, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "split bill fetched", lf)
+
+	return utils.SplitBillDTO(bill), nil
+}
+
+// PaySplitBillShare pays the user's pending share of an open bill. The payment is a transfer to the
+// organizer, checked like any transfer and processed by bank-worker, which settles the bill once
+// every share is paid.
+func (b *BankUC) PaySplitBillShare(ctx fiber.Ctx, splitBillID string, request entity.PaySplitBillRequest, userPhoneNumber string) (entity.SplitBillResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Split Bill and Check Balance
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	payer, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	bill, err := b.getSplitBill(ctx, splitBillID, payer.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	index := -1
+	for i, p := range bill.Participants {
+		if p.UserID == payer.ID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		err = pgsql.ErrSplitBillNotParticipant
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+	participant := bill.Participants[index]
+	if bill.Status != entity.SplitBillStatusOpen || participant.Status != entity.SplitShareStatusPending {
+		err = pgsql.ErrSplitBillShareInvalidState
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	if !entity.AccountAllowsDebit(payer.Status) {
+		err = utils.AccountStatusError(payer.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "account does not allow debit", err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	if payer.AvailableBalance() < participant.ShareAmount {
+		err = pgsql.ErrBalanceNotEnough
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "balance is not enough", err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	organizer, err := b.bankRepo.CheckIfUserExistByID(ctx.Context(), bill.OrganizerUserID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+	if !entity.AccountAllowsCredit(organizer.Status) {
+		err = utils.AccountStatusError(organizer.Status)
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "target account does not allow credit", err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	err = b.bankRepo.CheckTransferLimits(ctx.Context(), payer.ID, organizer.ID, participant.ShareAmount, organizer.Balance)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "transfer exceeds a limit", err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	// the token is spent last, so a payment rejected above can be retried with it
+	err = b.checkStepUp(ctx, userPhoneNumber, request.AuthorizationToken, userentity.TransactionOperationTransfer, participant.ShareAmount, bill.ID.String())
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(participant),
+	)
+
+	/*------------------------------------
+	| Step 3 : Publish TransferEvent
+	* ----------------------------------*/
+	participant, err = b.splitBills.PublishSplitBillShareTransfer(ctx.Context(), bill, participant)
+	if err != nil {
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillResponse{}, err
+	}
+	bill.Participants[index] = participant
+
+	return utils.SplitBillDTO(bill), nil
+}
+
+// RemindSplitBill sends a reminder to every participant with a pending share who was not reminded
+// within splitBillReminderCooldown. Only the organizer can remind; the response lists who was.
+func (b *BankUC) RemindSplitBill(ctx fiber.Ctx, splitBillID string, userPhoneNumber string) (entity.SplitBillReminderResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Remind Split Bill Participants
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := b.bankRepo.CheckIfUserExistByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillReminderResponse{}, err
+	}
+
+	bill, err := b.getSplitBill(ctx, splitBillID, user.ID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillReminderResponse{}, err
+	}
+	if bill.OrganizerUserID != user.ID {
+		err = pgsql.ErrSplitBillNotOrganizer
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillReminderResponse{}, err
+	}
+
+	res := entity.SplitBillReminderResponse{Reminded: make([]string, 0)}
+	due := make([]entity.SplitBillParticipant, 0)
+	cutoff := time.Now().Add(-splitBillReminderCooldown)
+	for _, p := range bill.Participants {
+		if p.Status != entity.SplitShareStatusPending {
+			continue
+		}
+		if p.LastRemindedAt != nil && p.LastRemindedAt.After(cutoff) {
+			continue
+		}
+		due = append(due, p)
+		res.Reminded = append(res.Reminded, p.PhoneNumber)
+	}
+	if len(due) == 0 {
+		lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+		pkg.LogInfoWithContext(ctx.Context(), "no split bill participant due for a reminder", lf)
+		return res, nil
+	}
+
+	err = b.splitBills.PublishSplitBillReminders(ctx.Context(), bill, due)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.SplitBillReminderResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "split bill participants reminded", lf)
+
+	return res, nil
+}
+
+// getSplitBill returns the bill when userID organizes it or takes part in it, and
+// ErrSplitBillNotFound otherwise.
+func (b *BankUC) getSplitBill(ctx fiber.Ctx, splitBillID string, userID uuid.UUID) (entity.SplitBill, error) {
+	id, err := uuid.Parse(splitBillID)
+	if err != nil {
+		return entity.SplitBill{}, pgsql.ErrSplitBillNotFound
+	}
+	return b.bankRepo.GetSplitBill(ctx.Context(), id, userID)
+}
+
+// splitBillShares returns the share of every participant in request order. An EQUAL split divides the
+// total with utils.SplitEqually so the shares sum to it exactly; a CUSTOM split takes the shares as
+// given and requires them to sum to the total.
+func splitBillShares(request entity.SplitBillRequest) ([]int, error) {
+	if request.SplitType == entity.SplitTypeEqual {
+		for _, p := range request.Participants {
+			if p.ShareAmount != 0 {
+				return nil, pgsql.ErrSplitBillSharesInvalid
+			}
+		}
+		// every share must be at least 1
+		if request.TotalAmount < len(request.Participants) {
+			return nil, pgsql.ErrSplitBillSharesInvalid
+		}
+		return utils.SplitEqually(request.TotalAmount, len(request.Participants)), nil
+	}
+
+	shares := make([]int, 0, len(request.Participants))
+	sum := 0
+	for _, p := range request.Participants {
+		if p.ShareAmount < 1 {
+			return nil, pgsql.ErrSplitBillSharesInvalid
+		}
+		shares = append(shares, p.ShareAmount)
+		sum += p.ShareAmount
+	}
+	if sum != request.TotalAmount {
+		return nil, pgsql.ErrSplitBillSharesInvalid
+	}
+	return shares, nil
+}
@@ -0,0 +1,261 @@
+package transport
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func (r *Rest) RecipientPreview(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState1))
+	previewPayload := new(entity.RecipientPreviewRequest)
+	err := ctx.Bind().Query(previewPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(previewPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(previewPayload),
+	)
+
+	res, err := r.bankUC.RecipientPreview(ctx, previewPayload.PhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(beneficiaryErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) CreateBeneficiary(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	beneficiaryPayload := new(entity.BeneficiaryRequest)
+	err := ctx.Bind().JSON(beneficiaryPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(beneficiaryPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(beneficiaryPayload),
+	)
+
+	res, err := r.bankUC.CreateBeneficiary(ctx, *beneficiaryPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(beneficiaryErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ListBeneficiaries(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.bankUC.ListBeneficiaries(ctx, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(beneficiaryErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) GetBeneficiary(ctx fiber.Ctx) error {
+	return r.beneficiaryAction(ctx, r.bankUC.GetBeneficiary)
+}
+
+func (r *Rest) UpdateBeneficiary(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	beneficiaryPayload := new(entity.UpdateBeneficiaryRequest)
+	err := ctx.Bind().JSON(beneficiaryPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(beneficiaryPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(beneficiaryPayload),
+	)
+
+	res, err := r.bankUC.UpdateBeneficiary(ctx, ctx.Params("beneficiary_id"), *beneficiaryPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(beneficiaryErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) DeleteBeneficiary(ctx fiber.Ctx) error {
+	return r.beneficiaryAction(ctx, r.bankUC.DeleteBeneficiary)
+}
+
+// beneficiaryAction handles the body-less endpoints addressed by :beneficiary_id.
+func (r *Rest) beneficiaryAction(ctx fiber.Ctx, action func(fiber.Ctx, string, string) (entity.BeneficiaryResponse, error)) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := action(ctx, ctx.Params("beneficiary_id"), userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(beneficiaryErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func beneficiaryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgsql.ErrBeneficiaryNotFound), errors.Is(err, pgsql.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pgsql.ErrBeneficiaryExists):
+		return http.StatusConflict
+	case errors.Is(err, pgsql.ErrBeneficiarySelf):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,252 @@
+package transport
+
+import (
+	"bank-backend/module/bank/entity"
+	bankutils "bank-backend/module/bank/utils"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func (r *Rest) CreateHold(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	holdPayload := new(entity.HoldRequest)
+	err := ctx.Bind().JSON(holdPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(holdPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(holdPayload),
+	)
+
+	res, err := r.bankUC.CreateHold(ctx, *holdPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return holdError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ListHolds(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	listPayload := new(entity.HoldListRequest)
+	err := ctx.Bind().Query(listPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(listPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(listPayload),
+	)
+
+	res, err := r.bankUC.ListHolds(ctx, *listPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return holdError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) GetHold(ctx fiber.Ctx) error {
+	return r.holdAction(ctx, r.bankUC.GetHold)
+}
+
+func (r *Rest) VoidHold(ctx fiber.Ctx) error {
+	return r.holdAction(ctx, r.bankUC.VoidHold)
+}
+
+func (r *Rest) CaptureHold(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	capturePayload := new(entity.CaptureHoldRequest)
+	// the body only carries the optional amount, so it may be empty
+	if len(ctx.Body()) > 0 {
+		err := ctx.Bind().JSON(capturePayload)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+			return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+				Message: "error processed request",
+			})
+		}
+	}
+	// Validate the struct
+	if err := r.validate.Struct(capturePayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(capturePayload),
+	)
+
+	res, err := r.bankUC.CaptureHold(ctx, ctx.Params("hold_id"), *capturePayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return holdError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// holdAction handles the body-less endpoints addressed by :hold_id.
+func (r *Rest) holdAction(ctx fiber.Ctx, action func(fiber.Ctx, string, string) (entity.HoldResponse, error)) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := action(ctx, ctx.Params("hold_id"), userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return holdError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// holdError writes the error response of the hold endpoints. An exceeded limit carries its details in
+// errors.
+func holdError(ctx fiber.Ctx, err error) error {
+	var limitErr *bankutils.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(utils.StandardResponse{
+			Message: err.Error(),
+			Errors:  limitErr,
+		})
+	}
+	return ctx.Status(holdErrorStatus(err)).JSON(utils.StandardResponse{
+		Message: err.Error(),
+	})
+}
+
+func holdErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgsql.ErrHoldNotFound), errors.Is(err, pgsql.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pgsql.ErrHoldInvalidState), errors.Is(err, pgsql.ErrHoldExpired),
+		errors.Is(err, pgsql.ErrConcurrentModification):
+		return http.StatusConflict
+	case errors.Is(err, pgsql.ErrHoldExpiryInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, pgsql.ErrCaptureExceedsHold), errors.Is(err, pgsql.ErrBalanceNotEnough):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pgsql.ErrAccountFrozen), errors.Is(err, pgsql.ErrAccountClosed),
+		errors.Is(err, pgsql.ErrTransactionAuthorizationRequired), errors.Is(err, pgsql.ErrTransactionAuthorizationInvalid):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,256 @@
+package transport
+
+import (
+	"bank-backend/module/bank/entity"
+	bankutils "bank-backend/module/bank/utils"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func (r *Rest) CreateMoneyRequest(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	moneyRequestPayload := new(entity.MoneyRequestRequest)
+	err := ctx.Bind().JSON(moneyRequestPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(moneyRequestPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(moneyRequestPayload),
+	)
+
+	res, err := r.bankUC.CreateMoneyRequest(ctx, *moneyRequestPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return moneyRequestError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ListIncomingMoneyRequests(ctx fiber.Ctx) error {
+	return r.listMoneyRequests(ctx, entity.MoneyRequestDirectionIncoming)
+}
+
+func (r *Rest) ListOutgoingMoneyRequests(ctx fiber.Ctx) error {
+	return r.listMoneyRequests(ctx, entity.MoneyRequestDirectionOutgoing)
+}
+
+func (r *Rest) listMoneyRequests(ctx fiber.Ctx, direction string) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	listPayload := new(entity.MoneyRequestListRequest)
+	err := ctx.Bind().Query(listPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(listPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(listPayload),
+	)
+
+	res, err := r.bankUC.ListMoneyRequests(ctx, direction, *listPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return moneyRequestError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) GetMoneyRequest(ctx fiber.Ctx) error {
+	return r.moneyRequestAction(ctx, r.bankUC.GetMoneyRequest)
+}
+
+func (r *Rest) DeclineMoneyRequest(ctx fiber.Ctx) error {
+	return r.moneyRequestAction(ctx, r.bankUC.DeclineMoneyRequest)
+}
+
+func (r *Rest) AcceptMoneyRequest(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	acceptPayload := new(entity.AcceptMoneyRequestRequest)
+	// the body only carries the optional authorization token, so it may be empty
+	if len(ctx.Body()) > 0 {
+		err := ctx.Bind().JSON(acceptPayload)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+			return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+				Message: "error processed request",
+			})
+		}
+	}
+	// Validate the struct
+	if err := r.validate.Struct(acceptPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState1Status))
+
+	res, err := r.bankUC.AcceptMoneyRequest(ctx, ctx.Params("money_request_id"), *acceptPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return moneyRequestError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// moneyRequestAction handles the body-less endpoints addressed by :money_request_id.
+func (r *Rest) moneyRequestAction(ctx fiber.Ctx, action func(fiber.Ctx, string, string) (entity.MoneyRequestResponse, error)) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := action(ctx, ctx.Params("money_request_id"), userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return moneyRequestError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// moneyRequestError writes the error response of the money request endpoints. An exceeded limit
+// carries its details in errors.
+func moneyRequestError(ctx fiber.Ctx, err error) error {
+	var limitErr *bankutils.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(utils.StandardResponse{
+			Message: err.Error(),
+			Errors:  limitErr,
+		})
+	}
+	return ctx.Status(moneyRequestErrorStatus(err)).JSON(utils.StandardResponse{
+		Message: err.Error(),
+	})
+}
+
+func moneyRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgsql.ErrMoneyRequestNotFound), errors.Is(err, pgsql.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pgsql.ErrMoneyRequestInvalidState), errors.Is(err, pgsql.ErrMoneyRequestExpired):
+		return http.StatusConflict
+	case errors.Is(err, pgsql.ErrMoneyRequestExpiryInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, pgsql.ErrMoneyRequestSelf), errors.Is(err, pgsql.ErrBalanceNotEnough):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pgsql.ErrAccountFrozen), errors.Is(err, pgsql.ErrAccountClosed),
+		errors.Is(err, pgsql.ErrTransactionAuthorizationRequired), errors.Is(err, pgsql.ErrTransactionAuthorizationInvalid):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
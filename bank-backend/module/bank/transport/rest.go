@@ -6,9 +6,13 @@ import (
 	"bank-backend/module/bank/internal/queue"
 	"bank-backend/module/bank/internal/repository"
 	"bank-backend/module/bank/internal/usecase"
+	bankutils "bank-backend/module/bank/utils"
 	"bank-backend/module/middleware"
 	"bank-backend/pkg"
 	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -23,22 +27,64 @@ type Rest struct {
 }
 
 func NewRest(cfg config.BankConfig) {
-	bankRepo := repository.NewBankRepository(cfg.PGx)
-	processTransferQueue := queue.NewProcessTransferQueue(*cfg.Producer, cfg.ProcessTranferTopic)
-	bankUsecase := usecase.NewBankUseCase(*bankRepo, processTransferQueue)
+	bankRepo := repository.NewBankRepository(cfg.PGx, cfg.ConflictRetry)
+	processTransferQueue := queue.NewProcessTransferQueue(cfg.ProcessTranferTopic, bankRepo)
+	moneyRequestQueue := queue.NewMoneyRequestQueue(cfg.MoneyRequestTopic, processTransferQueue, bankRepo)
+	splitBillQueue := queue.NewSplitBillQueue(cfg.SplitBillTopic, processTransferQueue, bankRepo)
+	bankUsecase := usecase.NewBankUseCase(*bankRepo, processTransferQueue, moneyRequestQueue, splitBillQueue, cfg.Users, cfg.StepUpThreshold)
 	transport := Rest{bankUC: bankUsecase, validate: cfg.Validate}
 
 	// Initialize Fiber app
 	transport.mountBank(cfg.Fiber)
 
 }
+
+// StartOutboxRelay publishes pending outbox messages to Kafka in the background until ctx is done.
+func StartOutboxRelay(ctx context.Context, cfg config.BankConfig) {
+	bankRepo := repository.NewBankRepository(cfg.PGx, cfg.ConflictRetry)
+	relay := queue.NewOutboxRelay(*cfg.Producer, bankRepo, cfg.OutboxBatchSize, cfg.OutboxPollInterval)
+	go relay.Run(ctx)
+}
+
 func (r *Rest) mountBank(app *fiber.App) {
 
 	fmt.Println("bank")
 	fmt.Println(app)
-	app.Post("/api/v1/topup", r.Topup, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware())
-	app.Post("/api/v1/payment", r.Payment, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware())
-	app.Post("/api/v1/transfer", r.Transfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware())
+	app.Post("/api/v1/topup", r.Topup, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite), middleware.IdempotencyMiddleware())
+	app.Post("/api/v1/payment", r.Payment, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite), middleware.IdempotencyMiddleware())
+	app.Post("/api/v1/transfer", r.Transfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite), middleware.IdempotencyMiddleware())
+	app.Get("/api/v1/balance", r.Balance, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead), middleware.ETagMiddleware())
+	app.Get("/api/v1/transactions", r.TransactionHistory, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/transfers/:transfer_id", r.TransferStatus, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/transfer/recipient", r.RecipientPreview, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Post("/api/v1/beneficiaries", r.CreateBeneficiary, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Get("/api/v1/beneficiaries", r.ListBeneficiaries, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/beneficiaries/:beneficiary_id", r.GetBeneficiary, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Put("/api/v1/beneficiaries/:beneficiary_id", r.UpdateBeneficiary, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Delete("/api/v1/beneficiaries/:beneficiary_id", r.DeleteBeneficiary, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Post("/api/v1/money-requests", r.CreateMoneyRequest, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Get("/api/v1/money-requests/incoming", r.ListIncomingMoneyRequests, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/money-requests/outgoing", r.ListOutgoingMoneyRequests, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/money-requests/:money_request_id", r.GetMoneyRequest, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Post("/api/v1/money-requests/:money_request_id/accept", r.AcceptMoneyRequest, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite), middleware.IdempotencyMiddleware())
+	app.Post("/api/v1/money-requests/:money_request_id/decline", r.DeclineMoneyRequest, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Post("/api/v1/split-bills", r.CreateSplitBill, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Get("/api/v1/split-bills", r.ListSplitBills, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/split-bills/:split_bill_id", r.GetSplitBill, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Post("/api/v1/split-bills/:split_bill_id/pay", r.PaySplitBillShare, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite), middleware.IdempotencyMiddleware())
+	app.Post("/api/v1/split-bills/:split_bill_id/remind", r.RemindSplitBill, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Post("/api/v1/holds", r.CreateHold, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite), middleware.IdempotencyMiddleware())
+	app.Get("/api/v1/holds", r.ListHolds, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/holds/:hold_id", r.GetHold, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Post("/api/v1/holds/:hold_id/capture", r.CaptureHold, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite), middleware.IdempotencyMiddleware())
+	app.Post("/api/v1/holds/:hold_id/void", r.VoidHold, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Post("/api/v1/scheduled-transfers", r.CreateScheduledTransfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Get("/api/v1/scheduled-transfers", r.ListScheduledTransfers, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Get("/api/v1/scheduled-transfers/:schedule_id", r.GetScheduledTransfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead))
+	app.Put("/api/v1/scheduled-transfers/:schedule_id", r.UpdateScheduledTransfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Delete("/api/v1/scheduled-transfers/:schedule_id", r.CancelScheduledTransfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Post("/api/v1/scheduled-transfers/:schedule_id/pause", r.PauseScheduledTransfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Post("/api/v1/scheduled-transfers/:schedule_id/resume", r.ResumeScheduledTransfer, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
 }
 
 func (r *Rest) Topup(ctx fiber.Ctx) error {
@@ -87,6 +133,23 @@ func (r *Rest) Topup(ctx fiber.Ctx) error {
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrConcurrentModification) {
+			return ctx.Status(http.StatusConflict).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		var limitErr *bankutils.LimitExceededError
+		if errors.As(err, &limitErr) {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(utils.StandardResponse{
+				Message: err.Error(),
+				Errors:  limitErr,
+			})
+		}
+		if errors.Is(err, pgsql.ErrAccountFrozen) || errors.Is(err, pgsql.ErrAccountClosed) {
+			return ctx.Status(http.StatusForbidden).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
 		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
 			Message: err.Error(),
 		})
@@ -144,6 +207,38 @@ func (r *Rest) Payment(ctx fiber.Ctx) error {
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrConcurrentModification) {
+			return ctx.Status(http.StatusConflict).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		var limitErr *bankutils.LimitExceededError
+		if errors.As(err, &limitErr) {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(utils.StandardResponse{
+				Message: err.Error(),
+				Errors:  limitErr,
+			})
+		}
+		if errors.Is(err, pgsql.ErrAccountFrozen) || errors.Is(err, pgsql.ErrAccountClosed) {
+			return ctx.Status(http.StatusForbidden).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		if errors.Is(err, pgsql.ErrTransactionAuthorizationRequired) || errors.Is(err, pgsql.ErrTransactionAuthorizationInvalid) {
+			return ctx.Status(http.StatusForbidden).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		if errors.Is(err, pgsql.ErrPaymentReferenceRequired) {
+			return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		if errors.Is(err, pgsql.ErrPaymentReferenceUsed) {
+			return ctx.Status(http.StatusConflict).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
 		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
 			Message: err.Error(),
 		})
@@ -199,10 +294,167 @@ func (r *Rest) Transfer(ctx fiber.Ctx) error {
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrUserNotFound) || errors.Is(err, pgsql.ErrBeneficiaryNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		var limitErr *bankutils.LimitExceededError
+		if errors.As(err, &limitErr) {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(utils.StandardResponse{
+				Message: err.Error(),
+				Errors:  limitErr,
+			})
+		}
+		if errors.Is(err, pgsql.ErrTransferSelf) {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		if errors.Is(err, pgsql.ErrAccountFrozen) || errors.Is(err, pgsql.ErrAccountClosed) {
+			return ctx.Status(http.StatusForbidden).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		if errors.Is(err, pgsql.ErrTransactionAuthorizationRequired) || errors.Is(err, pgsql.ErrTransactionAuthorizationInvalid) {
+			return ctx.Status(http.StatusForbidden).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) TransactionHistory(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	historyPayload := new(entity.TransactionHistoryRequest)
+	err := ctx.Bind().Query(historyPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(historyPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(historyPayload),
+	)
+
+	res, err := r.bankUC.TransactionHistory(ctx, *historyPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrUserNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) Balance(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.bankUC.Balance(ctx, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrUserNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
 		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
 			Message: err.Error(),
 		})
 	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) TransferStatus(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.bankUC.TransferStatus(ctx, ctx.Params("transfer_id"), userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrTransferNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
 	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
 		Status: "SUCCESS",
 		Result: res,
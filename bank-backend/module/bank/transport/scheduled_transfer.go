@@ -0,0 +1,219 @@
+package transport
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func (r *Rest) CreateScheduledTransfer(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	schedulePayload := new(entity.ScheduledTransferRequest)
+	err := ctx.Bind().JSON(schedulePayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(schedulePayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(schedulePayload),
+	)
+
+	res, err := r.bankUC.CreateScheduledTransfer(ctx, *schedulePayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(scheduledTransferErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ListScheduledTransfers(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.bankUC.ListScheduledTransfers(ctx, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(scheduledTransferErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) GetScheduledTransfer(ctx fiber.Ctx) error {
+	return r.scheduledTransferAction(ctx, r.bankUC.GetScheduledTransfer)
+}
+
+func (r *Rest) UpdateScheduledTransfer(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	schedulePayload := new(entity.ScheduledTransferRequest)
+	err := ctx.Bind().JSON(schedulePayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(schedulePayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(schedulePayload),
+	)
+
+	res, err := r.bankUC.UpdateScheduledTransfer(ctx, ctx.Params("schedule_id"), *schedulePayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(scheduledTransferErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) PauseScheduledTransfer(ctx fiber.Ctx) error {
+	return r.scheduledTransferAction(ctx, r.bankUC.PauseScheduledTransfer)
+}
+
+func (r *Rest) ResumeScheduledTransfer(ctx fiber.Ctx) error {
+	return r.scheduledTransferAction(ctx, r.bankUC.ResumeScheduledTransfer)
+}
+
+func (r *Rest) CancelScheduledTransfer(ctx fiber.Ctx) error {
+	return r.scheduledTransferAction(ctx, r.bankUC.CancelScheduledTransfer)
+}
+
+// scheduledTransferAction handles the body-less endpoints addressed by :schedule_id.
+func (r *Rest) scheduledTransferAction(ctx fiber.Ctx, action func(fiber.Ctx, string, string) (entity.ScheduledTransferResponse, error)) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := action(ctx, ctx.Params("schedule_id"), userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(scheduledTransferErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func scheduledTransferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgsql.ErrScheduledTransferNotFound), errors.Is(err, pgsql.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pgsql.ErrScheduledTransferInvalidState):
+		return http.StatusConflict
+	case errors.Is(err, pgsql.ErrScheduledTransferStartInPast), errors.Is(err, pgsql.ErrScheduledTransferEndBeforeFirstRun):
+		return http.StatusBadRequest
+	case errors.Is(err, pgsql.ErrScheduledTransferSelf):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pgsql.ErrTransactionAuthorizationRequired), errors.Is(err, pgsql.ErrTransactionAuthorizationInvalid):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
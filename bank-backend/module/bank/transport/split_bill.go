@@ -0,0 +1,267 @@
+package transport
+
+import (
+	"bank-backend/module/bank/entity"
+	bankutils "bank-backend/module/bank/utils"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func (r *Rest) CreateSplitBill(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	splitBillPayload := new(entity.SplitBillRequest)
+	err := ctx.Bind().JSON(splitBillPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(splitBillPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(splitBillPayload),
+	)
+
+	res, err := r.bankUC.CreateSplitBill(ctx, *splitBillPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return splitBillError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ListSplitBills(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	listPayload := new(entity.SplitBillListRequest)
+	err := ctx.Bind().Query(listPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(listPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(listPayload),
+	)
+
+	res, err := r.bankUC.ListSplitBills(ctx, *listPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return splitBillError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) GetSplitBill(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.bankUC.GetSplitBill(ctx, ctx.Params("split_bill_id"), userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return splitBillError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) PaySplitBillShare(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	payPayload := new(entity.PaySplitBillRequest)
+	// the body only carries the optional authorization token, so it may be empty
+	if len(ctx.Body()) > 0 {
+		err := ctx.Bind().JSON(payPayload)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+			return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+				Message: "error processed request",
+			})
+		}
+	}
+	// Validate the struct
+	if err := r.validate.Struct(payPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState1Status))
+
+	res, err := r.bankUC.PaySplitBillShare(ctx, ctx.Params("split_bill_id"), *payPayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return splitBillError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) RemindSplitBill(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("bank-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.bankUC.RemindSplitBill(ctx, ctx.Params("split_bill_id"), userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return splitBillError(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// splitBillError writes the error response of the split bill endpoints. An exceeded limit carries its
+// details in errors.
+func splitBillError(ctx fiber.Ctx, err error) error {
+	var limitErr *bankutils.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(utils.StandardResponse{
+			Message: err.Error(),
+			Errors:  limitErr,
+		})
+	}
+	return ctx.Status(splitBillErrorStatus(err)).JSON(utils.StandardResponse{
+		Message: err.Error(),
+	})
+}
+
+func splitBillErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgsql.ErrSplitBillNotFound), errors.Is(err, pgsql.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pgsql.ErrSplitBillShareInvalidState):
+		return http.StatusConflict
+	case errors.Is(err, pgsql.ErrSplitBillParticipantsInvalid), errors.Is(err, pgsql.ErrSplitBillSharesInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, pgsql.ErrBalanceNotEnough):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, pgsql.ErrSplitBillNotOrganizer), errors.Is(err, pgsql.ErrSplitBillNotParticipant),
+		errors.Is(err, pgsql.ErrAccountFrozen), errors.Is(err, pgsql.ErrAccountClosed),
+		errors.Is(err, pgsql.ErrTransactionAuthorizationRequired), errors.Is(err, pgsql.ErrTransactionAuthorizationInvalid):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
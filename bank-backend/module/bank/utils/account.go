@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"bank-backend/module/bank/entity"
+	"bank-backend/utils/pgsql"
+)
+
+// AccountStatusError is the error returned when a wallet in status refuses an operation.
+func AccountStatusError(status string) error {
+	if status == entity.AccountStatusClosed {
+		return pgsql.ErrAccountClosed
+	}
+	return pgsql.ErrAccountFrozen
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"bank-backend/module/bank/entity"
+	"strings"
+)
+
+// MaskName keeps the first two letters of every word of a name, e.g. "Fa**** Dw****".
+func MaskName(firstName string, lastName string) string {
+	words := strings.Fields(firstName + " " + lastName)
+	for i, word := range words {
+		runes := []rune(word)
+		if len(runes) > 2 {
+			words[i] = string(runes[:2]) + strings.Repeat("*", len(runes)-2)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func RecipientPreviewDTO(user entity.User) entity.RecipientPreviewResponse {
+	response := entity.RecipientPreviewResponse{
+		PhoneNumber:   user.PhoneNumber,
+		RecipientName: MaskName(user.FirstName, user.LastName),
+	}
+	return response
+}
+
+func BeneficiaryDTO(beneficiary entity.Beneficiary) entity.BeneficiaryResponse {
+	response := entity.BeneficiaryResponse{
+		BeneficiaryID: beneficiary.ID.String(),
+		Nickname:      beneficiary.Nickname,
+		PhoneNumber:   beneficiary.PhoneNumber,
+		RecipientName: MaskName(beneficiary.FirstName, beneficiary.LastName),
+		CreatedAt:     beneficiary.CreatedAt.String(),
+		UpdatedAt:     beneficiary.UpdatedAt.String(),
+	}
+	return response
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"bank-backend/module/bank/entity"
+)
+
+func HoldDTO(hold entity.Hold) entity.HoldResponse {
+	response := entity.HoldResponse{
+		HoldID:         hold.ID.String(),
+		Amount:         hold.Amount,
+		CapturedAmount: hold.CapturedAmount,
+		Remarks:        hold.Remarks,
+		Status:         hold.Status,
+		ExpiresAt:      hold.ExpiresAt.String(),
+		CreatedAt:      hold.CreatedAt.String(),
+		UpdatedAt:      hold.UpdatedAt.String(),
+	}
+	if hold.TransactionID != nil {
+		response.PaymentID = hold.TransactionID.String()
+	}
+	return response
+}
+
+func HoldsDTO(holds []entity.Hold) []entity.HoldResponse {
+	response := make([]entity.HoldResponse, 0, len(holds))
+	for _, hold := range holds {
+		response = append(response, HoldDTO(hold))
+	}
+	return response
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+
+	"bank-backend/utils/pgsql"
+)
+
+// LimitExceededError names the KYC tier limit a movement would exceed and how much is left under it.
+// It matches pgsql.ErrLimitExceeded.
+type LimitExceededError struct {
+	Limit       string `json:"limit"`
+	LimitAmount int    `json:"limit_amount"`
+	Remaining   int    `json:"remaining"`
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: %s of %d, %d remaining", pgsql.ErrLimitExceeded, e.Limit, e.LimitAmount, e.Remaining)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return pgsql.ErrLimitExceeded
+}
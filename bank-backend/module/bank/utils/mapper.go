@@ -7,6 +7,17 @@ import (
 	"github.com/google/uuid"
 )
 
+func BalanceDTO(balance entity.Balance) entity.BalanceResponse {
+	response := entity.BalanceResponse{
+		Balance:          balance.Balance,
+		AvailableBalance: balance.Balance - balance.HeldBalance,
+		HeldBalance:      balance.HeldBalance,
+		Status:           balance.Status,
+		UpdatedAt:        balance.UpdatedAt.String(),
+	}
+	return response
+}
+
 func TopUpDTO(user entity.User, prev int, tid uuid.UUID, topup int, time time.Time) entity.TopUpResponse {
 	response := entity.TopUpResponse{
 		TopUpId:       tid.String(),
@@ -30,6 +41,21 @@ func PaymentDTO(user entity.User, prev int, tid uuid.UUID, topup int, time time.
 	return response
 }
 
+func BalanceAdjustmentDTO(adjustment entity.BalanceAdjustment, user entity.User, prev int) entity.BalanceAdjustmentResponse {
+	response := entity.BalanceAdjustmentResponse{
+		AdjustmentID:  adjustment.ID.String(),
+		TransactionID: adjustment.TransactionID.String(),
+		Type:          adjustment.Type,
+		Amount:        adjustment.Amount,
+		BalanceBefore: prev,
+		BalanceAfter:  user.Balance,
+		Reason:        adjustment.Reason,
+		AdjustedBy:    adjustment.AdminPhoneNumber,
+		CreatedAt:     adjustment.CreatedAt.String(),
+	}
+	return response
+}
+
 func TransferDTO(balanceAfter int, prev int, tid uuid.UUID, topup int, time string, remarks string, targetTransfer string) entity.TransferResponse {
 	response := entity.TransferResponse{
 		TransferID:     tid.String(),
@@ -38,7 +64,82 @@ func TransferDTO(balanceAfter int, prev int, tid uuid.UUID, topup int, time stri
 		Remarks:        remarks,
 		TargetTransfer: targetTransfer,
 		Amount:         topup,
+		Status:         entity.TransferStatusPending,
 		CreatedAt:      time,
 	}
 	return response
 }
+
+func TransactionHistoryDTO(transactions []entity.Transaction) []entity.TransactionHistoryResponse {
+	response := make([]entity.TransactionHistoryResponse, 0, len(transactions))
+	for _, t := range transactions {
+		transaction := entity.TransactionHistoryResponse{
+			TransactionID:   t.ID.String(),
+			TransactionType: t.TransactionType,
+			Amount:          t.Amount,
+			BalanceBefore:   t.BalanceBefore,
+			BalanceAfter:    t.BalanceAfter,
+			Remarks:         t.Remarks,
+			CreatedAt:       t.CreatedDate.String(),
+		}
+		if t.ReferenceID != nil {
+			transaction.ReferenceID = t.ReferenceID.String()
+		}
+		response = append(response, transaction)
+	}
+	return response
+}
+
+func TransferStatusDTO(transfer entity.Transfer) entity.TransferStatusResponse {
+	response := entity.TransferStatusResponse{
+		TransferID:    transfer.ID.String(),
+		Status:        transfer.Status,
+		FailureReason: transfer.FailureReason,
+		Amount:        transfer.Amount,
+		TargetUser:    transfer.TargetUserID.String(),
+		Remarks:       transfer.Remarks,
+		CreatedAt:     transfer.CreatedAt.String(),
+		UpdatedAt:     transfer.UpdatedAt.String(),
+	}
+	return response
+}
+
+func ScheduledTransferDTO(schedule entity.ScheduledTransfer, runs []entity.ScheduledTransferRun) entity.ScheduledTransferResponse {
+	response := entity.ScheduledTransferResponse{
+		ScheduledTransferID: schedule.ID.String(),
+		TargetUser:          schedule.TargetUserID.String(),
+		Amount:              schedule.Amount,
+		Remarks:             schedule.Remarks,
+		Frequency:           schedule.Frequency,
+		DayOfMonth:          schedule.DayOfMonth,
+		StartAt:             schedule.StartAt.String(),
+		Status:              schedule.Status,
+		CreatedAt:           schedule.CreatedAt.String(),
+		UpdatedAt:           schedule.UpdatedAt.String(),
+	}
+	if schedule.EndAt != nil {
+		response.EndAt = schedule.EndAt.String()
+	}
+	if schedule.NextRunAt != nil {
+		response.NextRunAt = schedule.NextRunAt.String()
+	}
+	if schedule.LastRunAt != nil {
+		response.LastRunAt = schedule.LastRunAt.String()
+	}
+	for _, r := range runs {
+		run := entity.ScheduledTransferRunResponse{
+			RunID:         r.ID.String(),
+			TransferID:    r.TransferID.String(),
+			ScheduledFor:  r.ScheduledFor.String(),
+			Attempt:       r.Attempt,
+			Status:        r.Status,
+			FailureReason: r.FailureReason,
+			CreatedAt:     r.CreatedAt.String(),
+		}
+		if r.NextRetryAt != nil {
+			run.NextRetryAt = r.NextRetryAt.String()
+		}
+		response.Runs = append(response.Runs, run)
+	}
+	return response
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"bank-backend/module/bank/entity"
+)
+
+func MoneyRequestDTO(request entity.MoneyRequest) entity.MoneyRequestResponse {
+	response := entity.MoneyRequestResponse{
+		MoneyRequestID:       request.ID.String(),
+		RequesterPhoneNumber: request.RequesterPhoneNumber,
+		RequesterName:        MaskName(request.RequesterFirstName, request.RequesterLastName),
+		PayerPhoneNumber:     request.PayerPhoneNumber,
+		PayerName:            MaskName(request.PayerFirstName, request.PayerLastName),
+		Amount:               request.Amount,
+		Remarks:              request.Remarks,
+		Status:               request.Status,
+		ExpiresAt:            request.ExpiresAt.String(),
+		CreatedAt:            request.CreatedAt.String(),
+	}
+	if request.TransferID != nil {
+		response.TransferID = request.TransferID.String()
+	}
+	if request.RespondedAt != nil {
+		response.RespondedAt = request.RespondedAt.String()
+	}
+	return response
+}
+
+func MoneyRequestsDTO(requests []entity.MoneyRequest) []entity.MoneyRequestResponse {
+	response := make([]entity.MoneyRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, MoneyRequestDTO(request))
+	}
+	return response
+}
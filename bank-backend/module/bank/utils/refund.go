@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"bank-backend/module/bank/entity"
+)
+
+func RefundDTO(refund entity.PaymentRefund, payment entity.Payment, user entity.User, prev int) entity.RefundResponse {
+	response := entity.RefundResponse{
+		RefundID:       refund.ID.String(),
+		PaymentID:      refund.PaymentID.String(),
+		TransactionID:  refund.TransactionID.String(),
+		UserID:         refund.UserID.String(),
+		Amount:         refund.Amount,
+		PaymentAmount:  payment.Amount,
+		RefundedAmount: payment.RefundedAmount + refund.Amount,
+		BalanceBefore:  prev,
+		BalanceAfter:   user.Balance,
+		Reason:         refund.Reason,
+		RefundedBy:     refund.AdminPhoneNumber,
+		CreatedAt:      refund.CreatedAt.String(),
+	}
+	return response
+}
+
+func TransferReversalDTO(reversal entity.TransferReversal, transfer entity.Transfer, reversed int) entity.TransferReversalResponse {
+	response := entity.TransferReversalResponse{
+		ReversalID:     reversal.ID.String(),
+		TransferID:     reversal.TransferID.String(),
+		UserID:         reversal.UserID.String(),
+		Amount:         reversal.Amount,
+		TransferAmount: transfer.Amount,
+		ReversedAmount: reversed + reversal.Amount,
+		Status:         entity.TransferStatusPending,
+		Reason:         reversal.Reason,
+		ReversedBy:     reversal.AdminPhoneNumber,
+		CreatedAt:      reversal.CreatedAt.String(),
+	}
+	return response
+}
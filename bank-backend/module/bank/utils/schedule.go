@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bank-backend/module/bank/entity"
+	"time"
+)
+
+// FirstScheduledRun returns the first occurrence at or after startAt. Monthly schedules run on
+// dayOfMonth at the clock time of startAt, on the last day of shorter months.
+func FirstScheduledRun(frequency string, dayOfMonth int, startAt time.Time) time.Time {
+	if frequency != entity.ScheduleFrequencyMonthly {
+		return startAt
+	}
+
+	first := monthlyOccurrence(startAt, 0, dayOfMonth)
+	if first.Before(startAt) {
+		first = monthlyOccurrence(startAt, 1, dayOfMonth)
+	}
+	return first
+}
+
+// NextScheduledRun returns the occurrence after prev, or false when the schedule has none left.
+func NextScheduledRun(frequency string, dayOfMonth int, prev time.Time, endAt *time.Time) (time.Time, bool) {
+	var next time.Time
+	switch frequency {
+	case entity.ScheduleFrequencyDaily:
+		next = prev.AddDate(0, 0, 1)
+	case entity.ScheduleFrequencyWeekly:
+		next = prev.AddDate(0, 0, 7)
+	case entity.ScheduleFrequencyMonthly:
+		next = monthlyOccurrence(prev, 1, dayOfMonth)
+	default:
+		return time.Time{}, false
+	}
+
+	if endAt != nil && next.After(*endAt) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// monthlyOccurrence returns dayOfMonth of the month `months` after t, clamped to the month's length.
+func monthlyOccurrence(t time.Time, months int, dayOfMonth int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if dayOfMonth > lastDay {
+		dayOfMonth = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, dayOfMonth-1)
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"bank-backend/module/bank/entity"
+)
+
+// SplitEqually divides total into n integer shares that sum to total exactly. Shares differ by at
+// most 1: the remainder of the division is given one unit at a time to the first shares.
+func SplitEqually(total int, n int) []int {
+	shares := make([]int, n)
+	base, remainder := total/n, total%n
+	for i := range shares {
+		shares[i] = base
+		if i < remainder {
+			shares[i]++
+		}
+	}
+	return shares
+}
+
+func SplitBillDTO(bill entity.SplitBill) entity.SplitBillResponse {
+	response := entity.SplitBillResponse{
+		SplitBillID:          bill.ID.String(),
+		Title:                bill.Title,
+		OrganizerPhoneNumber: bill.OrganizerPhoneNumber,
+		OrganizerName:        MaskName(bill.OrganizerFirstName, bill.OrganizerLastName),
+		TotalAmount:          bill.TotalAmount,
+		SplitType:            bill.SplitType,
+		Status:               bill.Status,
+		Participants:         make([]entity.SplitBillParticipantResponse, 0, len(bill.Participants)),
+		CreatedAt:            bill.CreatedAt.String(),
+	}
+	if bill.SettledAt != nil {
+		response.SettledAt = bill.SettledAt.String()
+	}
+	for _, p := range bill.Participants {
+		participant := entity.SplitBillParticipantResponse{
+			PhoneNumber:       p.PhoneNumber,
+			Name:              MaskName(p.FirstName, p.LastName),
+			ShareAmount:       p.ShareAmount,
+			Status:            p.Status,
+			LastFailureReason: p.LastFailureReason,
+		}
+		if p.TransferID != nil {
+			participant.TransferID = p.TransferID.String()
+		}
+		if p.LastRemindedAt != nil {
+			participant.LastRemindedAt = p.LastRemindedAt.String()
+		}
+		if p.PaidAt != nil {
+			participant.PaidAt = p.PaidAt.String()
+		}
+		if p.Status == entity.SplitShareStatusPaid {
+			response.PaidAmount += p.ShareAmount
+		}
+		response.Participants = append(response.Participants, participant)
+	}
+	return response
+}
+
+func SplitBillsDTO(bills []entity.SplitBill) []entity.SplitBillResponse {
+	response := make([]entity.SplitBillResponse, 0, len(bills))
+	for _, bill := range bills {
+		response = append(response, SplitBillDTO(bill))
+	}
+	return response
+}
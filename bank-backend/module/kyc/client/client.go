@@ -0,0 +1,56 @@
+// Package client is the API other modules use to call into the kyc module.
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"bank-backend/module/kyc/config"
+	"bank-backend/module/kyc/entity"
+	"bank-backend/module/kyc/internal/repository"
+	"bank-backend/pkg"
+
+	"github.com/google/uuid"
+)
+
+type KycClient struct {
+	kycRepo   *repository.KycRepository
+	blobStore pkg.BlobStore
+}
+
+func NewKycClient(cfg config.KycConfig) *KycClient {
+	return &KycClient{kycRepo: repository.NewKycRepository(cfg.PGx), blobStore: cfg.BlobStore}
+}
+
+// LatestSubmission returns the most recent submission of the user.
+func (c *KycClient) LatestSubmission(ctx context.Context, userID uuid.UUID) (entity.Submission, error) {
+	return c.kycRepo.GetLatestSubmission(ctx, userID)
+}
+
+// ListSubmissions returns up to limit submissions in status, or in any status when it is empty,
+// oldest first.
+func (c *KycClient) ListSubmissions(ctx context.Context, status string, limit int) ([]entity.Submission, error) {
+	return c.kycRepo.ListSubmissions(ctx, status, limit)
+}
+
+func (c *KycClient) GetSubmission(ctx context.Context, id uuid.UUID) (entity.Submission, error) {
+	return c.kycRepo.GetSubmission(ctx, id)
+}
+
+// OpenPhoto opens the ID photo of a submission. The caller closes it.
+func (c *KycClient) OpenPhoto(ctx context.Context, submission entity.Submission) (io.ReadCloser, error) {
+	return c.blobStore.Get(ctx, submission.PhotoKey)
+}
+
+// ReviewSubmission moves a submission to status on behalf of reviewedBy. Approving it moves the user
+// to the verified tier.
+func (c *KycClient) ReviewSubmission(ctx context.Context, id uuid.UUID, status string, reason string, reviewedBy string) (entity.Submission, error) {
+	return c.kycRepo.ReviewSubmission(ctx, entity.Review{
+		SubmissionID: id,
+		Status:       status,
+		Reason:       reason,
+		ReviewedBy:   reviewedBy,
+		ReviewedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"bank-backend/pkg"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type KycConfig struct {
+	PGx       *pgxpool.Pool
+	Fiber     *fiber.App
+	Validate  *validator.Validate
+	BlobStore pkg.BlobStore
+	// MaxPhotoBytes bounds the size of an uploaded ID photo
+	MaxPhotoBytes int64
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Submission statuses. A submission is reviewed in order SUBMITTED, UNDER_REVIEW and then APPROVED
+// or REJECTED; a rejected user may submit again.
+const (
+	KycStatusSubmitted   = "SUBMITTED"
+	KycStatusUnderReview = "UNDER_REVIEW"
+	KycStatusApproved    = "APPROVED"
+	KycStatusRejected    = "REJECTED"
+	// KycStatusNotSubmitted is reported for users without any submission, it is never stored
+	KycStatusNotSubmitted = "NOT_SUBMITTED"
+)
+
+// KYC tiers, see kyc_tier_limit. Approval moves the user to KycTierVerified.
+const (
+	KycTierUnverified = "UNVERIFIED"
+	KycTierVerified   = "VERIFIED"
+)
+
+var kycStatusTransitions = map[string][]string{
+	KycStatusSubmitted:   {KycStatusUnderReview},
+	KycStatusUnderReview: {KycStatusApproved, KycStatusRejected},
+}
+
+// CanChangeKycStatus reports whether a submission may move from one status to another.
+func CanChangeKycStatus(from string, to string) bool {
+	for _, s := range kycStatusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Submission is the identity data a user sent for verification. The ID photo lives in the blob store
+// under PhotoKey.
+type Submission struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	IDNumber         string
+	FullName         string
+	DateOfBirth      time.Time
+	PhotoKey         string
+	PhotoContentType string
+	Status           string
+	RejectionReason  string
+	ReviewedBy       string
+	SubmittedAt      time.Time
+	ReviewedAt       *time.Time
+	UpdatedAt        time.Time
+}
+
+// Review moves a submission to Status on behalf of the reviewing admin.
+type Review struct {
+	SubmissionID uuid.UUID
+	Status       string
+	Reason       string
+	ReviewedBy   string
+	ReviewedAt   time.Time
+}
+
+// SubmitKycRequest is sent as multipart/form-data together with the ID photo in the photo field.
+type SubmitKycRequest struct {
+	IDNumber    string `form:"id_number" validate:"required,len=16,numeric"`
+	FullName    string `form:"full_name" validate:"required,min=1,max=100"`
+	DateOfBirth string `form:"date_of_birth" validate:"required,datetime=2006-01-02"`
+}
+
+// KycSubmissionResponse is the customer's view of a submission, the ID number is masked.
+type KycSubmissionResponse struct {
+	SubmissionID    string `json:"submission_id"`
+	IDNumber        string `json:"id_number"`
+	FullName        string `json:"full_name"`
+	DateOfBirth     string `json:"date_of_birth"`
+	Status          string `json:"status"`
+	RejectionReason string `json:"rejection_reason,omitempty"`
+	SubmittedAt     string `json:"submitted_at"`
+	ReviewedAt      string `json:"reviewed_at,omitempty"`
+}
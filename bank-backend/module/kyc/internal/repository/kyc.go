@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+
+	"bank-backend/module/kyc/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const selectSubmission = `
+	SELECT id, user_id, id_number, full_name, date_of_birth, photo_key, photo_content_type, status,
+		coalesce(rejection_reason, ''), coalesce(reviewed_by, ''), submitted_at, reviewed_at, updated_at
+	FROM kyc_submission
+`
+
+type KycRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewKycRepository(db *pgxpool.Pool) *KycRepository {
+	return &KycRepository{db: db}
+}
+
+// GetUserTier returns the id and KYC tier of the user owning phoneNumber.
+func (k *KycRepository) GetUserTier(ctx context.Context, phoneNumber string) (uuid.UUID, string, error) {
+	query := `SELECT id, kyc_tier FROM "user" WHERE phone_number = $1`
+
+	var id uuid.UUID
+	var tier string
+	err := k.db.QueryRow(ctx, query, phoneNumber).Scan(&id, &tier)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return uuid.UUID{}, "", err
+	}
+	return id, tier, nil
+}
+
+// HasPendingSubmission reports whether the user has a submission waiting for review.
+func (k *KycRepository) HasPendingSubmission(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM kyc_submission WHERE user_id = $1 AND status IN ('SUBMITTED', 'UNDER_REVIEW'))`
+
+	var pending bool
+	err := k.db.QueryRow(ctx, query, userID).Scan(&pending)
+	return pending, err
+}
+
+// InsertSubmission stores a new submission. A user has at most one submission waiting for review,
+// which the partial unique index on user_id enforces even for concurrent uploads.
+func (k *KycRepository) InsertSubmission(ctx context.Context, submission entity.Submission) error {
+	query := `
+		INSERT INTO kyc_submission (id, user_id, id_number, full_name, date_of_birth, photo_key, photo_content_type, status, submitted_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (user_id) WHERE status IN ('SUBMITTED', 'UNDER_REVIEW') DO NOTHING
+	`
+
+	tag, err := k.db.Exec(ctx, query, submission.ID, submission.UserID, submission.IDNumber, submission.FullName,
+		submission.DateOfBirth, submission.PhotoKey, submission.PhotoContentType, submission.Status, submission.SubmittedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrKycSubmissionPending
+	}
+	return nil
+}
+
+// GetLatestSubmission returns the most recent submission of the user.
+func (k *KycRepository) GetLatestSubmission(ctx context.Context, userID uuid.UUID) (entity.Submission, error) {
+	query := selectSubmission + ` WHERE user_id = $1 ORDER BY id DESC LIMIT 1`
+	return scanSubmission(k.db.QueryRow(ctx, query, userID))
+}
+
+func (k *KycRepository) GetSubmission(ctx context.Context, id uuid.UUID) (entity.Submission, error) {
+	query := selectSubmission + ` WHERE id = $1`
+	return scanSubmission(k.db.QueryRow(ctx, query, id))
+}
+
+// ListSubmissions returns the submissions in status, or all of them when status is empty, oldest
+// first so reviewers work through the queue in order.
+func (k *KycRepository) ListSubmissions(ctx context.Context, status string, limit int) ([]entity.Submission, error) {
+	submissions := []entity.Submission{}
+	query := selectSubmission + ` WHERE ($1 = '' OR status = $1) ORDER BY id LIMIT $2`
+
+	rows, err := k.db.Query(ctx, query, status, limit)
+	if err != nil {
+		return submissions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanSubmission(rows)
+		if err != nil {
+			return submissions, err
+		}
+		submissions = append(submissions, s)
+	}
+
+	return submissions, rows.Err()
+}
+
+// ReviewSubmission moves a submission to review.Status. Approving it moves the user to the verified
+// tier in the same transaction.
+func (k *KycRepository) ReviewSubmission(ctx context.Context, review entity.Review) (entity.Submission, error) {
+	tx, err := k.db.Begin(ctx)
+	if err != nil {
+		return entity.Submission{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	selectStatus := `SELECT user_id, status FROM kyc_submission WHERE id = $1 FOR UPDATE`
+	updateSubmission := `
+		UPDATE kyc_submission SET status = $1, rejection_reason = nullif($2, ''), reviewed_by = $3,
+			reviewed_at = CASE WHEN $1 IN ('APPROVED', 'REJECTED') THEN $4 END, updated_at = $4
+		WHERE id = $5
+	`
+	updateTier := `update "user" set kyc_tier = $1, version = version+1, updated_at = $2 where id = $3`
+
+	var userID uuid.UUID
+	var status string
+	err = tx.QueryRow(ctx, selectStatus, review.SubmissionID).Scan(&userID, &status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrKycSubmissionNotFound
+		}
+		return entity.Submission{}, err
+	}
+
+	if !entity.CanChangeKycStatus(status, review.Status) {
+		return entity.Submission{}, pgsql.ErrKycInvalidTransition
+	}
+
+	_, err = tx.Exec(ctx, updateSubmission, review.Status, review.Reason, review.ReviewedBy, review.ReviewedAt, review.SubmissionID)
+	if err != nil {
+		return entity.Submission{}, err
+	}
+
+	if review.Status == entity.KycStatusApproved {
+		if _, err = tx.Exec(ctx, updateTier, entity.KycTierVerified, review.ReviewedAt, userID); err != nil {
+			return entity.Submission{}, err
+		}
+	}
+
+	submission, err := scanSubmission(tx.QueryRow(ctx, selectSubmission+` WHERE id = $1`, review.SubmissionID))
+	if err != nil {
+		return entity.Submission{}, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		return entity.Submission{}, err
+	}
+
+	return submission, nil
+}
+
+func scanSubmission(row pgx.Row) (entity.Submission, error) {
+	s := entity.Submission{}
+	err := row.Scan(&s.ID, &s.UserID, &s.IDNumber, &s.FullName, &s.DateOfBirth, &s.PhotoKey, &s.PhotoContentType, &s.Status,
+		&s.RejectionReason, &s.ReviewedBy, &s.SubmittedAt, &s.ReviewedAt, &s.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrKycSubmissionNotFound
+	}
+	return s, err
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"bank-backend/module/kyc/entity"
+	"bank-backend/module/kyc/internal/repository"
+	"bank-backend/module/kyc/utils"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type KycUseCase interface {
+	Submit(ctx fiber.Ctx, request entity.SubmitKycRequest, photo *multipart.FileHeader, userPhoneNumber string) (entity.KycSubmissionResponse, error)
+	LatestSubmission(ctx fiber.Ctx, userPhoneNumber string) (entity.KycSubmissionResponse, error)
+}
+
+// photoExtensions lists the accepted ID photo content types, sniffed from the upload itself.
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+type KycUC struct {
+	kycRepo       repository.KycRepository
+	blobStore     pkg.BlobStore
+	maxPhotoBytes int64
+}
+
+func NewKycUseCase(kycRepo repository.KycRepository, blobStore pkg.BlobStore, maxPhotoBytes int64) *KycUC {
+	return &KycUC{kycRepo: kycRepo, blobStore: blobStore, maxPhotoBytes: maxPhotoBytes}
+}
+
+// Submit stores the ID photo and records the submission for review. Users already verified or with
+// a submission waiting for review are refused before anything is stored.
+func (k *KycUC) Submit(ctx fiber.Ctx, request entity.SubmitKycRequest, photo *multipart.FileHeader, userPhoneNumber string) (entity.KycSubmissionResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lvState3       = utls.LogEventStateUploadBlob
+		lfState3Status = "state_3_upload_blob_status"
+
+		lvState4       = utls.LogEventStateInsertDB
+		lfState4Status = "state_4_insert_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("kyc-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch User
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	userID, tier, err := k.kycRepo.GetUserTier(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+	if tier == entity.KycTierVerified {
+		err = pgsql.ErrKycAlreadyVerified
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+
+	// checked before the upload so a refused submission stores no photo, InsertSubmission still
+	// catches a concurrent one
+	pending, err := k.kycRepo.HasPendingSubmission(ctx.Context(), userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+	if pending {
+		err = pgsql.ErrKycSubmissionPending
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	/*------------------------------------
+	| Step 3 : Upload Photo
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+
+	photoKey, contentType, err := k.uploadPhoto(ctx, photo, fmt.Sprintf("kyc/%s/%s", userID, id))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState3Status))
+
+	/*------------------------------------
+	| Step 4 : Insert Submission
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState4))
+
+	// the request was validated with the same layout
+	dateOfBirth, _ := time.Parse(utils.DateOfBirthLayout, request.DateOfBirth)
+	now := time.Now()
+	submission := entity.Submission{
+		ID:               id,
+		UserID:           userID,
+		IDNumber:         request.IDNumber,
+		FullName:         request.FullName,
+		DateOfBirth:      dateOfBirth,
+		PhotoKey:         photoKey,
+		PhotoContentType: contentType,
+		Status:           entity.KycStatusSubmitted,
+		SubmittedAt:      now,
+		UpdatedAt:        now,
+	}
+
+	err = k.kycRepo.InsertSubmission(ctx.Context(), submission)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState4Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		// no submission points at the photo, so it would never be read or cleaned up
+		if deleteErr := k.blobStore.Delete(ctx.Context(), photoKey); deleteErr != nil {
+			pkg.LogWarnWithContext(ctx.Context(), "delete kyc photo error", deleteErr, lf)
+		}
+		return entity.KycSubmissionResponse{}, err
+	}
+
+	// the ID number and photo stay out of the logs
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState4Status),
+		pkg.LogEventPayload(submission.ID),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "kyc submitted", lf)
+
+	return utils.KycSubmissionDTO(submission), nil
+}
+
+// uploadPhoto checks the size and sniffed content type of the photo and stores it under keyPrefix
+// plus the extension of its content type. It returns the key and the content type.
+func (k *KycUC) uploadPhoto(ctx fiber.Ctx, photo *multipart.FileHeader, keyPrefix string) (string, string, error) {
+	if photo.Size == 0 || photo.Size > k.maxPhotoBytes {
+		return "", "", pgsql.ErrKycPhotoInvalid
+	}
+
+	file, err := photo.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", "", err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	extension, ok := photoExtensions[contentType]
+	if !ok {
+		return "", "", pgsql.ErrKycPhotoInvalid
+	}
+
+	key := keyPrefix + extension
+	body := io.MultiReader(bytes.NewReader(head), file)
+	if err = k.blobStore.Put(ctx.Context(), key, body); err != nil {
+		return "", "", err
+	}
+	return key, contentType, nil
+}
+
+// LatestSubmission returns the user's most recent submission.
+func (k *KycUC) LatestSubmission(ctx fiber.Ctx, userPhoneNumber string) (entity.KycSubmissionResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("kyc-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch Submission
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	userID, _, err := k.kycRepo.GetUserTier(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+
+	submission, err := k.kycRepo.GetLatestSubmission(ctx.Context(), userID)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.KycSubmissionResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.KycSubmissionDTO(submission), nil
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"bank-backend/module/kyc/config"
+	"bank-backend/module/kyc/entity"
+	"bank-backend/module/kyc/internal/repository"
+	"bank-backend/module/kyc/internal/usecase"
+	"bank-backend/module/middleware"
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+type Rest struct {
+	kycUC    usecase.KycUseCase
+	validate *validator.Validate
+}
+
+func NewRest(cfg config.KycConfig) {
+	kycRepo := repository.NewKycRepository(cfg.PGx)
+	kycUsecase := usecase.NewKycUseCase(*kycRepo, cfg.BlobStore, cfg.MaxPhotoBytes)
+	transport := Rest{kycUC: kycUsecase, validate: cfg.Validate}
+
+	transport.mountKyc(cfg.Fiber)
+}
+
+func (r *Rest) mountKyc(app *fiber.App) {
+	app.Post("/api/v1/kyc", r.Submit, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionProfileWrite))
+	app.Get("/api/v1/kyc", r.LatestSubmission, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer))
+}
+
+func (r *Rest) Submit(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("kyc-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	submitPayload := new(entity.SubmitKycRequest)
+	err := ctx.Bind().MultipartForm(submitPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(submitPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	photo, err := ctx.FormFile("photo")
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "photo missing", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: map[string]string{
+			"photo": "This field is required",
+		}})
+	}
+	// the ID number and photo stay out of the logs
+	lf = append(lf, pkg.LogStatusSuccess(lfState1Status))
+
+	res, err := r.kycUC.Submit(ctx, *submitPayload, photo, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(kycErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) LatestSubmission(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("kyc-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.kycUC.LatestSubmission(ctx, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(kycErrorStatus(err)).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func kycErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgsql.ErrUserNotFound), errors.Is(err, pgsql.ErrKycSubmissionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, pgsql.ErrKycSubmissionPending), errors.Is(err, pgsql.ErrKycAlreadyVerified):
+		return http.StatusConflict
+	case errors.Is(err, pgsql.ErrKycPhotoInvalid):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+
+	"bank-backend/module/kyc/entity"
+)
+
+// DateOfBirthLayout is the layout of dates of birth in requests and responses.
+const DateOfBirthLayout = "2006-01-02"
+
+func KycSubmissionDTO(submission entity.Submission) entity.KycSubmissionResponse {
+	response := entity.KycSubmissionResponse{
+		SubmissionID:    submission.ID.String(),
+		IDNumber:        MaskIDNumber(submission.IDNumber),
+		FullName:        submission.FullName,
+		DateOfBirth:     submission.DateOfBirth.Format(DateOfBirthLayout),
+		Status:          submission.Status,
+		RejectionReason: submission.RejectionReason,
+		SubmittedAt:     submission.SubmittedAt.String(),
+	}
+	if submission.ReviewedAt != nil {
+		response.ReviewedAt = submission.ReviewedAt.String()
+	}
+	return response
+}
+
+// MaskIDNumber keeps only the last four digits of an ID number.
+func MaskIDNumber(idNumber string) string {
+	if len(idNumber) <= 4 {
+		return idNumber
+	}
+	return strings.Repeat("*", len(idNumber)-4) + idNumber[len(idNumber)-4:]
+}
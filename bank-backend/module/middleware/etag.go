@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/etag"
+)
+
+// ETagMiddleware tags successful responses with an ETag of their body and answers 304 Not Modified
+// when the client's If-None-Match still matches, so read endpoints can be polled cheaply. Responses
+// are private to the signed-in user and must be revalidated on every use.
+func ETagMiddleware() fiber.Handler {
+	tag := etag.New()
+	return func(c fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+		return tag(c)
+	}
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"bank-backend/pkg"
+	"bank-backend/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	idempotencyKeyMaxLength = 255
+	// idempotencyKeyTTL is how long a stored response is replayed for
+	idempotencyKeyTTL = 24 * time.Hour
+	// idempotencyLockTimeout releases keys left IN_PROGRESS by a request that never finished
+	idempotencyLockTimeout = time.Minute
+	// idempotencyStoreTimeout bounds storing the response once the handler has finished
+	idempotencyStoreTimeout = 5 * time.Second
+
+	idempotencyStatusInProgress = "IN_PROGRESS"
+	idempotencyStatusCompleted  = "COMPLETED"
+)
+
+type idempotencyRecord struct {
+	Fingerprint    string
+	Status         string
+	ResponseStatus int
+	ResponseBody   []byte
+	ContentType    string
+}
+
+// IdempotencyMiddleware makes a route safe to retry with an Idempotency-Key header. Keys are scoped
+// to the authenticated phone number, so it has to run after RoleBasedMiddleware. The first request
+// for a key runs the handler and its response is stored; a replay with the same body gets that
+// response back byte-for-byte, a replay with a different body gets 409 and a replay while the first
+// request is still running gets 425. Requests without the header are not affected.
+func IdempotencyMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		var (
+			lvState1       = utils.LogEventStateDecodeRequest
+			lfState1Status = "state_1_idempotency_key_status"
+
+			lf = []slog.Attr{
+				pkg.LogEventName("middleware"),
+			}
+		)
+		/*------------------------------------
+		| Step 1 : Claim idempotency key
+		* ----------------------------------*/
+		lf = append(lf, pkg.LogEventState(lvState1))
+
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(c.Context(), "idempotency key too long", errors.New("idempotency key too long"), lf)
+			return c.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+				Message: "idempotency key must not be longer than 255 characters",
+			})
+		}
+
+		phoneNumber, ok := c.Locals("user-phone").(string)
+		if !ok {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(c.Context(), "user-phone missing on context", errors.New("user-phone missing on context"), lf)
+			return c.Status(http.StatusUnauthorized).JSON(utils.StandardResponse{
+				Message: "invalid token or missing jwt token",
+			})
+		}
+
+		hash := sha256.Sum256([]byte(c.Method() + " " + c.Path() + "\n" + string(c.Body())))
+		fingerprint := hex.EncodeToString(hash[:])
+
+		claimed, err := claimIdempotencyKey(c.Context(), phoneNumber, key, fingerprint)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(c.Context(), "claim idempotency key error", err, lf)
+			return c.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+
+		if !claimed {
+			record, err := getIdempotencyKey(c.Context(), phoneNumber, key)
+			if err != nil {
+				lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+				pkg.LogWarnWithContext(c.Context(), "get idempotency key error", err, lf)
+				return c.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+					Message: err.Error(),
+				})
+			}
+			if record.Fingerprint != fingerprint {
+				lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+				pkg.LogWarnWithContext(c.Context(), "idempotency key reused", errors.New("idempotency key reused with a different request"), lf)
+				return c.Status(http.StatusConflict).JSON(utils.StandardResponse{
+					Message: "idempotency key was already used for a different request",
+				})
+			}
+			if record.Status == idempotencyStatusInProgress {
+				lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+				pkg.LogWarnWithContext(c.Context(), "idempotency key in progress", errors.New("request still in progress"), lf)
+				return c.Status(http.StatusTooEarly).JSON(utils.StandardResponse{
+					Message: "a request with this idempotency key is still in progress",
+				})
+			}
+
+			lf = append(lf, pkg.LogStatusSuccess(lfState1Status))
+			pkg.LogInfoWithContext(c.Context(), "idempotent response replayed", lf)
+			c.Set(fiber.HeaderContentType, record.ContentType)
+			c.Set("Idempotent-Replayed", "true")
+			return c.Status(record.ResponseStatus).Send(record.ResponseBody)
+		}
+
+		lf = append(lf, pkg.LogStatusSuccess(lfState1Status))
+
+		/*------------------------------------
+		| Step 2 : Store response
+		* ----------------------------------*/
+		err = c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil || status >= http.StatusInternalServerError {
+			// let the client retry requests that failed on our side
+			if releaseErr := releaseIdempotencyKey(c.Context(), phoneNumber, key); releaseErr != nil {
+				pkg.LogWarnWithContext(c.Context(), "release idempotency key error", releaseErr, lf)
+			}
+			return err
+		}
+
+		record := idempotencyRecord{
+			ResponseStatus: status,
+			ResponseBody:   append([]byte(nil), c.Response().Body()...),
+			ContentType:    string(c.Response().Header.ContentType()),
+		}
+		// the handler already ran, so the response is stored even when the client went away
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(c.Context()), idempotencyStoreTimeout)
+		defer cancel()
+		if storeErr := completeIdempotencyKey(storeCtx, phoneNumber, key, record); storeErr != nil {
+			pkg.LogWarnWithContext(c.Context(), "store idempotent response error", storeErr, lf)
+			// clear the key rather than answer 425 to every retry until idempotencyLockTimeout; when
+			// that fails too the lock timeout still lets the key be claimed again
+			if releaseErr := releaseIdempotencyKey(storeCtx, phoneNumber, key); releaseErr != nil {
+				pkg.LogWarnWithContext(c.Context(), "release idempotency key error", releaseErr, lf)
+			}
+		}
+		return nil
+	}
+}
+
+// claimIdempotencyKey stores the key as IN_PROGRESS. It reports false when the key already exists,
+// unless the existing row expired or was abandoned in progress, in which case it is taken over.
+func claimIdempotencyKey(ctx context.Context, phoneNumber string, key string, fingerprint string) (bool, error) {
+	query := `
+		INSERT INTO idempotency_key (phone_number, idempotency_key, fingerprint, status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (phone_number, idempotency_key) DO UPDATE SET
+			fingerprint = excluded.fingerprint,
+			status = excluded.status,
+			response_status = null,
+			response_body = null,
+			content_type = null,
+			created_at = excluded.created_at,
+			completed_at = null,
+			expires_at = excluded.expires_at
+		WHERE idempotency_key.expires_at < excluded.created_at
+			OR (idempotency_key.status = $4 AND idempotency_key.created_at < $7)
+	`
+	now := time.Now()
+
+	tag, err := db.Exec(ctx, query, phoneNumber, key, fingerprint, idempotencyStatusInProgress, now, now.Add(idempotencyKeyTTL), now.Add(-idempotencyLockTimeout))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func getIdempotencyKey(ctx context.Context, phoneNumber string, key string) (idempotencyRecord, error) {
+	record := idempotencyRecord{}
+	query := `
+		SELECT fingerprint, status, coalesce(response_status, 0), coalesce(response_body, ''), coalesce(content_type, '')
+		FROM idempotency_key WHERE phone_number = $1 AND idempotency_key = $2
+	`
+
+	err := db.QueryRow(ctx, query, phoneNumber, key).Scan(&record.Fingerprint, &record.Status, &record.ResponseStatus, &record.ResponseBody, &record.ContentType)
+	return record, err
+}
+
+func completeIdempotencyKey(ctx context.Context, phoneNumber string, key string, record idempotencyRecord) error {
+	query := `
+		update idempotency_key set status = $1, response_status = $2, response_body = $3, content_type = $4, completed_at = $5
+		where phone_number = $6 and idempotency_key = $7
+	`
+
+	_, err := db.Exec(ctx, query, idempotencyStatusCompleted, record.ResponseStatus, record.ResponseBody, record.ContentType, time.Now(), phoneNumber, key)
+	return err
+}
+
+func releaseIdempotencyKey(ctx context.Context, phoneNumber string, key string) error {
+	query := `delete from idempotency_key where phone_number = $1 and idempotency_key = $2 and status = $3`
+
+	_, err := db.Exec(ctx, query, phoneNumber, key, idempotencyStatusInProgress)
+	return err
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	db *pgxpool.Pool
+)
+
+// SetDBPool sets the pool used by the middlewares that keep state in the database.
+func SetDBPool(dbPool *pgxpool.Pool) {
+	if dbPool == nil {
+		panic("cannot assign nil db pool")
+	}
+
+	db = dbPool
+}
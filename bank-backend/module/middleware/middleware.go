@@ -3,14 +3,15 @@ package middleware
 import (
 	"bank-backend/pkg"
 	"bank-backend/utils"
+	"context"
 	"errors"
-	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func JwtMiddleware() fiber.Handler {
@@ -40,13 +41,7 @@ func JwtMiddleware() fiber.Handler {
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verify the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(pkg.JWTSecret), nil
-		})
+		token, err := pkg.ParseToken(tokenString, jwt.MapClaims{})
 
 		if err != nil || !token.Valid {
 			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
@@ -56,11 +51,46 @@ func JwtMiddleware() fiber.Handler {
 			})
 		}
 
+		// a refresh token is signed with the same keys but must not be accepted as a bearer token
+		claims, _ := token.Claims.(jwt.MapClaims)
+		if typ, _ := claims["typ"].(string); typ != pkg.TokenTypeAccess {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(c.Context(), "not an access token", errors.New("not an access token"), lf)
+			return c.Status(http.StatusUnauthorized).JSON(utils.StandardResponse{
+				Message: "invalid jwt token",
+			})
+		}
+
+		// tokens are deny-listed by jti on logout and when their refresh token family is revoked
+		jti, _ := claims["jti"].(string)
+		revoked, err := isAccessTokenRevoked(c.Context(), jti)
+		if err != nil {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(c.Context(), "check revoked token error", err, lf)
+			return c.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		if revoked {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(c.Context(), "revoked jwt token", errors.New("revoked jwt token"), lf)
+			return c.Status(http.StatusUnauthorized).JSON(utils.StandardResponse{
+				Message: "jwt token has been revoked",
+			})
+		}
+
 		c.Locals("user", token)
+		c.Locals("token-jti", jti)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Locals("token-expires-at", exp.Time)
+		}
 		return c.Next()
 	}
 }
 
+// RoleBasedMiddleware reads the caller's phone number, roles and scopes from the token into Locals
+// ("user-phone", "user-roles", "user-scopes"). When allowedRoles are given the caller must hold at
+// least one of them.
 func RoleBasedMiddleware(allowedRoles ...string) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		var (
@@ -93,22 +123,100 @@ func RoleBasedMiddleware(allowedRoles ...string) fiber.Handler {
 			})
 		}
 
-		userRole, ok := claims["phone_number"].(string)
-		if !ok {
+		userPhone, ok := claims["phone_number"].(string)
+		if !ok || userPhone == "" {
 			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
 			pkg.LogWarnWithContext(c.Context(), "phone_numbre claims missing on token", errors.New("phone_number claims missing on token"), lf)
 			return c.Status(http.StatusForbidden).JSON(utils.StandardResponse{
 				Message: "phone_number claims missing on token",
 			})
 		}
-		// Store the user role in context
-		c.Locals("user-phone", userRole)
+		roles := stringsClaim(claims, "roles")
+		scopes := stringsClaim(claims, "scopes")
+
+		// Store the user phone, roles and scopes in context
+		c.Locals("user-phone", userPhone)
+		c.Locals("user-roles", roles)
+		c.Locals("user-scopes", scopes)
+
+		if len(allowedRoles) > 0 && !containsAny(roles, allowedRoles) {
+			lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+			pkg.LogWarnWithContext(c.Context(), "role not allowed", errors.New("role not allowed"), lf)
+			return c.Status(http.StatusForbidden).JSON(utils.StandardResponse{
+				Message: "access denied: requires role " + strings.Join(allowedRoles, " or "),
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// PermissionMiddleware requires every listed permission in the token's scopes. It runs after
+// RoleBasedMiddleware and answers 403 naming the first missing permission.
+func PermissionMiddleware(permissions ...string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		var (
+			lvState1       = utils.LogEventStateValidateToken
+			lfState1Status = "state_1_validate_permission_status"
+
+			lf = []slog.Attr{
+				pkg.LogEventName("middleware"),
+			}
+		)
+		/*------------------------------------
+		| Step 1 : validate permission
+		* ----------------------------------*/
+		lf = append(lf, pkg.LogEventState(lvState1))
+		scopes, _ := c.Locals("user-scopes").([]string)
+
+		for _, permission := range permissions {
+			if !containsAny(scopes, []string{permission}) {
+				lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+				pkg.LogWarnWithContext(c.Context(), "missing permission", errors.New("missing permission "+permission), lf)
+				return c.Status(http.StatusForbidden).JSON(utils.StandardResponse{
+					Message: "missing permission: " + permission,
+					Errors:  map[string]string{"permission": permission},
+				})
+			}
+		}
 
-		if userRole != "" {
-			return c.Next()
+		return c.Next()
+	}
+}
+
+func stringsClaim(claims jwt.MapClaims, name string) []string {
+	values, _ := claims[name].([]interface{})
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func containsAny(values []string, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
 		}
-		return c.Status(http.StatusForbidden).JSON(utils.StandardResponse{
-			Message: "access denied",
-		})
 	}
+	return false
+}
+
+func isAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	id, err := uuid.Parse(jti)
+	if err != nil {
+		return false, nil
+	}
+
+	var revoked bool
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_access_token WHERE jti = $1)`
+	err = db.QueryRow(ctx, query, id).Scan(&revoked)
+	return revoked, err
 }
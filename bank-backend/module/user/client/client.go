@@ -0,0 +1,62 @@
+// Package client is the API other modules use to call into the user module.
+package client
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/user/config"
+	"bank-backend/module/user/entity"
+	"bank-backend/module/user/internal/repository"
+	"bank-backend/module/user/utils"
+
+	"github.com/google/uuid"
+)
+
+type UserClient struct {
+	userRepo *repository.UserRepository
+}
+
+func NewUserClient(cfg config.UserConfig) *UserClient {
+	return &UserClient{userRepo: repository.NewUserRepository(cfg.PGx, cfg.ConflictRetry)}
+}
+
+// SearchUsers matches query against the phone number prefix and the first and last name.
+func (c *UserClient) SearchUsers(ctx context.Context, query string, limit int) ([]entity.User, error) {
+	return c.userRepo.SearchUsers(ctx, query, limit)
+}
+
+func (c *UserClient) GetUser(ctx context.Context, id uuid.UUID) (entity.User, error) {
+	return c.userRepo.GetUserByID(ctx, id)
+}
+
+// GetUserRoles returns the roles granted to the user owning phoneNumber.
+func (c *UserClient) GetUserRoles(ctx context.Context, phoneNumber string) ([]string, error) {
+	roles, _, err := c.userRepo.GetUserAccess(ctx, phoneNumber)
+	return roles, err
+}
+
+// ChangeUserStatus applies and records an account status transition.
+func (c *UserClient) ChangeUserStatus(ctx context.Context, change entity.AccountStatusChange) (entity.User, error) {
+	return c.userRepo.ChangeUserStatus(ctx, change)
+}
+
+func (c *UserClient) ListAccountStatusHistory(ctx context.Context, id uuid.UUID) ([]entity.AccountStatusChange, error) {
+	return c.userRepo.ListAccountStatusHistory(ctx, id)
+}
+
+// ConsumeTransactionAuthorization spends a token from /api/v1/pin/verify. It fails unless the token
+// was issued to phoneNumber for this exact operation, amount and target and is unused and unexpired.
+func (c *UserClient) ConsumeTransactionAuthorization(ctx context.Context, phoneNumber string, token string, operation string, amount int, target string) error {
+	return c.userRepo.ConsumeTransactionAuthorization(ctx, phoneNumber, utils.HashSecret(token), operation, amount, target, time.Now())
+}
+
+// ResetPinLockout clears the failed PIN attempt counter of phoneNumber and reports whether one was set.
+func (c *UserClient) ResetPinLockout(ctx context.Context, phoneNumber string) (bool, error) {
+	return c.userRepo.ResetPinLockout(ctx, phoneNumber)
+}
+
+// ResetIPPinLockout clears the failed PIN attempt counter of ipAddress and reports whether one was set.
+func (c *UserClient) ResetIPPinLockout(ctx context.Context, ipAddress string) (bool, error) {
+	return c.userRepo.ResetIPPinLockout(ctx, ipAddress)
+}
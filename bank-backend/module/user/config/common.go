@@ -1,6 +1,13 @@
 package config
 
 import (
+	"time"
+
+	kycclient "bank-backend/module/kyc/client"
+	"bank-backend/module/user/entity"
+	"bank-backend/pkg"
+	"bank-backend/utils/pgsql"
+
 	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -10,6 +17,13 @@ import (
 type UserConfig struct {
 	PGx *pgxpool.Pool
 	// Producer *sarama.SyncProducer
-	Fiber    *fiber.App
-	Validate *validator.Validate
+	Fiber         *fiber.App
+	Validate      *validator.Validate
+	ConflictRetry pgsql.RetryPolicy
+	PinLockout    entity.PinLockoutPolicy
+	OTP           entity.OTPPolicy
+	OTPSender     pkg.OTPSender
+	// TransactionAuthorizationTTL is how long a step-up token from /api/v1/pin/verify stays valid
+	TransactionAuthorizationTTL time.Duration
+	Kyc                         *kycclient.KycClient
 }
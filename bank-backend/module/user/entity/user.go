@@ -17,6 +17,126 @@ type User struct {
 	Balance     int
 	Address     string
 	Pin         string
+	Status      string
+	KycTier     string
+	// HeldBalance is the part of Balance reserved by holds and not available to spend
+	HeldBalance int
+}
+
+// Account statuses. FROZEN_DEBIT blocks money leaving the wallet, FROZEN_ALL blocks every movement
+// and sign-in, CLOSED is final.
+const (
+	AccountStatusActive      = "ACTIVE"
+	AccountStatusFrozenDebit = "FROZEN_DEBIT"
+	AccountStatusFrozenAll   = "FROZEN_ALL"
+	AccountStatusClosed      = "CLOSED"
+)
+
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive:      {AccountStatusFrozenDebit, AccountStatusFrozenAll, AccountStatusClosed},
+	AccountStatusFrozenDebit: {AccountStatusActive, AccountStatusFrozenAll, AccountStatusClosed},
+	AccountStatusFrozenAll:   {AccountStatusActive, AccountStatusFrozenDebit, AccountStatusClosed},
+}
+
+// CanChangeAccountStatus reports whether an account may move from one status to another.
+func CanChangeAccountStatus(from string, to string) bool {
+	for _, s := range accountStatusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountStatusBlocksSignIn reports whether users in status may not sign in or keep their sessions.
+func AccountStatusBlocksSignIn(status string) bool {
+	return status == AccountStatusFrozenAll || status == AccountStatusClosed
+}
+
+// AccountStatusChange is one audited transition of an account status.
+type AccountStatusChange struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	FromStatus string
+	ToStatus   string
+	Reason     string
+	ChangedBy  string
+	CreatedAt  time.Time
+}
+
+// Failed PIN attempts are counted per phone number and per client IP.
+const (
+	PinLockoutSubjectPhone = "PHONE"
+	PinLockoutSubjectIP    = "IP"
+)
+
+// PinLockoutPolicy decides how long sign-in is refused after failed PIN attempts. Every failure
+// delays the next attempt by BaseDelay, doubled per failure up to MaxDelay; reaching the maximum
+// attempts locks the phone number or IP for Lockout. Counters start over once the last failure is
+// older than Lockout.
+type PinLockoutPolicy struct {
+	MaxAttempts   int
+	IPMaxAttempts int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	Lockout       time.Duration
+	// Topic receives a PinLockoutEvent whenever a phone number or IP gets locked
+	Topic string
+}
+
+// MaxAttemptsFor returns the number of failures that locks subject.
+func (p PinLockoutPolicy) MaxAttemptsFor(subject string) int {
+	if subject == PinLockoutSubjectIP {
+		return p.IPMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+// LockedUntil returns when the next attempt is allowed after failedAttempts failures, and whether
+// that is a full lockout rather than a progressive delay.
+func (p PinLockoutPolicy) LockedUntil(subject string, failedAttempts int, now time.Time) (time.Time, bool) {
+	if failedAttempts >= p.MaxAttemptsFor(subject) {
+		return now.Add(p.Lockout), true
+	}
+	delay := p.BaseDelay
+	for i := 1; i < failedAttempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return now.Add(delay), false
+}
+
+// PinLockout is the failed PIN attempt counter of a phone number or client IP.
+type PinLockout struct {
+	Subject        string
+	Key            string
+	FailedAttempts int
+	LockedUntil    time.Time
+	Locked         bool
+}
+
+// PinLockoutEvent is published when a phone number or IP gets locked, for alerting.
+type PinLockoutEvent struct {
+	Subject        string `json:"subject"`
+	Key            string `json:"key"`
+	FailedAttempts int    `json:"failed_attempts"`
+	LockedUntil    string `json:"locked_until"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// RefreshToken is the server-side record of an issued refresh token. Every refresh replaces the
+// token with a new one in the same family; the access token issued alongside is kept so it can be
+// deny-listed when the family is revoked.
+type RefreshToken struct {
+	JTI             uuid.UUID
+	FamilyID        uuid.UUID
+	PhoneNumber     string
+	AccessTokenJTI  uuid.UUID
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
 }
 
 type RegisterRequest struct {
@@ -72,9 +192,33 @@ type UpdateProfileResponse struct {
 	Updated_at  string `json:"updated_at"`
 }
 
+// MeResponse is the signed-in user's own profile with the state of their KYC verification.
+// AvailableBalance is what can be spent, the balance less HeldBalance.
+type MeResponse struct {
+	UserID           string     `json:"user_id"`
+	PhoneNumber      string     `json:"phone_number"`
+	FirstName        string     `json:"first_name"`
+	LastName         string     `json:"last_name"`
+	Address          string     `json:"address"`
+	Balance          int        `json:"balance"`
+	AvailableBalance int        `json:"available_balance"`
+	HeldBalance      int        `json:"held_balance,omitempty"`
+	Status           string     `json:"status"`
+	KycTier          string     `json:"kyc_tier"`
+	Kyc              MeKycState `json:"kyc"`
+	CreatedAt        string     `json:"created_at"`
+	UpdatedAt        string     `json:"updated_at"`
+}
+
+type MeKycState struct {
+	Status          string `json:"status"`
+	RejectionReason string `json:"rejection_reason,omitempty"`
+	SubmittedAt     string `json:"submitted_at,omitempty"`
+}
+
 type LoginRequest struct {
-	PhoneNumber string `json:"phone_number"validate:"required,indonesianphone"`
-	Pin         string `json:"pin"validate:"required,len=6,numeric"`
+	PhoneNumber string `json:"phone_number" validate:"required,indonesianphone"`
+	Pin         string `json:"pin" validate:"required,len=6,numeric"`
 }
 
 type LoginResponse struct {
@@ -85,3 +229,82 @@ type LoginResponse struct {
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
+
+// OTPPolicy bounds the one-time passwords of the forgot-PIN flow. A verified OTP is exchanged for a
+// reset token valid for ResetTokenTTL.
+type OTPPolicy struct {
+	TTL            time.Duration
+	MaxAttempts    int
+	ResendCooldown time.Duration
+	ResetTokenTTL  time.Duration
+}
+
+// PinOTP is a one-time password sent for a forgot-PIN request. Only hashes of the OTP and of the
+// reset token it is exchanged for are stored.
+type PinOTP struct {
+	ID                  uuid.UUID
+	PhoneNumber         string
+	OTPHash             string
+	Attempts            int
+	ExpiresAt           time.Time
+	ResetTokenHash      string
+	ResetTokenExpiresAt time.Time
+	CreatedAt           time.Time
+}
+
+type ChangePinRequest struct {
+	OldPin string `json:"old_pin" validate:"required,len=6,numeric"`
+	NewPin string `json:"new_pin" validate:"required,len=6,numeric,nefield=OldPin"`
+}
+
+type ForgotPinRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,indonesianphone"`
+}
+
+type VerifyPinOTPRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,indonesianphone"`
+	OTP         string `json:"otp" validate:"required,len=6,numeric"`
+}
+
+type VerifyPinOTPResponse struct {
+	ResetToken string `json:"reset_token"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// Operations a transaction authorization can be issued for.
+const (
+	TransactionOperationTransfer = "TRANSFER"
+	TransactionOperationPayment  = "PAYMENT"
+)
+
+// TransactionAuthorization is a single-use step-up token proving the PIN was re-entered for one
+// operation of Amount to Target. Only the hash of the token is stored.
+type TransactionAuthorization struct {
+	ID          uuid.UUID
+	PhoneNumber string
+	TokenHash   string
+	Operation   string
+	Amount      int
+	Target      string
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+// AuthorizeTransactionRequest re-verifies the PIN for one operation. Target is the recipient of a
+// transfer as it is addressed in the transfer body, or the payment_reference of a payment.
+type AuthorizeTransactionRequest struct {
+	Pin       string `json:"pin" validate:"required,len=6,numeric"`
+	Operation string `json:"operation" validate:"required,oneof=TRANSFER PAYMENT"`
+	Amount    int    `json:"amount" validate:"required,min=1"`
+	Target    string `json:"target" validate:"required,max=50"`
+}
+
+type AuthorizeTransactionResponse struct {
+	AuthorizationToken string `json:"authorization_token"`
+	ExpiresAt          string `json:"expires_at"`
+}
+
+type ResetPinRequest struct {
+	ResetToken string `json:"reset_token" validate:"required"`
+	NewPin     string `json:"new_pin" validate:"required,len=6,numeric"`
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/user/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (u *UserRepository) InsertTransactionAuthorization(ctx context.Context, authorization entity.TransactionAuthorization) error {
+	query := `
+		INSERT INTO transaction_authorization (id, phone_number, token_hash, operation, amount, target, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := u.db.Exec(ctx, query,
+		authorization.ID,
+		authorization.PhoneNumber,
+		authorization.TokenHash,
+		authorization.Operation,
+		authorization.Amount,
+		authorization.Target,
+		authorization.ExpiresAt,
+		authorization.CreatedAt,
+	)
+	return err
+}
+
+// ConsumeTransactionAuthorization spends the token matching tokenHash. It only matches an unused,
+// unexpired token issued to phoneNumber for exactly this operation, amount and target.
+func (u *UserRepository) ConsumeTransactionAuthorization(ctx context.Context, phoneNumber string, tokenHash string, operation string, amount int, target string, now time.Time) error {
+	query := `
+		update transaction_authorization set used_at = $6
+		where token_hash = $1 and phone_number = $2 and operation = $3 and amount = $4 and target = $5
+			and used_at is null and expires_at > $6
+		returning id
+	`
+
+	var id uuid.UUID
+	err := u.db.QueryRow(ctx, query, tokenHash, phoneNumber, operation, amount, target, now).Scan(&id)
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrTransactionAuthorizationInvalid
+	}
+	return err
+}
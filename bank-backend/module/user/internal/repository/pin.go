@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/user/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ChangePin replaces the PIN of phoneNumber and signs the user out everywhere. The update only
+// applies while the stored hash is still oldPinHash, so two concurrent changes cannot both win.
+func (u *UserRepository) ChangePin(ctx context.Context, phoneNumber string, oldPinHash string, newPinHash string, now time.Time) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `update "user" set pin = $1, version = version+1, updated_at = $2 where phone_number = $3 and pin = $4`
+
+	tag, err := tx.Exec(ctx, query, newPinHash, now, phoneNumber, oldPinHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrConcurrentModification
+	}
+
+	err = revokeUserSessions(ctx, tx, phoneNumber, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// InsertPinOTP stores otp and discards the earlier OTPs of the phone number. It stores nothing and
+// reports false when the last OTP was sent less than cooldown ago.
+func (u *UserRepository) InsertPinOTP(ctx context.Context, otp entity.PinOTP, cooldown time.Duration) (bool, error) {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the user row serialises concurrent requests for the same phone number
+	lockUser := `select id from "user" where phone_number = $1 for update`
+	lastSent := `select max(created_at) from pin_otp where phone_number = $1`
+	discard := `update pin_otp set consumed_at = $2 where phone_number = $1 and consumed_at is null`
+	insert := `
+		INSERT INTO pin_otp (id, phone_number, otp_hash, attempts, expires_at, created_at)
+		VALUES ($1, $2, $3, 0, $4, $5)
+	`
+
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, lockUser, otp.PhoneNumber).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return false, err
+	}
+
+	var lastSentAt *time.Time
+	err = tx.QueryRow(ctx, lastSent, otp.PhoneNumber).Scan(&lastSentAt)
+	if err != nil {
+		return false, err
+	}
+	if lastSentAt != nil && otp.CreatedAt.Sub(*lastSentAt) < cooldown {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, discard, otp.PhoneNumber, otp.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, insert, otp.ID, otp.PhoneNumber, otp.OTPHash, otp.ExpiresAt, otp.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// ClaimPinOTPAttempt counts a verification attempt against the latest unverified OTP of phoneNumber
+// and returns it. The attempt is counted before the code is compared, so concurrent guesses cannot
+// exceed maxAttempts.
+func (u *UserRepository) ClaimPinOTPAttempt(ctx context.Context, phoneNumber string, maxAttempts int, now time.Time) (entity.PinOTP, error) {
+	query := `
+		UPDATE pin_otp SET attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM pin_otp
+			WHERE phone_number = $1 AND consumed_at IS NULL AND reset_token_hash IS NULL
+			ORDER BY created_at DESC LIMIT 1
+		) AND attempts < $2 AND expires_at > $3
+		RETURNING id, phone_number, otp_hash, attempts, expires_at, created_at
+	`
+
+	otp := entity.PinOTP{}
+	err := u.db.QueryRow(ctx, query, phoneNumber, maxAttempts, now).Scan(&otp.ID, &otp.PhoneNumber, &otp.OTPHash, &otp.Attempts, &otp.ExpiresAt, &otp.CreatedAt)
+	if err == pgx.ErrNoRows {
+		err = pgsql.ErrOTPInvalid
+	}
+	return otp, err
+}
+
+// SetPinResetToken marks the OTP as verified by attaching the hash of the reset token issued for it.
+func (u *UserRepository) SetPinResetToken(ctx context.Context, id uuid.UUID, resetTokenHash string, expiresAt time.Time) error {
+	query := `update pin_otp set reset_token_hash = $2, reset_token_expires_at = $3 where id = $1 and reset_token_hash is null`
+
+	tag, err := u.db.Exec(ctx, query, id, resetTokenHash, expiresAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgsql.ErrOTPInvalid
+	}
+	return nil
+}
+
+// ResetPin spends the reset token, sets the new PIN, clears the PIN lockout of the phone number and
+// signs the user out everywhere. It returns the phone number the token was issued for.
+func (u *UserRepository) ResetPin(ctx context.Context, resetTokenHash string, newPinHash string, now time.Time) (string, error) {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	spendToken := `
+		update pin_otp set consumed_at = $2
+		where reset_token_hash = $1 and consumed_at is null and reset_token_expires_at > $2
+		returning phone_number
+	`
+	updatePin := `update "user" set pin = $1, version = version+1, updated_at = $2 where phone_number = $3`
+	clearLockout := `DELETE FROM pin_lockout WHERE phone_number = $1`
+
+	var phoneNumber string
+	err = tx.QueryRow(ctx, spendToken, resetTokenHash, now).Scan(&phoneNumber)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrPinResetTokenInvalid
+		}
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, updatePin, newPinHash, now, phoneNumber)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, clearLockout, phoneNumber)
+	if err != nil {
+		return "", err
+	}
+
+	err = revokeUserSessions(ctx, tx, phoneNumber, now)
+	if err != nil {
+		return "", err
+	}
+
+	return phoneNumber, tx.Commit(ctx)
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"bank-backend/module/user/entity"
+	"bank-backend/pkg"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// pinLockoutTable names the counter table and its key column for each subject.
+var pinLockoutTable = map[string][2]string{
+	entity.PinLockoutSubjectPhone: {"pin_lockout", "phone_number"},
+	entity.PinLockoutSubjectIP:    {"pin_lockout_ip", "ip_address"},
+}
+
+// GetPinLockedUntil returns until when sign-in is refused for phoneNumber or ipAddress, or the zero
+// time when neither is locked at now.
+func (u *UserRepository) GetPinLockedUntil(ctx context.Context, phoneNumber string, ipAddress string, now time.Time) (time.Time, error) {
+	query := `
+		SELECT max(locked_until) FROM (
+			SELECT locked_until FROM pin_lockout WHERE phone_number = $1
+			UNION ALL
+			SELECT locked_until FROM pin_lockout_ip WHERE ip_address = $2
+		) l WHERE locked_until > $3
+	`
+
+	var lockedUntil *time.Time
+	err := u.db.QueryRow(ctx, query, phoneNumber, ipAddress, now).Scan(&lockedUntil)
+	if err != nil || lockedUntil == nil {
+		return time.Time{}, err
+	}
+	return *lockedUntil, nil
+}
+
+// RecordPinFailure counts a failed PIN attempt for key and sets until when the next attempt is
+// refused. When the attempt locks key, the lockout event is written to the outbox in the same
+// transaction.
+func (u *UserRepository) RecordPinFailure(ctx context.Context, subject string, key string, policy entity.PinLockoutPolicy, now time.Time) (entity.PinLockout, error) {
+	table := pinLockoutTable[subject]
+	lockout := entity.PinLockout{Subject: subject, Key: key}
+
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return lockout, err
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `INSERT INTO ` + table[0] + ` (` + table[1] + `, failed_attempts, updated_at) VALUES ($1, 0, $2) ON CONFLICT (` + table[1] + `) DO NOTHING`
+	selectQuery := `SELECT failed_attempts, updated_at FROM ` + table[0] + ` WHERE ` + table[1] + ` = $1 FOR UPDATE`
+	updateQuery := `UPDATE ` + table[0] + ` SET failed_attempts = $1, locked_until = $2, updated_at = $3 WHERE ` + table[1] + ` = $4`
+
+	_, err = tx.Exec(ctx, insertQuery, key, now)
+	if err != nil {
+		return lockout, err
+	}
+
+	var lastFailure time.Time
+	err = tx.QueryRow(ctx, selectQuery, key).Scan(&lockout.FailedAttempts, &lastFailure)
+	if err != nil {
+		return lockout, err
+	}
+	if lastFailure.Before(now.Add(-policy.Lockout)) {
+		lockout.FailedAttempts = 0
+	}
+	lockout.FailedAttempts++
+	lockout.LockedUntil, lockout.Locked = policy.LockedUntil(subject, lockout.FailedAttempts, now)
+
+	_, err = tx.Exec(ctx, updateQuery, lockout.FailedAttempts, lockout.LockedUntil, now, key)
+	if err != nil {
+		return lockout, err
+	}
+
+	if lockout.Locked {
+		err = insertPinLockoutEvent(ctx, tx, policy.Topic, lockout, now)
+		if err != nil {
+			return lockout, err
+		}
+	}
+
+	return lockout, tx.Commit(ctx)
+}
+
+func insertPinLockoutEvent(ctx context.Context, tx pgx.Tx, topic string, lockout entity.PinLockout, now time.Time) error {
+	payload, err := json.Marshal(entity.PinLockoutEvent{
+		Subject:        lockout.Subject,
+		Key:            lockout.Key,
+		FailedAttempts: lockout.FailedAttempts,
+		LockedUntil:    lockout.LockedUntil.String(),
+		CreatedAt:      now.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO outbox (id, topic, message_key, payload, attempts, created_at)
+		VALUES ($1, $2, $3, $4, 0, $5)
+	`
+	_, err = tx.Exec(ctx, query, id, topic, lockout.Key, string(payload), now)
+	return err
+}
+
+// ResetPinLockout clears the failed PIN attempt counter of phoneNumber. It reports whether there was
+// a counter to clear.
+func (u *UserRepository) ResetPinLockout(ctx context.Context, phoneNumber string) (bool, error) {
+	query := `DELETE FROM pin_lockout WHERE phone_number = $1`
+
+	tag, err := u.db.Exec(ctx, query, phoneNumber)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ResetIPPinLockout clears the failed PIN attempt counter of ipAddress. It reports whether there was
+// a counter to clear.
+func (u *UserRepository) ResetIPPinLockout(ctx context.Context, ipAddress string) (bool, error) {
+	query := `DELETE FROM pin_lockout_ip WHERE ip_address = $1`
+
+	tag, err := u.db.Exec(ctx, query, ipAddress)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bank-backend/module/user/entity"
+	"bank-backend/utils/pgsql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func insertRefreshToken(ctx context.Context, tx pgx.Tx, token entity.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_token (jti, family_id, phone_number, access_token_jti, access_expires_at, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := tx.Exec(ctx, query,
+		token.JTI,
+		token.FamilyID,
+		token.PhoneNumber,
+		token.AccessTokenJTI,
+		token.AccessExpiresAt,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	return err
+}
+
+// InsertRefreshToken stores the refresh token of a new login as the first member of its family.
+func (u *UserRepository) InsertRefreshToken(ctx context.Context, token entity.RefreshToken) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = insertRefreshToken(ctx, tx, token); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RotateRefreshToken replaces the refresh token jti with next, which joins the same family. Presenting
+// a token that was already rotated or revoked means it leaked, so the whole family is revoked and
+// ErrRefreshTokenReused is returned.
+func (u *UserRepository) RotateRefreshToken(ctx context.Context, jti uuid.UUID, next entity.RefreshToken) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	selectToken := `
+		select family_id, phone_number, expires_at, rotated_at is not null or revoked_at is not null
+		from refresh_token where jti = $1 for update
+	`
+	rotate := `update refresh_token set rotated_at = $1, replaced_by = $2 where jti = $3`
+
+	var familyID uuid.UUID
+	var phoneNumber string
+	var expiresAt time.Time
+	var used bool
+
+	err = tx.QueryRow(ctx, selectToken, jti).Scan(&familyID, &phoneNumber, &expiresAt, &used)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrRefreshTokenInvalid
+		}
+		return err
+	}
+
+	now := time.Now()
+	if used {
+		if err = revokeTokenFamily(ctx, tx, familyID, now); err != nil {
+			return err
+		}
+		if err = tx.Commit(ctx); err != nil {
+			return err
+		}
+		return pgsql.ErrRefreshTokenReused
+	}
+	if phoneNumber != next.PhoneNumber || !expiresAt.After(now) {
+		return pgsql.ErrRefreshTokenInvalid
+	}
+
+	_, err = tx.Exec(ctx, rotate, now, next.JTI, jti)
+	if err != nil {
+		return err
+	}
+
+	next.FamilyID = familyID
+	if err = insertRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RevokeSession deny-lists the access token and revokes the refresh token family it was issued with.
+// revoked_access_token is the deny-list of every token type, so the revoked refresh tokens go there
+// too.
+func (u *UserRepository) RevokeSession(ctx context.Context, accessTokenJTI uuid.UUID, phoneNumber string, accessExpiresAt time.Time) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	denyAccessToken := `
+		INSERT INTO revoked_access_token (jti, phone_number, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING
+	`
+	selectFamily := `select family_id from refresh_token where access_token_jti = $1`
+
+	now := time.Now()
+	_, err = tx.Exec(ctx, denyAccessToken, accessTokenJTI, phoneNumber, accessExpiresAt, now)
+	if err != nil {
+		return err
+	}
+
+	var familyID uuid.UUID
+	err = tx.QueryRow(ctx, selectFamily, accessTokenJTI).Scan(&familyID)
+	if err != nil && err != pgx.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		if err = revokeTokenFamily(ctx, tx, familyID, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// revokeTokenFamily revokes every refresh token of the family and deny-lists them together with the
+// access tokens issued with them.
+func revokeTokenFamily(ctx context.Context, tx pgx.Tx, familyID uuid.UUID, now time.Time) error {
+	denyTokens := `
+		INSERT INTO revoked_access_token (jti, phone_number, expires_at, revoked_at)
+		SELECT access_token_jti, phone_number, access_expires_at, $2 FROM refresh_token
+		WHERE family_id = $1 AND access_token_jti IS NOT NULL AND access_expires_at > $2
+		UNION ALL
+		SELECT jti, phone_number, expires_at, $2 FROM refresh_token
+		WHERE family_id = $1 AND expires_at > $2
+		ON CONFLICT DO NOTHING
+	`
+	revokeRefreshTokens := `update refresh_token set revoked_at = $2 where family_id = $1 and revoked_at is null`
+
+	_, err := tx.Exec(ctx, denyTokens, familyID, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, revokeRefreshTokens, familyID, now)
+	return err
+}
+
+// revokeUserSessions revokes every refresh token of the user and deny-lists them together with the
+// access tokens issued with them, signing the user out everywhere.
+func revokeUserSessions(ctx context.Context, tx pgx.Tx, phoneNumber string, now time.Time) error {
+	denyTokens := `
+		INSERT INTO revoked_access_token (jti, phone_number, expires_at, revoked_at)
+		SELECT access_token_jti, phone_number, access_expires_at, $2 FROM refresh_token
+		WHERE phone_number = $1 AND access_token_jti IS NOT NULL AND access_expires_at > $2
+		UNION ALL
+		SELECT jti, phone_number, expires_at, $2 FROM refresh_token
+		WHERE phone_number = $1 AND expires_at > $2
+		ON CONFLICT DO NOTHING
+	`
+	revokeRefreshTokens := `update refresh_token set revoked_at = $2 where phone_number = $1 and revoked_at is null`
+
+	_, err := tx.Exec(ctx, denyTokens, phoneNumber, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, revokeRefreshTokens, phoneNumber, now)
+	return err
+}
+
+// GetUserAccess returns the user's roles and the permissions they grant, both sorted.
+func (u *UserRepository) GetUserAccess(ctx context.Context, phoneNumber string) ([]string, []string, error) {
+	query := `
+		SELECT coalesce(array_agg(DISTINCT ur.role_code) FILTER (WHERE ur.role_code IS NOT NULL), '{}'),
+			coalesce(array_agg(DISTINCT rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM "user" u
+			LEFT JOIN user_role ur ON ur.user_id = u.id
+			LEFT JOIN role_permission rp ON rp.role_code = ur.role_code
+		WHERE u.phone_number = $1
+	`
+	var roles, permissions []string
+
+	err := u.db.QueryRow(ctx, query, phoneNumber).Scan(&roles, &permissions)
+	return roles, permissions, err
+}
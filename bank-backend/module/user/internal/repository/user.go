@@ -2,36 +2,58 @@ package repository
 
 import (
 	"context"
+	"strings"
 
 	"bank-backend/module/user/entity"
+	"bank-backend/pkg"
 	"bank-backend/utils/pgsql"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type UserRepository struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	retry pgsql.RetryPolicy
 }
 
-func NewUserRepository(db *pgxpool.Pool) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *pgxpool.Pool, retry pgsql.RetryPolicy) *UserRepository {
+	return &UserRepository{db: db, retry: retry}
 }
 
+// InsertUser creates the user with the customer role.
 func (u *UserRepository) InsertUser(ctx context.Context, user entity.User) (entity.User, error) {
 
 	returningUser := entity.User{}
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return returningUser, err
+	}
+	defer tx.Rollback(ctx)
 
 	query := `
         INSERT INTO "user" (id, phone_number, pin, first_name, last_name, address, created_at, updated_at, version, balance)
         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, first_name, last_name, phone_number, address,  created_at
     `
-	err := u.db.QueryRow(ctx, query, user.ID, user.PhoneNumber, user.Pin, user.FirstName, user.LastName, user.Address, user.CreatedAt, user.UpdatedAt, user.Version, user.Balance).Scan(&returningUser.ID, &returningUser.FirstName, &returningUser.LastName, &returningUser.PhoneNumber, &returningUser.Address, &returningUser.CreatedAt)
+	roleQuery := `INSERT INTO user_role (user_id, role_code, created_at) VALUES ($1, $2, $3)`
+
+	err = tx.QueryRow(ctx, query, user.ID, user.PhoneNumber, user.Pin, user.FirstName, user.LastName, user.Address, user.CreatedAt, user.UpdatedAt, user.Version, user.Balance).Scan(&returningUser.ID, &returningUser.FirstName, &returningUser.LastName, &returningUser.PhoneNumber, &returningUser.Address, &returningUser.CreatedAt)
 
 	if err != nil {
 		return returningUser, err
 	}
 
+	_, err = tx.Exec(ctx, roleQuery, returningUser.ID, pkg.RoleCustomer, user.CreatedAt)
+	if err != nil {
+		return returningUser, err
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		return returningUser, err
+	}
+
 	return returningUser, nil
 }
 
@@ -54,7 +76,17 @@ func (u *UserRepository) CheckPhoneNumberExists(ctx context.Context, phoneNumber
 	return true, PhoneNumber, Pin, nil
 }
 
-func (u *UserRepository) UpdateUser(ctx context.Context, user entity.User) (entity.User, error) {
+// UpdateUser updates the profile, re-running the transaction when a concurrent write wins the
+// version check.
+func (u *UserRepository) UpdateUser(ctx context.Context, user entity.User) (returningUser entity.User, err error) {
+	err = pgsql.RetryOnConflict(ctx, u.retry, "update_user", func() error {
+		returningUser, err = u.updateUser(ctx, user)
+		return err
+	})
+	return returningUser, err
+}
+
+func (u *UserRepository) updateUser(ctx context.Context, user entity.User) (entity.User, error) {
 
 	returningUser := entity.User{}
 	tx, err := u.db.Begin(ctx)
@@ -82,6 +114,9 @@ func (u *UserRepository) UpdateUser(ctx context.Context, user entity.User) (enti
 	err = tx.QueryRow(ctx, query, user.FirstName, user.LastName, user.Address, user.UpdatedAt, user.PhoneNumber, Version).Scan(&returningUser.ID, &returningUser.FirstName, &returningUser.LastName, &returningUser.Address, &returningUser.UpdatedAt)
 
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrConcurrentModification
+		}
 		return returningUser, err
 	}
 
@@ -93,3 +128,174 @@ func (u *UserRepository) UpdateUser(ctx context.Context, user entity.User) (enti
 	return returningUser, nil
 
 }
+
+// likeEscaper escapes the LIKE wildcards so a search query only matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers returns up to limit users whose phone number starts with query or whose first or last
+// name contains it, ignoring case. A query that is empty once trimmed would match every user and
+// returns ErrSearchQueryEmpty.
+func (u *UserRepository) SearchUsers(ctx context.Context, query string, limit int) ([]entity.User, error) {
+	users := []entity.User{}
+	selectUsers := `
+		SELECT id, phone_number, first_name, last_name, coalesce(balance, 0), status, created_at
+		FROM "user"
+		WHERE phone_number LIKE ($1 || '%') ESCAPE '\'
+			OR first_name ILIKE ('%' || $1 || '%') ESCAPE '\'
+			OR last_name ILIKE ('%' || $1 || '%') ESCAPE '\'
+		ORDER BY created_at DESC LIMIT $2
+	`
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return users, pgsql.ErrSearchQueryEmpty
+	}
+
+	rows, err := u.db.Query(ctx, selectUsers, likeEscaper.Replace(query), limit)
+	if err != nil {
+		return users, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := entity.User{}
+		err = rows.Scan(&user.ID, &user.PhoneNumber, &user.FirstName, &user.LastName, &user.Balance, &user.Status, &user.CreatedAt)
+		if err != nil {
+			return users, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (u *UserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (entity.User, error) {
+	query := `
+		SELECT id, phone_number, first_name, last_name, coalesce(address, ''), coalesce(balance, 0), held_balance, status, kyc_tier, version, created_at, updated_at
+		FROM "user" WHERE id = $1
+	`
+	return scanUser(u.db.QueryRow(ctx, query, id))
+}
+
+func (u *UserRepository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (entity.User, error) {
+	query := `
+		SELECT id, phone_number, first_name, last_name, coalesce(address, ''), coalesce(balance, 0), held_balance, status, kyc_tier, version, created_at, updated_at
+		FROM "user" WHERE phone_number = $1
+	`
+	return scanUser(u.db.QueryRow(ctx, query, phoneNumber))
+}
+
+func scanUser(row pgx.Row) (entity.User, error) {
+	user := entity.User{}
+	err := row.Scan(&user.ID, &user.PhoneNumber, &user.FirstName, &user.LastName, &user.Address, &user.Balance, &user.HeldBalance, &user.Status, &user.KycTier, &user.Version, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+func (u *UserRepository) GetUserStatus(ctx context.Context, phoneNumber string) (string, error) {
+	query := `SELECT status FROM "user" WHERE phone_number = $1`
+
+	var status string
+	err := u.db.QueryRow(ctx, query, phoneNumber).Scan(&status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return "", err
+	}
+	return status, nil
+}
+
+// ChangeUserStatus moves the account to change.ToStatus and records the transition in the same
+// transaction. Closing requires a zero balance, and statuses that block sign-in revoke every session.
+// Version is bumped so in-flight profile and balance updates read before the change fail their
+// version check.
+func (u *UserRepository) ChangeUserStatus(ctx context.Context, change entity.AccountStatusChange) (entity.User, error) {
+	user := entity.User{}
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return user, err
+	}
+	defer tx.Rollback(ctx)
+
+	selectUser := `select phone_number, status, coalesce(balance, 0) from "user" where id = $1 for update`
+	updateStatus := `
+		update "user" set status = $1, version = version+1, updated_at = $2 where id = $3
+		RETURNING id, phone_number, status, updated_at
+	`
+	insertHistory := `
+		INSERT INTO account_status_history (id, user_id, from_status, to_status, reason, changed_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	var phoneNumber, status string
+	var balance int
+	err = tx.QueryRow(ctx, selectUser, change.UserID).Scan(&phoneNumber, &status, &balance)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = pgsql.ErrUserNotFound
+		}
+		return user, err
+	}
+
+	if !entity.CanChangeAccountStatus(status, change.ToStatus) {
+		return user, pgsql.ErrAccountInvalidTransition
+	}
+	if change.ToStatus == entity.AccountStatusClosed && balance != 0 {
+		return user, pgsql.ErrAccountBalanceNotZero
+	}
+
+	err = tx.QueryRow(ctx, updateStatus, change.ToStatus, change.CreatedAt, change.UserID).Scan(&user.ID, &user.PhoneNumber, &user.Status, &user.UpdatedAt)
+	if err != nil {
+		return user, err
+	}
+
+	_, err = tx.Exec(ctx, insertHistory, change.ID, change.UserID, status, change.ToStatus, change.Reason, change.ChangedBy, change.CreatedAt)
+	if err != nil {
+		return user, err
+	}
+
+	if entity.AccountStatusBlocksSignIn(change.ToStatus) {
+		if err = revokeUserSessions(ctx, tx, phoneNumber, change.CreatedAt); err != nil {
+			return user, err
+		}
+	}
+
+	// Commit transaction
+	if err = tx.Commit(ctx); err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// ListAccountStatusHistory returns the status transitions of the account, newest first.
+func (u *UserRepository) ListAccountStatusHistory(ctx context.Context, userID uuid.UUID) ([]entity.AccountStatusChange, error) {
+	changes := []entity.AccountStatusChange{}
+	query := `
+		SELECT id, user_id, from_status, to_status, reason, changed_by, created_at
+		FROM account_status_history WHERE user_id = $1 ORDER BY id DESC
+	`
+
+	rows, err := u.db.Query(ctx, query, userID)
+	if err != nil {
+		return changes, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := entity.AccountStatusChange{}
+		err = rows.Scan(&c.ID, &c.UserID, &c.FromStatus, &c.ToStatus, &c.Reason, &c.ChangedBy, &c.CreatedAt)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
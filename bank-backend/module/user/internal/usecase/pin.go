@@ -0,0 +1,340 @@
+package usecase
+
+import (
+	"bank-backend/module/user/entity"
+	"bank-backend/module/user/utils"
+	"bank-backend/pkg"
+	utls "bank-backend/utils"
+	"bank-backend/utils/pgsql"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePin replaces the PIN after checking the old one. A wrong old PIN counts towards the PIN
+// lockout like a failed login. Every session of the user is revoked.
+func (u *UserUC) ChangePin(ctx fiber.Ctx, request entity.ChangePinRequest, userPhoneNumber string) error {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_compare_password_status"
+
+		lvState3       = utls.LogEventStateUpdateDB
+		lfState3Status = "state_3_update_pin_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Check Old PIN
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	now := time.Now()
+	oldPinHash, err := u.verifyPin(ctx, userPhoneNumber, request.OldPin, now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	/*------------------------------------
+	| Step 3 : Update PIN
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	newPinHash, err := bcrypt.GenerateFromPassword([]byte(request.NewPin), bcrypt.DefaultCost)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+
+	err = u.userRepo.ChangePin(ctx.Context(), userPhoneNumber, oldPinHash, string(newPinHash), now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState3Status),
+		pkg.LogEventPayload(userPhoneNumber),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "pin changed", lf)
+	return nil
+}
+
+// AuthorizeTransaction re-verifies the PIN of a signed-in user and issues a single-use token bound to
+// the operation, amount and target. A wrong PIN counts towards the PIN lockout.
+func (u *UserUC) AuthorizeTransaction(ctx fiber.Ctx, request entity.AuthorizeTransactionRequest, userPhoneNumber string) (entity.AuthorizeTransactionResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_compare_password_status"
+
+		lvState3       = utls.LogEventStateInsertDB
+		lfState3Status = "state_3_insert_authorization_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Check PIN
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	now := time.Now()
+	_, err := u.verifyPin(ctx, userPhoneNumber, request.Pin, now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.AuthorizeTransactionResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	/*------------------------------------
+	| Step 3 : Issue Authorization Token
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	token, err := utils.GenerateSecretToken()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.AuthorizeTransactionResponse{}, err
+	}
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.AuthorizeTransactionResponse{}, err
+	}
+
+	authorization := entity.TransactionAuthorization{
+		ID:          id,
+		PhoneNumber: userPhoneNumber,
+		TokenHash:   utils.HashSecret(token),
+		Operation:   request.Operation,
+		Amount:      request.Amount,
+		Target:      request.Target,
+		ExpiresAt:   now.Add(u.authorizationTTL),
+		CreatedAt:   now,
+	}
+	err = u.userRepo.InsertTransactionAuthorization(ctx.Context(), authorization)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.AuthorizeTransactionResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState3Status),
+		pkg.LogEventPayload(id),
+	)
+
+	return entity.AuthorizeTransactionResponse{
+		AuthorizationToken: token,
+		ExpiresAt:          authorization.ExpiresAt.String(),
+	}, nil
+}
+
+// verifyPin checks pin against the stored PIN of a signed-in user, refusing while the phone number
+// or IP is locked out. It returns the stored hash.
+func (u *UserUC) verifyPin(ctx fiber.Ctx, phoneNumber string, pin string, now time.Time) (string, error) {
+	lockedUntil, err := u.userRepo.GetPinLockedUntil(ctx.Context(), phoneNumber, ctx.IP(), now)
+	if err != nil {
+		return "", err
+	}
+	if !lockedUntil.IsZero() {
+		return "", &utils.PinLockedError{RetryAt: lockedUntil}
+	}
+
+	exists, _, pinHash, err := u.userRepo.CheckPhoneNumberExists(ctx.Context(), phoneNumber)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", pgsql.ErrUserNotFound
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(pinHash), []byte(pin)); err != nil {
+		return "", u.recordPinFailure(ctx, phoneNumber, now)
+	}
+
+	_, err = u.userRepo.ResetPinLockout(ctx.Context(), phoneNumber)
+	if err != nil {
+		return "", err
+	}
+	return pinHash, nil
+}
+
+// ForgotPin sends an OTP to the phone number. It succeeds without sending anything when the phone
+// number is unknown or an OTP was sent within the resend cooldown, so the caller cannot tell which.
+func (u *UserUC) ForgotPin(ctx fiber.Ctx, request entity.ForgotPinRequest) error {
+	var (
+		lvState2       = utls.LogEventStateInsertDB
+		lfState2Status = "state_2_insert_otp_status"
+
+		lvState3       = utls.LogEventStateSendOTP
+		lfState3Status = "state_3_send_otp_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Store OTP
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	code, err := utils.GenerateOTP()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+	id, err := pkg.GenerateId()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+
+	now := time.Now()
+	otp := entity.PinOTP{
+		ID:          id,
+		PhoneNumber: request.PhoneNumber,
+		OTPHash:     utils.HashOTP(id, code),
+		ExpiresAt:   now.Add(u.otp.TTL),
+		CreatedAt:   now,
+	}
+	stored, err := u.userRepo.InsertPinOTP(ctx.Context(), otp, u.otp.ResendCooldown)
+	if err == pgsql.ErrUserNotFound {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "otp requested for unknown phone number", err, lf)
+		return nil
+	}
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+	if !stored {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "otp requested within resend cooldown", nil, lf)
+		return nil
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	/*------------------------------------
+	| Step 3 : Send OTP
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState3))
+
+	err = u.otpSender.SendOTP(ctx.Context(), request.PhoneNumber, code)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState3Status),
+		pkg.LogEventPayload(request.PhoneNumber),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "otp sent", lf)
+	return nil
+}
+
+// VerifyPinOTP exchanges a valid OTP for a single-use reset token. Every attempt counts, and the OTP
+// is dead after the maximum attempts even if the right code comes later.
+func (u *UserUC) VerifyPinOTP(ctx fiber.Ctx, request entity.VerifyPinOTPRequest) (entity.VerifyPinOTPResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateValidateToken
+		lfState2Status = "state_2_validate_otp_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Validate OTP
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	now := time.Now()
+	otp, err := u.userRepo.ClaimPinOTPAttempt(ctx.Context(), request.PhoneNumber, u.otp.MaxAttempts, now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.VerifyPinOTPResponse{}, err
+	}
+	if !utils.EqualHash(utils.HashOTP(otp.ID, request.OTP), otp.OTPHash) {
+		err = pgsql.ErrOTPInvalid
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.VerifyPinOTPResponse{}, err
+	}
+
+	resetToken, err := utils.GenerateSecretToken()
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.VerifyPinOTPResponse{}, err
+	}
+	expiresAt := now.Add(u.otp.ResetTokenTTL)
+	err = u.userRepo.SetPinResetToken(ctx.Context(), otp.ID, utils.HashSecret(resetToken), expiresAt)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.VerifyPinOTPResponse{}, err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(request.PhoneNumber),
+	)
+
+	return entity.VerifyPinOTPResponse{
+		ResetToken: resetToken,
+		ExpiresAt:  expiresAt.String(),
+	}, nil
+}
+
+// ResetPin sets a new PIN with the reset token from VerifyPinOTP. It also clears the PIN lockout of
+// the phone number and revokes every session of the user.
+func (u *UserUC) ResetPin(ctx fiber.Ctx, request entity.ResetPinRequest) error {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_update_pin_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Update PIN
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	pinHash, err := bcrypt.GenerateFromPassword([]byte(request.NewPin), bcrypt.DefaultCost)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+
+	phoneNumber, err := u.userRepo.ResetPin(ctx.Context(), utils.HashSecret(request.ResetToken), string(pinHash), time.Now())
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState2Status),
+		pkg.LogEventPayload(phoneNumber),
+	)
+	pkg.LogInfoWithContext(ctx.Context(), "pin reset", lf)
+	return nil
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	kycclient "bank-backend/module/kyc/client"
+	kycentity "bank-backend/module/kyc/entity"
 	"bank-backend/module/user/entity"
 	"bank-backend/module/user/internal/repository"
 	"bank-backend/module/user/utils"
@@ -8,13 +10,12 @@ import (
 	utls "bank-backend/utils"
 	"bank-backend/utils/pgsql"
 	"errors"
-	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -22,17 +23,34 @@ type UserUsecase interface {
 	Register(ctx fiber.Ctx, request entity.RegisterRequest) (entity.RegisterResponse, error)
 	Login(ctx fiber.Ctx, request entity.LoginRequest) (entity.LoginResponse, error)
 	RefreshToken(ctx fiber.Ctx, request entity.RefreshRequest) (entity.LoginResponse, error)
+	Logout(ctx fiber.Ctx, accessTokenJTI string, accessExpiresAt time.Time, userPhoneNumber string) error
 	UpdateProfile(ctx fiber.Ctx, request entity.UpdateProfileRequest, userPhoneNumber string) (entity.UpdateProfileResponse, error)
+	ChangePin(ctx fiber.Ctx, request entity.ChangePinRequest, userPhoneNumber string) error
+	ForgotPin(ctx fiber.Ctx, request entity.ForgotPinRequest) error
+	VerifyPinOTP(ctx fiber.Ctx, request entity.VerifyPinOTPRequest) (entity.VerifyPinOTPResponse, error)
+	ResetPin(ctx fiber.Ctx, request entity.ResetPinRequest) error
+	AuthorizeTransaction(ctx fiber.Ctx, request entity.AuthorizeTransactionRequest, userPhoneNumber string) (entity.AuthorizeTransactionResponse, error)
+	Me(ctx fiber.Ctx, userPhoneNumber string) (entity.MeResponse, error)
 }
 
 type UserUC struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	pinLockout entity.PinLockoutPolicy
+	otp        entity.OTPPolicy
+	otpSender  pkg.OTPSender
+	// authorizationTTL is how long a transaction authorization token stays valid
+	authorizationTTL time.Duration
+	kyc              *kycclient.KycClient
 }
 
-func NewUserUseCase(userRepo repository.UserRepository) *UserUC {
-	return &UserUC{userRepo: userRepo}
+func NewUserUseCase(userRepo repository.UserRepository, pinLockout entity.PinLockoutPolicy, otp entity.OTPPolicy, otpSender pkg.OTPSender, authorizationTTL time.Duration, kyc *kycclient.KycClient) *UserUC {
+	return &UserUC{userRepo: userRepo, pinLockout: pinLockout, otp: otp, otpSender: otpSender, authorizationTTL: authorizationTTL, kyc: kyc}
 }
 
+// dummyPinHash is compared against when the phone number is unknown, so that a failed sign-in takes
+// as long whether the user exists or not.
+var dummyPinHash, _ = bcrypt.GenerateFromPassword([]byte("000000"), bcrypt.DefaultCost)
+
 func (u *UserUC) Register(ctx fiber.Ctx, request entity.RegisterRequest) (entity.RegisterResponse, error) {
 	var (
 		lvState2       = utls.LogEventStateFetchDB
@@ -135,6 +153,22 @@ func (u *UserUC) Login(ctx fiber.Ctx, request entity.LoginRequest) (entity.Login
 	| Step 2 : Check If Username Is Exist
 	* ----------------------------------*/
 	lf = append(lf, pkg.LogEventState(lvState2))
+
+	// a locked phone number or IP is refused before the phone number is even looked up
+	now := time.Now()
+	lockedUntil, err := u.userRepo.GetPinLockedUntil(ctx.Context(), request.PhoneNumber, ctx.IP(), now)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.LoginResponse{}, err
+	}
+	if !lockedUntil.IsZero() {
+		err = &utils.PinLockedError{RetryAt: lockedUntil}
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.LoginResponse{}, err
+	}
+
 	exists, PhoneNumber, password, err := u.userRepo.CheckPhoneNumberExists(ctx.Context(), request.PhoneNumber)
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
@@ -143,6 +177,8 @@ func (u *UserUC) Login(ctx fiber.Ctx, request entity.LoginRequest) (entity.Login
 	}
 
 	if !exists {
+		bcrypt.CompareHashAndPassword(dummyPinHash, []byte(request.Pin))
+		err = u.recordPinFailure(ctx, request.PhoneNumber, now)
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), "Phone Number and PIN doesn't match", err, lf)
 		return entity.LoginResponse{}, err
@@ -158,11 +194,37 @@ func (u *UserUC) Login(ctx fiber.Ctx, request entity.LoginRequest) (entity.Login
 	lf = append(lf, pkg.LogEventState(lvState3))
 
 	if err := bcrypt.CompareHashAndPassword([]byte(password), []byte(request.Pin)); err != nil {
+		err = u.recordPinFailure(ctx, PhoneNumber, now)
 		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
 		pkg.LogWarnWithContext(ctx.Context(), "Phone Number and PIN doesn't match", err, lf)
 		return entity.LoginResponse{}, err
 	}
 
+	// the IP counter is kept, so one valid account does not reset it for guesses on others
+	_, err = u.userRepo.ResetPinLockout(ctx.Context(), PhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.LoginResponse{}, err
+	}
+
+	// the status is only revealed once the PIN matched
+	status, err := u.userRepo.GetUserStatus(ctx.Context(), PhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.LoginResponse{}, err
+	}
+	if entity.AccountStatusBlocksSignIn(status) {
+		err = pgsql.ErrAccountFrozen
+		if status == entity.AccountStatusClosed {
+			err = pgsql.ErrAccountClosed
+		}
+		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.LoginResponse{}, err
+	}
+
 	lf = append(lf,
 		pkg.LogStatusSuccess(lfState3Status),
 		pkg.LogEventPayload(PhoneNumber),
@@ -172,31 +234,54 @@ func (u *UserUC) Login(ctx fiber.Ctx, request entity.LoginRequest) (entity.Login
 	| Step 4 : Generate Access Token & refresh Token
 	* ----------------------------------*/
 	lf = append(lf, pkg.LogEventState(lvState4))
-	accessToken, err := pkg.GenerateAccessTokens(PhoneNumber)
+	familyID, err := pkg.GenerateId()
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState4Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
-		return entity.LoginResponse{}, nil
+		return entity.LoginResponse{}, err
 	}
 
-	refreshToken, err := pkg.GenerateRefreshTokens(PhoneNumber)
+	res, err := u.issueTokens(ctx, PhoneNumber, func(token entity.RefreshToken) error {
+		token.FamilyID = familyID
+		return u.userRepo.InsertRefreshToken(ctx.Context(), token)
+	})
 	if err != nil {
-		lf = append(lf, pkg.LogStatusFailed(lvState4))
+		lf = append(lf, pkg.LogStatusFailed(lfState4Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
 		return entity.LoginResponse{}, err
 	}
 
 	lf = append(lf,
 		pkg.LogStatusSuccess(lfState4Status),
-		pkg.LogEventPayload(refreshToken),
+		pkg.LogEventPayload(PhoneNumber),
 	)
-	res := entity.LoginResponse{
-		Token:        accessToken,
-		RefreshToken: refreshToken,
-	}
 	return res, nil
 }
 
+// recordPinFailure counts a failed sign-in against the phone number and the client IP. It returns
+// pgsql.ErrInvalidCredentials unless the counters could not be written.
+func (u *UserUC) recordPinFailure(ctx fiber.Ctx, phoneNumber string, now time.Time) error {
+	subjects := [][2]string{
+		{entity.PinLockoutSubjectPhone, phoneNumber},
+		{entity.PinLockoutSubjectIP, ctx.IP()},
+	}
+	for _, s := range subjects {
+		lockout, err := u.userRepo.RecordPinFailure(ctx.Context(), s[0], s[1], u.pinLockout, now)
+		if err != nil {
+			return err
+		}
+		if lockout.Locked {
+			pkg.LogWarnWithContext(ctx.Context(), "pin lockout", pgsql.ErrPinLocked, []slog.Attr{
+				pkg.LogEventName("user-service"),
+				pkg.LogEventPayload(lockout),
+			})
+		}
+	}
+	return pgsql.ErrInvalidCredentials
+}
+
+// RefreshToken rotates the refresh token: the presented token is spent and a new access and refresh
+// token pair is returned. Presenting a spent token revokes every token of its family.
 func (u *UserUC) RefreshToken(ctx fiber.Ctx, request entity.RefreshRequest) (entity.LoginResponse, error) {
 	var (
 		lvState2       = utls.LogEventStateValidateToken
@@ -215,32 +300,27 @@ func (u *UserUC) RefreshToken(ctx fiber.Ctx, request entity.RefreshRequest) (ent
 	* ----------------------------------*/
 	lf = append(lf, pkg.LogEventState(lvState2))
 
-	// Parse and validate the refresh token
-	token, err := jwt.ParseWithClaims(request.RefreshToken, &pkg.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(pkg.JWTSecret), nil
-	})
+	// Parse and validate the refresh token, expiry is checked by the parser
+	token, err := pkg.ParseToken(request.RefreshToken, &pkg.Claims{})
 
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
-		return entity.LoginResponse{}, err
+		return entity.LoginResponse{}, pgsql.ErrRefreshTokenInvalid
 	}
 
 	claims, ok := token.Claims.(*pkg.Claims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || claims.TokenType != pkg.TokenTypeRefresh {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), "invalid refresh token", errors.New("invalid refresh token"), lf)
-		return entity.LoginResponse{}, err
+		return entity.LoginResponse{}, pgsql.ErrRefreshTokenInvalid
 	}
 
-	// Check if the token is expired
-	if time.Now().Unix() > claims.ExpiresAt.Unix() {
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
-		pkg.LogWarnWithContext(ctx.Context(), "Refresh token has expired", errors.New("Refresh token has expired"), lf)
-		return entity.LoginResponse{}, err
+		pkg.LogWarnWithContext(ctx.Context(), "invalid refresh token id", err, lf)
+		return entity.LoginResponse{}, pgsql.ErrRefreshTokenInvalid
 	}
 
 	lf = append(lf,
@@ -248,11 +328,13 @@ func (u *UserUC) RefreshToken(ctx fiber.Ctx, request entity.RefreshRequest) (ent
 		pkg.LogEventPayload(claims),
 	)
 	/*------------------------------------
-	| Step 3 : Reg-generate Access Token
+	| Step 3 : Rotate Access Token & Refresh Token
 	* ----------------------------------*/
 	lf = append(lf, pkg.LogEventState(lvState3))
 
-	accessToken, err := pkg.GenerateAccessTokens(claims.PhoneNumber)
+	res, err := u.issueTokens(ctx, claims.PhoneNumber, func(next entity.RefreshToken) error {
+		return u.userRepo.RotateRefreshToken(ctx.Context(), jti, next)
+	})
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState3Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
@@ -260,15 +342,91 @@ func (u *UserUC) RefreshToken(ctx fiber.Ctx, request entity.RefreshRequest) (ent
 	}
 	lf = append(lf,
 		pkg.LogStatusSuccess(lfState3Status),
-		pkg.LogEventPayload(accessToken),
+		pkg.LogEventPayload(claims.PhoneNumber),
 	)
-	res := entity.LoginResponse{
-		Token: accessToken,
-	}
 
 	return res, nil
 }
 
+// Logout deny-lists the access token used for the request and revokes its refresh token family.
+func (u *UserUC) Logout(ctx fiber.Ctx, accessTokenJTI string, accessExpiresAt time.Time, userPhoneNumber string) error {
+	var (
+		lvState2       = utls.LogEventStateUpdateDB
+		lfState2Status = "state_2_revoke_session_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Revoke Session
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	jti, err := uuid.Parse(accessTokenJTI)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), "invalid access token id", err, lf)
+		return err
+	}
+
+	err = u.userRepo.RevokeSession(ctx.Context(), jti, userPhoneNumber, accessExpiresAt)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+	pkg.LogInfoWithContext(ctx.Context(), "session revoked", lf)
+
+	return nil
+}
+
+// issueTokens signs a new access and refresh token pair and hands the refresh token record to store
+// before returning the pair. Roles and permissions are read fresh, so a refresh picks up changes.
+func (u *UserUC) issueTokens(ctx fiber.Ctx, phoneNumber string, store func(entity.RefreshToken) error) (entity.LoginResponse, error) {
+	roles, scopes, err := u.userRepo.GetUserAccess(ctx.Context(), phoneNumber)
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+
+	accessToken, accessClaims, err := pkg.GenerateAccessTokens(phoneNumber, roles, scopes)
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+
+	refreshToken, refreshClaims, err := pkg.GenerateRefreshTokens(phoneNumber)
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+
+	accessJTI, err := uuid.Parse(accessClaims.ID)
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+	refreshJTI, err := uuid.Parse(refreshClaims.ID)
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+
+	err = store(entity.RefreshToken{
+		JTI:             refreshJTI,
+		PhoneNumber:     phoneNumber,
+		AccessTokenJTI:  accessJTI,
+		AccessExpiresAt: accessClaims.ExpiresAt.Time,
+		ExpiresAt:       refreshClaims.ExpiresAt.Time,
+		CreatedAt:       time.Now(),
+	})
+	if err != nil {
+		return entity.LoginResponse{}, err
+	}
+
+	return entity.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
 func (u *UserUC) UpdateProfile(ctx fiber.Ctx, request entity.UpdateProfileRequest, userPhoneNumber string) (entity.UpdateProfileResponse, error) {
 	var (
 		lvState2       = utls.LogEventStateUpdateDB
@@ -308,3 +466,40 @@ func (u *UserUC) UpdateProfile(ctx fiber.Ctx, request entity.UpdateProfileReques
 	dto := utils.UserUpdateToDTO(user)
 	return dto, nil
 }
+
+// Me returns the profile of the signed-in user with the status of their latest KYC submission.
+func (u *UserUC) Me(ctx fiber.Ctx, userPhoneNumber string) (entity.MeResponse, error) {
+	var (
+		lvState2       = utls.LogEventStateFetchDB
+		lfState2Status = "state_2_fetch_db_status"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 2 : Fetch User
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState2))
+
+	user, err := u.userRepo.GetUserByPhoneNumber(ctx.Context(), userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MeResponse{}, err
+	}
+
+	kyc := entity.MeKycState{Status: kycentity.KycStatusNotSubmitted}
+	submission, err := u.kyc.LatestSubmission(ctx.Context(), user.ID)
+	switch {
+	case err == nil:
+		kyc = utils.MeKycStateDTO(submission)
+	case !errors.Is(err, pgsql.ErrKycSubmissionNotFound):
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return entity.MeResponse{}, err
+	}
+	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+
+	return utils.MeDTO(user, kyc), nil
+}
@@ -6,13 +6,18 @@ import (
 	"bank-backend/module/user/entity"
 	"bank-backend/module/user/internal/repository"
 	"bank-backend/module/user/internal/usecase"
+	userutils "bank-backend/module/user/utils"
 	"errors"
 	"fmt"
 
 	"bank-backend/pkg"
 	"bank-backend/utils"
+	"bank-backend/utils/pgsql"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v3"
@@ -24,8 +29,8 @@ type Rest struct {
 }
 
 func NewRest(cfg config.UserConfig) {
-	userRepo := repository.NewUserRepository(cfg.PGx)
-	userUsecase := usecase.NewUserUseCase(*userRepo)
+	userRepo := repository.NewUserRepository(cfg.PGx, cfg.ConflictRetry)
+	userUsecase := usecase.NewUserUseCase(*userRepo, cfg.PinLockout, cfg.OTP, cfg.OTPSender, cfg.TransactionAuthorizationTTL, cfg.Kyc)
 	transport := Rest{userUC: userUsecase, validate: cfg.Validate}
 	// Initialize Fiber app
 	transport.mountUser(cfg.Fiber)
@@ -39,7 +44,15 @@ func (r *Rest) mountUser(app *fiber.App) {
 	app.Post("/api/v1/register", r.Register)
 	app.Post("/api/v1/login", r.Login)
 	app.Post("/api/v1/refresh", r.RefreshToken)
-	app.Put("/api/v1/update", r.UpdateProfile, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware())
+	app.Post("/api/v1/logout", r.Logout, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware())
+	app.Get("/.well-known/jwks.json", r.JWKS)
+	app.Post("/api/v1/pin/change", r.ChangePin, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionProfileWrite))
+	app.Post("/api/v1/pin/verify", r.AuthorizeTransaction, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletWrite))
+	app.Post("/api/v1/pin/forgot", r.ForgotPin)
+	app.Post("/api/v1/pin/forgot/verify", r.VerifyPinOTP)
+	app.Post("/api/v1/pin/reset", r.ResetPin)
+	app.Get("/api/v1/me", r.Me, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionWalletRead), middleware.ETagMiddleware())
+	app.Put("/api/v1/update", r.UpdateProfile, middleware.JwtMiddleware(), middleware.RoleBasedMiddleware(pkg.RoleCustomer), middleware.PermissionMiddleware(pkg.PermissionProfileWrite))
 }
 
 func (r *Rest) Register(ctx fiber.Ctx) error {
@@ -138,9 +151,7 @@ func (r *Rest) Login(ctx fiber.Ctx) error {
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
-		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
-			Message: err.Error(),
-		})
+		return pinErrorResponse(ctx, err)
 	}
 
 	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
@@ -184,6 +195,11 @@ func (r *Rest) RefreshToken(ctx fiber.Ctx) error {
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrRefreshTokenInvalid) || errors.Is(err, pgsql.ErrRefreshTokenReused) {
+			return ctx.Status(http.StatusUnauthorized).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
 		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
 			Message: err.Error(),
 		})
@@ -195,6 +211,41 @@ func (r *Rest) RefreshToken(ctx fiber.Ctx) error {
 	})
 }
 
+func (r *Rest) Logout(ctx fiber.Ctx) error {
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	// Retrieve the user phoneNumber and the token being logged out from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+	jti, _ := ctx.Locals("token-jti").(string)
+	expiresAt, _ := ctx.Locals("token-expires-at").(time.Time)
+
+	err := r.userUC.Logout(ctx, jti, expiresAt, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+	})
+}
+
+// JWKS publishes the public signing keys so other services can verify our tokens.
+func (r *Rest) JWKS(ctx fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.Status(http.StatusOK).JSON(pkg.Keys().JWKS())
+}
+
 func (r *Rest) UpdateProfile(ctx fiber.Ctx) error {
 
 	var (
@@ -238,8 +289,344 @@ func (r *Rest) UpdateProfile(ctx fiber.Ctx) error {
 	)
 
 	res, err := r.userUC.UpdateProfile(ctx, *updatePayload, userPhoneNumber)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrConcurrentModification) {
+			return ctx.Status(http.StatusConflict).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) Me(ctx fiber.Ctx) error {
+
+	var (
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	res, err := r.userUC.Me(ctx, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		if errors.Is(err, pgsql.ErrUserNotFound) {
+			return ctx.Status(http.StatusNotFound).JSON(utils.StandardResponse{
+				Message: err.Error(),
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(utils.StandardResponse{
+			Message: err.Error(),
+		})
+	}
+
 	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
 		Status: "SUCCESS",
 		Result: res,
 	})
 }
+
+func (r *Rest) ChangePin(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	changePayload := new(entity.ChangePinRequest)
+	err := ctx.Bind().JSON(changePayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(changePayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	// PINs, OTPs and reset tokens are kept out of the logs
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(userPhoneNumber),
+	)
+
+	err = r.userUC.ChangePin(ctx, *changePayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return pinErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status:  "SUCCESS",
+		Message: "PIN changed, please log in again",
+	})
+}
+
+func (r *Rest) ForgotPin(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState1))
+	forgotPayload := new(entity.ForgotPinRequest)
+	err := ctx.Bind().JSON(forgotPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(forgotPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(forgotPayload.PhoneNumber),
+	)
+
+	err = r.userUC.ForgotPin(ctx, *forgotPayload)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return pinErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(utils.StandardResponse{
+		Status:  "SUCCESS",
+		Message: "if the phone number is registered, an OTP has been sent",
+	})
+}
+
+func (r *Rest) VerifyPinOTP(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState1))
+	verifyPayload := new(entity.VerifyPinOTPRequest)
+	err := ctx.Bind().JSON(verifyPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(verifyPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(verifyPayload.PhoneNumber),
+	)
+
+	res, err := r.userUC.VerifyPinOTP(ctx, *verifyPayload)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return pinErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+func (r *Rest) ResetPin(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	lf = append(lf, pkg.LogEventState(lvState1))
+	resetPayload := new(entity.ResetPinRequest)
+	err := ctx.Bind().JSON(resetPayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(resetPayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload("pin reset"),
+	)
+
+	err = r.userUC.ResetPin(ctx, *resetPayload)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return pinErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status:  "SUCCESS",
+		Message: "PIN changed, please log in again",
+	})
+}
+
+func (r *Rest) AuthorizeTransaction(ctx fiber.Ctx) error {
+
+	var (
+		lvState1       = utils.LogEventStateDecodeRequest
+		lfState1Status = "state_1_decode_request_status"
+
+		lvState2       = utils.LogEventStateCallUsecase
+		lfState2Status = "state_2_call_usecase"
+
+		lf = []slog.Attr{
+			pkg.LogEventName("user-service"),
+		}
+	)
+	/*------------------------------------
+	| Step 1 : Decode request
+	* ----------------------------------*/
+	// Retrieve the user phoneNumber from the context
+	userPhoneNumber := ctx.Locals("user-phone").(string)
+
+	lf = append(lf, pkg.LogEventState(lvState1))
+	authorizePayload := new(entity.AuthorizeTransactionRequest)
+	err := ctx.Bind().JSON(authorizePayload)
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "error processed request", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{
+			Message: "error processed request",
+		})
+	}
+	// Validate the struct
+	if err = r.validate.Struct(authorizePayload); err != nil {
+		errors := utils.FormatValidationErrors(err)
+		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
+		pkg.LogWarnWithContext(ctx.Context(), "validation invalid", err, lf)
+		return ctx.Status(http.StatusBadRequest).JSON(utils.StandardResponse{Errors: errors})
+	}
+	lf = append(lf,
+		pkg.LogStatusSuccess(lfState1Status),
+		pkg.LogEventPayload(userPhoneNumber),
+	)
+
+	res, err := r.userUC.AuthorizeTransaction(ctx, *authorizePayload, userPhoneNumber)
+	lf = append(lf, pkg.LogEventState(lvState2))
+	if err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogWarnWithContext(ctx.Context(), err.Error(), err, lf)
+		return pinErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(utils.StandardResponse{
+		Status: "SUCCESS",
+		Result: res,
+	})
+}
+
+// pinErrorResponse maps the errors of login and the PIN flows to a response.
+func pinErrorResponse(ctx fiber.Ctx, err error) error {
+	status := http.StatusInternalServerError
+	var locked *userutils.PinLockedError
+	switch {
+	case errors.As(err, &locked):
+		retryAfter := int(math.Ceil(time.Until(locked.RetryAt).Seconds()))
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(retryAfter, 1)))
+		status = http.StatusTooManyRequests
+	case errors.Is(err, pgsql.ErrInvalidCredentials), errors.Is(err, pgsql.ErrOTPInvalid), errors.Is(err, pgsql.ErrPinResetTokenInvalid):
+		status = http.StatusUnauthorized
+	case errors.Is(err, pgsql.ErrAccountFrozen), errors.Is(err, pgsql.ErrAccountClosed):
+		status = http.StatusForbidden
+	case errors.Is(err, pgsql.ErrUserNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, pgsql.ErrConcurrentModification):
+		status = http.StatusConflict
+	}
+	return ctx.Status(status).JSON(utils.StandardResponse{
+		Message: err.Error(),
+	})
+}
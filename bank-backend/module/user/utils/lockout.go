@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"time"
+
+	"bank-backend/utils/pgsql"
+)
+
+// PinLockedError refuses a sign-in until RetryAt. It matches pgsql.ErrPinLocked and says nothing
+// about whether the phone number exists.
+type PinLockedError struct {
+	RetryAt time.Time
+}
+
+func (e *PinLockedError) Error() string {
+	return pgsql.ErrPinLocked.Error()
+}
+
+func (e *PinLockedError) Unwrap() error {
+	return pgsql.ErrPinLocked
+}
@@ -1,6 +1,9 @@
 package utils
 
-import "bank-backend/module/user/entity"
+import (
+	kycentity "bank-backend/module/kyc/entity"
+	"bank-backend/module/user/entity"
+)
 
 func UserToDTO(user entity.User) entity.RegisterResponse {
 	response := entity.RegisterResponse{
@@ -25,3 +28,31 @@ func UserUpdateToDTO(user entity.User) entity.UpdateProfileResponse {
 	}
 	return response
 }
+
+func MeDTO(user entity.User, kyc entity.MeKycState) entity.MeResponse {
+	response := entity.MeResponse{
+		UserID:           user.ID.String(),
+		PhoneNumber:      user.PhoneNumber,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		Address:          user.Address,
+		Balance:          user.Balance,
+		AvailableBalance: user.Balance - user.HeldBalance,
+		HeldBalance:      user.HeldBalance,
+		Status:           user.Status,
+		KycTier:          user.KycTier,
+		Kyc:              kyc,
+		CreatedAt:        user.CreatedAt.String(),
+		UpdatedAt:        user.UpdatedAt.String(),
+	}
+	return response
+}
+
+func MeKycStateDTO(submission kycentity.Submission) entity.MeKycState {
+	response := entity.MeKycState{
+		Status:          submission.Status,
+		RejectionReason: submission.RejectionReason,
+		SubmittedAt:     submission.SubmittedAt.String(),
+	}
+	return response
+}
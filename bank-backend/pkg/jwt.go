@@ -11,18 +11,55 @@ import (
 const (
 	AccessTokenExpiration  = 24 * time.Hour
 	RefreshTokenExpiration = 7 * 24 * time.Hour
-	JWTSecret              = "farhan-dwian" // In production, use an environment variable
 )
 
+// TokenTypeAccess and TokenTypeRefresh are the typ claim of the two tokens, so neither is accepted
+// in place of the other.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	keyManager *KeyManager
+)
+
+// SetKeyManager sets the keys every token is issued and verified with.
+func SetKeyManager(km *KeyManager) {
+	if km == nil {
+		panic("cannot assign nil key manager")
+	}
+
+	keyManager = km
+}
+
+// Keys returns the key manager set with SetKeyManager.
+func Keys() *KeyManager {
+	return keyManager
+}
+
+// ParseToken verifies tokenString with the configured keys and decodes it into claims.
+func ParseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return keyManager.Parse(tokenString, claims)
+}
+
 type Claims struct {
-	PhoneNumber string `json:"phone_number"`
+	PhoneNumber string   `json:"phone_number"`
+	TokenType   string   `json:"typ"`
+	Roles       []string `json:"roles,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessTokens(phone string) (string, error) {
+// GenerateAccessTokens returns the signed token and its claims, whose ID is the token's jti. The
+// roles and scopes are checked by RoleBasedMiddleware and PermissionMiddleware.
+func GenerateAccessTokens(phone string, roles []string, scopes []string) (string, Claims, error) {
 	// Generate Access Token
 	accessTokenClaims := Claims{
 		PhoneNumber: phone,
+		TokenType:   TokenTypeAccess,
+		Roles:       roles,
+		Scopes:      scopes,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -33,19 +70,21 @@ func GenerateAccessTokens(phone string) (string, error) {
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
-	accessTokenString, err := accessToken.SignedString([]byte(JWTSecret))
+	accessTokenString, err := keyManager.Sign(accessTokenClaims)
 	if err != nil {
-		return "", err
+		return "", Claims{}, err
 	}
 
-	return accessTokenString, nil
+	return accessTokenString, accessTokenClaims, nil
 }
 
-func GenerateRefreshTokens(phone string) (string, error) {
+// GenerateRefreshTokens returns the signed token and its claims. The jti in the claims is the key the
+// token is stored under server-side.
+func GenerateRefreshTokens(phone string) (string, Claims, error) {
 	// Generate Refresh Token
 	refreshTokenClaims := Claims{
 		PhoneNumber: phone,
+		TokenType:   TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -56,10 +95,9 @@ func GenerateRefreshTokens(phone string) (string, error) {
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshTokenClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(JWTSecret))
+	refreshTokenString, err := keyManager.Sign(refreshTokenClaims)
 	if err != nil {
-		return "", err
+		return "", Claims{}, err
 	}
-	return refreshTokenString, nil
+	return refreshTokenString, refreshTokenClaims, nil
 }
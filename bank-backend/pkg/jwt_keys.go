@@ -0,0 +1,311 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+var (
+	ErrUnknownSigningKey = errors.New("jwt: unknown signing key")
+	ErrNoActiveKey       = errors.New("jwt: active signing key has no private key")
+)
+
+// KeyConfig describes one signing key. HS256 keys take Secret or SecretFile. RS256 and ES256 keys take
+// a PEM PrivateKeyFile, or only a PublicKeyFile for a retired key that still verifies tokens. An
+// RS256/ES256 key without any file gets an ephemeral key pair, which only suits local development.
+type KeyConfig struct {
+	ID             string
+	Algorithm      string
+	Secret         string
+	SecretFile     string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+type signingKey struct {
+	id        string
+	algorithm string
+	method    jwt.SigningMethod
+	sign      interface{}
+	verify    interface{}
+}
+
+// KeyManager signs tokens with the active key and verifies them with whichever configured key the
+// token's kid header names, so keys can be rotated by adding the new key, making it active and
+// dropping the old one once its tokens have expired.
+type KeyManager struct {
+	active string
+	keys   map[string]signingKey
+	order  []string
+}
+
+func NewKeyManager(activeKeyID string, configs []KeyConfig) (*KeyManager, error) {
+	km := &KeyManager{active: activeKeyID, keys: make(map[string]signingKey)}
+	for _, cfg := range configs {
+		if cfg.ID == "" {
+			return nil, errors.New("jwt: signing key without id")
+		}
+		if _, ok := km.keys[cfg.ID]; ok {
+			return nil, fmt.Errorf("jwt: duplicate signing key %q", cfg.ID)
+		}
+
+		key, err := loadSigningKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: load signing key %q: %w", cfg.ID, err)
+		}
+		km.keys[cfg.ID] = key
+		km.order = append(km.order, cfg.ID)
+	}
+
+	active, ok := km.keys[activeKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownSigningKey, activeKeyID)
+	}
+	if active.sign == nil {
+		return nil, ErrNoActiveKey
+	}
+	return km, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header.
+func (k *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	key := k.keys[k.active]
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.sign)
+}
+
+// Parse verifies the token with the key named by its kid header and decodes it into claims.
+func (k *KeyManager) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, k.keyFunc, jwt.WithValidMethods(k.algorithms()))
+}
+
+func (k *KeyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	// a token must use the algorithm of its key, otherwise an RS256 public key could be fed to HS256
+	if token.Method.Alg() != key.algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verify, nil
+}
+
+func (k *KeyManager) algorithms() []string {
+	seen := make(map[string]bool)
+	algorithms := make([]string, 0, len(k.keys))
+	for _, id := range k.order {
+		alg := k.keys[id].algorithm
+		if !seen[alg] {
+			seen[alg] = true
+			algorithms = append(algorithms, alg)
+		}
+	}
+	return algorithms
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of every asymmetric key. HS256 secrets are never published.
+func (k *KeyManager) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(k.keys))}
+	for _, id := range k.order {
+		key := k.keys[id]
+		switch pub := key.verify.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.id,
+				Use:       "sig",
+				Algorithm: key.algorithm,
+				N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			ecdh, err := pub.ECDH()
+			if err != nil {
+				continue
+			}
+			// uncompressed point: 0x04 || X || Y, 32 bytes each on P-256
+			point := ecdh.Bytes()
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "EC",
+				KeyID:     key.id,
+				Use:       "sig",
+				Algorithm: key.algorithm,
+				Curve:     "P-256",
+				X:         base64.RawURLEncoding.EncodeToString(point[1:33]),
+				Y:         base64.RawURLEncoding.EncodeToString(point[33:]),
+			})
+		}
+	}
+	return set
+}
+
+func loadSigningKey(cfg KeyConfig) (signingKey, error) {
+	key := signingKey{id: cfg.ID, algorithm: strings.ToUpper(cfg.Algorithm)}
+
+	switch key.algorithm {
+	case AlgorithmHS256:
+		key.method = jwt.SigningMethodHS256
+		secret := []byte(cfg.Secret)
+		if cfg.SecretFile != "" {
+			b, err := os.ReadFile(cfg.SecretFile)
+			if err != nil {
+				return key, err
+			}
+			secret = []byte(strings.TrimSpace(string(b)))
+		}
+		if len(secret) < 32 {
+			return key, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		key.sign, key.verify = secret, secret
+		return key, nil
+	case AlgorithmRS256:
+		key.method = jwt.SigningMethodRS256
+	case AlgorithmES256:
+		key.method = jwt.SigningMethodES256
+	default:
+		return key, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	var (
+		private crypto.Signer
+		public  crypto.PublicKey
+		err     error
+	)
+	switch {
+	case cfg.PrivateKeyFile != "":
+		private, err = readPrivateKey(cfg.PrivateKeyFile)
+		if err != nil {
+			return key, err
+		}
+		public = private.Public()
+	case cfg.PublicKeyFile != "":
+		public, err = readPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return key, err
+		}
+	default:
+		slog.Warn("no key file configured, generating an ephemeral signing key; tokens will not survive a restart",
+			slog.String("kid", cfg.ID), slog.String("alg", key.algorithm))
+		if key.algorithm == AlgorithmRS256 {
+			private, err = rsa.GenerateKey(rand.Reader, 2048)
+		} else {
+			private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		}
+		if err != nil {
+			return key, err
+		}
+		public = private.Public()
+	}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if key.algorithm != AlgorithmRS256 {
+			return key, fmt.Errorf("RSA key cannot be used for %s", key.algorithm)
+		}
+		if pub.N.BitLen() < 2048 {
+			return key, errors.New("RSA key must be at least 2048 bits")
+		}
+	case *ecdsa.PublicKey:
+		if key.algorithm != AlgorithmES256 {
+			return key, fmt.Errorf("EC key cannot be used for %s", key.algorithm)
+		}
+		if pub.Curve != elliptic.P256() {
+			return key, errors.New("ES256 requires a P-256 key")
+		}
+	default:
+		return key, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	if private != nil {
+		key.sign = private
+	}
+	key.verify = public
+	return key, nil
+}
+
+func readPEM(fn string) (*pem.Block, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", fn)
+	}
+	return block, nil
+}
+
+func readPrivateKey(fn string) (crypto.Signer, error) {
+	block, err := readPEM(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported private key type %T", fn, key)
+		}
+		return signer, nil
+	}
+}
+
+func readPublicKey(fn string) (crypto.PublicKey, error) {
+	block, err := readPEM(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -27,3 +27,15 @@ func PublishMessage(producer sarama.SyncProducer, topic, value string) error {
 	_, _, err := producer.SendMessage(msg)
 	return err
 }
+
+// PublishMessageWithKey publishes value keyed by key, so messages for the same key land on the same
+// partition and keep their order.
+func PublishMessageWithKey(producer sarama.SyncProducer, topic, key, value string) error {
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(key),
+		Value: sarama.StringEncoder(value),
+	}
+	_, _, err := producer.SendMessage(msg)
+	return err
+}
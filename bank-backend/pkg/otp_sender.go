@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// OTPSender delivers a one-time password to a phone number. Production senders wrap an SMS gateway.
+type OTPSender interface {
+	SendOTP(ctx context.Context, phoneNumber string, code string) error
+}
+
+// LogOTPSender writes OTPs to the application log. It is meant for local development only.
+type LogOTPSender struct{}
+
+func (LogOTPSender) SendOTP(ctx context.Context, phoneNumber string, code string) error {
+	slog.InfoContext(ctx, "otp sent", slog.String("phone_number", phoneNumber), slog.String("otp", code))
+	return nil
+}
+
+// FileOTPSender appends every OTP as a line to Path, so tests and local tools can read it back.
+type FileOTPSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileOTPSender) SendOTP(_ context.Context, phoneNumber string, code string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s %s %s\n", time.Now().Format(time.RFC3339), phoneNumber, code)
+	return err
+}
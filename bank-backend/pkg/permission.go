@@ -0,0 +1,17 @@
+package pkg
+
+// Roles are granted per user in user_role. Each role grants the permissions listed in role_permission,
+// and both are embedded in the access token at login and refresh.
+const (
+	RoleCustomer = "customer"
+	RoleSupport  = "support"
+	RoleAdmin    = "admin"
+)
+
+const (
+	PermissionWalletRead   = "wallet:read"
+	PermissionWalletWrite  = "wallet:write"
+	PermissionProfileWrite = "profile:write"
+	PermissionUsersRead    = "users:read"
+	PermissionUsersWrite   = "users:write"
+)
@@ -12,4 +12,6 @@ var (
 	LogEventStateMapper        = "mapper"
 	LogEventStateCallUsecase   = "internal server error"
 	LogEventStateKafkaPublish  = "kafka_publish"
+	LogEventStateSendOTP       = "send_otp"
+	LogEventStateUploadBlob    = "upload_blob"
 )
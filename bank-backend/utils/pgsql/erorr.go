@@ -5,4 +5,72 @@ import "errors"
 var (
 	ErrUserNotFound     = errors.New("user: not found")
 	ErrBalanceNotEnough = errors.New("bank: balance not enough")
+	ErrTransferNotFound = errors.New("transfer: not found")
+	ErrTransferSelf     = errors.New("transfer: cannot transfer to yourself")
+	ErrSearchQueryEmpty = errors.New("user: search query must not be empty")
+	// ErrConcurrentModification means a row changed between read and update, so the version check missed
+	ErrConcurrentModification = errors.New("pgsql: concurrent modification, please retry")
+
+	// ErrInvalidCredentials is returned for an unknown phone number and a wrong PIN alike
+	ErrInvalidCredentials = errors.New("user: phone number and PIN doesn't match")
+	ErrPinLocked          = errors.New("user: too many failed PIN attempts, try again later")
+
+	ErrOTPInvalid           = errors.New("otp: invalid or expired")
+	ErrPinResetTokenInvalid = errors.New("pin: invalid or expired reset token")
+
+	ErrTransactionAuthorizationRequired = errors.New("authorization: PIN re-verification required for this amount")
+	ErrTransactionAuthorizationInvalid  = errors.New("authorization: invalid, expired or already used token")
+
+	ErrRefreshTokenInvalid = errors.New("token: invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("token: refresh token already used, session revoked")
+
+	ErrScheduledTransferNotFound          = errors.New("scheduled transfer: not found")
+	ErrScheduledTransferInvalidState      = errors.New("scheduled transfer: not allowed in the current status")
+	ErrScheduledTransferStartInPast       = errors.New("scheduled transfer: start_at must be in the future")
+	ErrScheduledTransferSelf              = errors.New("scheduled transfer: cannot transfer to yourself")
+	ErrScheduledTransferEndBeforeFirstRun = errors.New("scheduled transfer: end_at must not be before the first run")
+
+	ErrAccountFrozen            = errors.New("account: frozen")
+	ErrAccountClosed            = errors.New("account: closed")
+	ErrAccountInvalidTransition = errors.New("account: status change not allowed")
+	ErrAccountBalanceNotZero    = errors.New("account: balance must be zero or paid out before closing")
+
+	ErrLimitExceeded = errors.New("limit: exceeded")
+
+	ErrBeneficiaryNotFound = errors.New("beneficiary: not found")
+	ErrBeneficiaryExists   = errors.New("beneficiary: recipient already saved")
+	ErrBeneficiarySelf     = errors.New("beneficiary: cannot save your own phone number")
+
+	ErrMoneyRequestNotFound      = errors.New("money request: not found")
+	ErrMoneyRequestInvalidState  = errors.New("money request: not allowed in the current status")
+	ErrMoneyRequestExpired       = errors.New("money request: expired")
+	ErrMoneyRequestSelf          = errors.New("money request: cannot request money from yourself")
+	ErrMoneyRequestExpiryInvalid = errors.New("money request: expires_at must be in the future and within 30 days")
+
+	ErrSplitBillNotFound            = errors.New("split bill: not found")
+	ErrSplitBillNotOrganizer        = errors.New("split bill: only the organizer can do this")
+	ErrSplitBillNotParticipant      = errors.New("split bill: you have no share in this bill")
+	ErrSplitBillShareInvalidState   = errors.New("split bill: share is not payable in the current status")
+	ErrSplitBillParticipantsInvalid = errors.New("split bill: participants must be distinct and include someone besides the organizer")
+	ErrSplitBillSharesInvalid       = errors.New("split bill: shares must be set only for a CUSTOM split, be at least 1 each and sum to total_amount")
+
+	ErrPaymentNotFound         = errors.New("payment: not found")
+	ErrRefundExceedsPayment    = errors.New("refund: amount exceeds what is left to refund of the payment")
+	ErrTransferNotReversible   = errors.New("reversal: only a completed transfer that is not a reversal itself can be reversed")
+	ErrReversalExceedsTransfer = errors.New("reversal: amount exceeds what is left to reverse of the transfer")
+
+	ErrPaymentReferenceRequired = errors.New("payment: payment_reference is required above the step-up threshold")
+	ErrPaymentReferenceUsed     = errors.New("payment: payment_reference was already paid from this wallet")
+
+	ErrHoldNotFound       = errors.New("hold: not found")
+	ErrHoldInvalidState   = errors.New("hold: not allowed in the current status")
+	ErrHoldExpired        = errors.New("hold: expired")
+	ErrCaptureExceedsHold = errors.New("hold: capture amount exceeds the held amount")
+	ErrHoldExpiryInvalid  = errors.New("hold: expires_at must be in the future and within 30 days")
+
+	ErrKycSubmissionNotFound = errors.New("kyc: submission not found")
+	ErrKycSubmissionPending  = errors.New("kyc: a submission is already waiting for review")
+	ErrKycAlreadyVerified    = errors.New("kyc: user is already verified")
+	ErrKycInvalidTransition  = errors.New("kyc: review step not allowed in the current status")
+	ErrKycPhotoInvalid       = errors.New("kyc: photo must be a JPEG or PNG within the size limit")
 )
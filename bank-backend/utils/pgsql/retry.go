@@ -0,0 +1,75 @@
+package pgsql
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	"time"
+)
+
+var (
+	// attempts, conflicts and exhausted retries per operation, conflict rate is conflicts / attempts
+	optimisticLockAttempts  = expvar.NewMap("optimistic_lock_attempts_total")
+	optimisticLockConflicts = expvar.NewMap("optimistic_lock_conflicts_total")
+	optimisticLockExhausted = expvar.NewMap("optimistic_lock_retries_exhausted_total")
+)
+
+// RetryPolicy bounds how often a read-check-update transaction is re-run after losing a version check.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    200 * time.Millisecond,
+	}
+}
+
+// RetryOnConflict runs fn until it succeeds, fails with an error other than ErrConcurrentModification,
+// or MaxAttempts is reached. fn must run the whole transaction so every attempt reads fresh rows.
+// Between attempts it sleeps a random duration up to an exponentially growing cap (full jitter).
+func RetryOnConflict(ctx context.Context, policy RetryPolicy, op string, fn func() error) error {
+	defaults := DefaultRetryPolicy()
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaults.BaseDelay
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+
+	var err error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := policy.BaseDelay << (attempt - 1)
+			if backoff <= 0 || backoff > policy.MaxDelay {
+				backoff = policy.MaxDelay
+			}
+
+			timer := time.NewTimer(rand.N(backoff) + 1)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+
+		optimisticLockAttempts.Add(op, 1)
+		err = fn()
+		if !errors.Is(err, ErrConcurrentModification) {
+			return err
+		}
+		optimisticLockConflicts.Add(op, 1)
+	}
+
+	optimisticLockExhausted.Add(op, 1)
+	return err
+}
@@ -34,6 +34,20 @@ func FormatValidationErrors(err error) map[string]string {
 			errorMessages[err.Field()] = "Must contain only numeric characters"
 		case "strongpassword":
 			errorMessages[err.Field()] = "Must contain at least one uppercase letter, one lowercase letter, one number, and one special character"
+		case "oneof":
+			errorMessages[err.Field()] = fmt.Sprintf("Must be one of: %s", err.Param())
+		case "datetime":
+			errorMessages[err.Field()] = fmt.Sprintf("Must match the %s format", err.Param())
+		case "required_if":
+			errorMessages[err.Field()] = fmt.Sprintf("This field is required when %s", err.Param())
+		case "gtfield":
+			errorMessages[err.Field()] = fmt.Sprintf("Must be after %s", err.Param())
+		case "required_without_all":
+			errorMessages[err.Field()] = fmt.Sprintf("This field is required when none of %s is set", err.Param())
+		case "excluded_with":
+			errorMessages[err.Field()] = fmt.Sprintf("Must not be set together with %s", err.Param())
+		case "uuid":
+			errorMessages[err.Field()] = "Must be a valid UUID"
 		case "indonesianphone":
 			errorMessages[err.Field()] = "Must be a valid Indonesian phone number : start with +628121.."
 		default:
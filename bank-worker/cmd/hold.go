@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bank-worker/feature/bank"
+	"bank-worker/feature/shared"
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runExpireHolds expires active holds past their expiry every interval until ctx is done.
+func runExpireHolds(ctx context.Context, interval time.Duration, batchSize int) {
+	if batchSize <= 0 {
+		log.Fatalln("batch size must be positive")
+	}
+	if interval <= 0 {
+		log.Fatalln("interval must be positive")
+	}
+
+	cfg := shared.LoadConfig("config/app.yml")
+
+	dbCfg, err := pgxpool.ParseConfig(cfg.DBConfig.ConnStr())
+	if err != nil {
+		log.Fatalln("unable to parse database config", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, dbCfg)
+	if err != nil {
+		log.Fatalln("unable to create database connection pool", err)
+	}
+	defer pool.Close()
+
+	bank.SetDBPool(pool)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("hold sweeper up and running, interval %s, batch size %d", interval, batchSize)
+
+	for {
+		expired, err := bank.ExpireHolds(ctx, batchSize)
+		if err != nil {
+			log.Printf("expire holds error %s", err.Error())
+		}
+		if expired > 0 {
+			log.Printf("holds expired %d", expired)
+		}
+
+		// a full batch means more may be due, so go again without waiting
+		if expired == batchSize {
+			if ctx.Err() != nil {
+				log.Println("hold sweeper stopped")
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("hold sweeper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
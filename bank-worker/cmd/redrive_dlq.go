@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bank-worker/feature/bank"
+	"bank-worker/pkg"
+	"context"
+	"log"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// runRedriveTransferDLQ publishes the messages of bank.transfer_created.dlq back to
+// bank.transfer_created. It stops after maxMessages messages (0 means no limit) or once the dead-letter
+// topic has been idle for idleTimeout.
+func runRedriveTransferDLQ(ctx context.Context, maxMessages int64, idleTimeout time.Duration) {
+	kafkaCfg := pkg.NewKafkaConsumerConfig()
+	kafkaCfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	dlqTopic := pkg.DeadLetterTopic(bank.CreateNewTransferTopic)
+
+	consumer, err := sarama.NewConsumerGroup([]string{"localhost:9092"}, bank.RedriveTransferDLQGroupConsumer, kafkaCfg)
+	if err != nil {
+		log.Fatalln("unable to create consumer group", err)
+	}
+
+	defer consumer.Close()
+
+	producer, err := sarama.NewSyncProducer([]string{"localhost:9092"}, pkg.NewKafkaProducerConfig())
+	if err != nil {
+		log.Fatalln("unable to create kafka producer", err)
+	}
+
+	defer producer.Close()
+
+	redriver := &pkg.DeadLetterRedriver{Producer: producer, Topic: bank.CreateNewTransferTopic}
+
+	newCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		for err = range consumer.Errors() {
+			log.Printf("consumer error, topic %s, error %s", dlqTopic, err.Error())
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for newCtx.Err() == nil {
+			err := consumer.Consume(newCtx, []string{dlqTopic}, pkg.NewKafkaConsumer(redriver, 1))
+			if err != nil {
+				log.Printf("consume message error, topic %s, error %s", dlqTopic, err.Error())
+				return
+			}
+		}
+	}()
+
+	log.Printf("redriving %s to %s", dlqTopic, bank.CreateNewTransferTopic)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	last, lastChange := int64(0), time.Now()
+	for {
+		select {
+		case <-done:
+			log.Printf("redrive stopped, %d messages redriven", redriver.Redriven())
+			return
+		case <-ticker.C:
+		}
+
+		redriven := redriver.Redriven()
+		if redriven != last {
+			last, lastChange = redriven, time.Now()
+		}
+		if (maxMessages > 0 && redriven >= maxMessages) || time.Since(lastChange) >= idleTimeout {
+			cancel()
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"time"
 )
 
 func Start() {
@@ -26,6 +27,10 @@ func Start() {
 				runTransferConsumer(ctx)
 			},
 		},
+		redriveDLQCommand(ctx),
+		scheduleTransfersCommand(ctx),
+		expireMoneyRequestsCommand(ctx),
+		expireHoldsCommand(ctx),
 	}
 
 	rootCmd.AddCommand(cmd...)
@@ -33,3 +38,74 @@ func Start() {
 		log.Fatalln(err)
 	}
 }
+
+func redriveDLQCommand(ctx context.Context) *cobra.Command {
+	var (
+		maxMessages int64
+		idleTimeout time.Duration
+	)
+	redrive := &cobra.Command{
+		Use:   "redrive-dlq",
+		Short: "Publish dead-lettered transfer events back to the transfer topic",
+		Run: func(cmd *cobra.Command, _ []string) {
+			runRedriveTransferDLQ(ctx, maxMessages, idleTimeout)
+		},
+	}
+	redrive.Flags().Int64Var(&maxMessages, "max", 0, "maximum number of messages to redrive, 0 for all")
+	redrive.Flags().DurationVar(&idleTimeout, "idle-timeout", 10*time.Second, "stop after the dead-letter topic is idle for this long")
+	return redrive
+}
+
+func scheduleTransfersCommand(ctx context.Context) *cobra.Command {
+	var (
+		interval  time.Duration
+		batchSize int
+	)
+	schedule := &cobra.Command{
+		Use:   "schedule-transfers",
+		Short: "Emit transfer events for due scheduled transfers and retry failed runs",
+		Long: "Emit transfer events for due scheduled transfers and retry failed runs.\n\n" +
+			"The transfers and their events are written to the outbox table. They reach Kafka only while the " +
+			"bank-backend outbox relay (serve-http) is running.",
+		Run: func(cmd *cobra.Command, _ []string) {
+			runScheduledTransfers(ctx, interval, batchSize)
+		},
+	}
+	schedule.Flags().DurationVar(&interval, "interval", 30*time.Second, "how often to scan for due schedules")
+	schedule.Flags().IntVar(&batchSize, "batch", 100, "maximum number of schedules handled per transaction")
+	return schedule
+}
+
+func expireMoneyRequestsCommand(ctx context.Context) *cobra.Command {
+	var (
+		interval  time.Duration
+		batchSize int
+	)
+	expire := &cobra.Command{
+		Use:   "expire-money-requests",
+		Short: "Expire pending money requests past their expiry",
+		Run: func(cmd *cobra.Command, _ []string) {
+			runExpireMoneyRequests(ctx, interval, batchSize)
+		},
+	}
+	expire.Flags().DurationVar(&interval, "interval", time.Minute, "how often to scan for expired requests")
+	expire.Flags().IntVar(&batchSize, "batch", 100, "maximum number of requests expired per transaction")
+	return expire
+}
+
+func expireHoldsCommand(ctx context.Context) *cobra.Command {
+	var (
+		interval  time.Duration
+		batchSize int
+	)
+	expire := &cobra.Command{
+		Use:   "expire-holds",
+		Short: "Expire active wallet holds past their expiry and release their amounts",
+		Run: func(cmd *cobra.Command, _ []string) {
+			runExpireHolds(ctx, interval, batchSize)
+		},
+	}
+	expire.Flags().DurationVar(&interval, "interval", time.Minute, "how often to scan for expired holds")
+	expire.Flags().IntVar(&batchSize, "batch", 100, "maximum number of holds expired per transaction")
+	return expire
+}
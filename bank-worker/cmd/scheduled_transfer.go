@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bank-worker/feature/bank"
+	"bank-worker/feature/shared"
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runScheduledTransfers triggers due scheduled transfers and retries failed runs every interval
+// until ctx is done. It only writes to the outbox; bank-backend's relay publishes the events.
+func runScheduledTransfers(ctx context.Context, interval time.Duration, batchSize int) {
+	if batchSize <= 0 {
+		log.Fatalln("batch size must be positive")
+	}
+	if interval <= 0 {
+		log.Fatalln("interval must be positive")
+	}
+
+	cfg := shared.LoadConfig("config/app.yml")
+
+	dbCfg, err := pgxpool.ParseConfig(cfg.DBConfig.ConnStr())
+	if err != nil {
+		log.Fatalln("unable to parse database config", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, dbCfg)
+	if err != nil {
+		log.Fatalln("unable to create database connection pool", err)
+	}
+	defer pool.Close()
+
+	bank.SetDBPool(pool)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("scheduler up and running, interval %s, batch size %d", interval, batchSize)
+
+	for {
+		triggered, err := bank.TriggerDueScheduledTransfers(ctx, batchSize)
+		if err != nil {
+			log.Printf("trigger scheduled transfers error %s", err.Error())
+		}
+		retried, err := bank.RetryFailedScheduledTransferRuns(ctx, batchSize)
+		if err != nil {
+			log.Printf("retry scheduled transfer runs error %s", err.Error())
+		}
+		if triggered > 0 || retried > 0 {
+			log.Printf("scheduled transfers triggered %d, retried %d", triggered, retried)
+		}
+
+		// a full batch means more may be due, so go again without waiting
+		if triggered == batchSize || retried == batchSize {
+			if ctx.Err() != nil {
+				log.Println("scheduler stopped")
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
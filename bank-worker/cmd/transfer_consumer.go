@@ -25,6 +25,20 @@ func runTransferConsumer(ctx context.Context) {
 
 	defer consumer.Close()
 
+	producer, err := sarama.NewSyncProducer([]string{"localhost:9092"}, pkg.NewKafkaProducerConfig())
+	if err != nil {
+		log.Fatalln("unable to create kafka producer", err)
+	}
+
+	defer producer.Close()
+
+	retryPolicy := &pkg.RetryPolicy{
+		Producer: producer,
+		Topic:    bank.CreateNewTransferTopic,
+		Attempts: bank.TransferRetryAttempts,
+		Backoff:  bank.TransferRetryBackoff,
+	}
+
 	dbCfg, err := pgxpool.ParseConfig(cfg.DBConfig.ConnStr())
 	if err != nil {
 		log.Fatalln("unable to parse database config", err)
@@ -54,8 +68,8 @@ func runTransferConsumer(ctx context.Context) {
 				log.Println("consumer stopped")
 				return
 			default:
-				err = consumer.Consume(newCtx, []string{bank.CreateNewTransferTopic},
-					pkg.NewKafkaConsumer(&bank.NewTransferEventHandler{}, 1000),
+				err = consumer.Consume(newCtx, retryPolicy.Topics(),
+					pkg.NewKafkaConsumerWithRetry(&bank.NewTransferEventHandler{}, 1000, retryPolicy),
 				)
 				if err != nil {
 					log.Printf("consume message error, topic %s, error %s", bank.CreateNewTransferTopic, err.Error())
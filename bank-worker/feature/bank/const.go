@@ -1,6 +1,69 @@
 package bank
 
+import "time"
+
 const (
 	CreateNewTransferTopic              = "bank.transfer_created"
 	CreateNewTransferTopicGroupConsumer = "bank.transfer_created_group_consumer"
+	RedriveTransferDLQGroupConsumer     = "bank.transfer_created_dlq_redrive_group_consumer"
+
+	// MoneyRequestTopic carries the MoneyRequestEvent of every money request state change
+	MoneyRequestTopic = "bank.money_request"
+
+	// SplitBillTopic carries the SplitBillEvent of split bills, settlement included
+	SplitBillTopic = "bank.split_bill"
+
+	// TransferRetryAttempts is the number of retry topics, bank.transfer_created.retry.1 and .retry.2
+	TransferRetryAttempts = 2
+	TransferRetryBackoff  = 5 * time.Second
+
+	// ScheduledTransferMaxAttempts bounds the runs per occurrence of a schedule. A failed run is
+	// retried after ScheduledTransferRetryBackoff, doubling on every further attempt.
+	ScheduledTransferMaxAttempts  = 3
+	ScheduledTransferRetryBackoff = 30 * time.Minute
+)
+
+// a scheduled transfer run carries the status of the transfer it created
+const (
+	transferStatusPending   = "PENDING"
+	transferStatusCompleted = "COMPLETED"
+	transferStatusFailed    = "FAILED"
+)
+
+const (
+	scheduleStatusActive    = "ACTIVE"
+	scheduleStatusCompleted = "COMPLETED"
+
+	scheduleFrequencyDaily   = "DAILY"
+	scheduleFrequencyWeekly  = "WEEKLY"
+	scheduleFrequencyMonthly = "MONTHLY"
+)
+
+const (
+	moneyRequestStatusPending = "PENDING"
+	moneyRequestStatusExpired = "EXPIRED"
+
+	moneyRequestEventExpired = "MONEY_REQUEST_EXPIRED"
+)
+
+const (
+	splitBillStatusOpen    = "OPEN"
+	splitBillStatusSettled = "SETTLED"
+
+	splitShareStatusPending    = "PENDING"
+	splitShareStatusProcessing = "PROCESSING"
+	splitShareStatusPaid       = "PAID"
+
+	splitBillEventSettled = "SPLIT_BILL_SETTLED"
+)
+
+const (
+	holdStatusActive  = "ACTIVE"
+	holdStatusExpired = "EXPIRED"
+)
+
+// account statuses checked before moving funds, see "user".status
+const (
+	accountStatusActive      = "ACTIVE"
+	accountStatusFrozenDebit = "FROZEN_DEBIT"
 )
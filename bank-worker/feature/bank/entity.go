@@ -30,3 +30,16 @@ type Transaction struct {
 	CreatedDate     time.Time
 	Version         int
 }
+
+type ScheduledTransfer struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	PhoneNumber  string
+	TargetUserID uuid.UUID
+	Amount       int
+	Remarks      string
+	Frequency    string
+	DayOfMonth   int
+	NextRunAt    time.Time
+	EndAt        *time.Time
+}
@@ -5,4 +5,10 @@ import "errors"
 var (
 	errUserNotFound     = errors.New("user: not found")
 	errBalanceNotEnough = errors.New("bank: balance not enough")
+	errConcurrentUpdate = errors.New("bank: concurrent modification")
+	errDuplicateEvent   = errors.New("bank: event already processed")
+	errAccountNotActive = errors.New("bank: account is frozen or closed")
+	errLimitExceeded    = errors.New("limit: exceeded")
+	errSelfTransfer     = errors.New("bank: origin and destination are the same wallet")
+	errTransferFailed   = errors.New("bank: transfer already failed")
 )
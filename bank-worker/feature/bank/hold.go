@@ -0,0 +1,71 @@
+package bank
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpireHolds moves up to limit active holds whose expiry has passed to EXPIRED and takes their
+// amounts off the held balance of their wallets, making them available again. The holds and their
+// wallets are locked with SKIP LOCKED, so several sweepers can run side by side and a sweep never
+// waits on a capture or void of the same hold in bank-backend.
+func ExpireHolds(ctx context.Context, limit int) (int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	selectDue := `
+		SELECT h.id, h.user_id, h.amount
+		FROM hold h JOIN "user" u ON u.id = h.user_id
+		WHERE h.status = $1 AND h.expires_at <= $2
+		ORDER BY h.expires_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`
+	expire := `update hold set status = $1, updated_at = $2 where id = $3`
+	release := `update "user" set held_balance = held_balance - $1, version = version+1, updated_at = $2 where id = $3`
+
+	type dueHold struct {
+		ID     uuid.UUID
+		UserID uuid.UUID
+		Amount int
+	}
+
+	now := time.Now()
+	rows, err := tx.Query(ctx, selectDue, holdStatusActive, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	holds := make([]dueHold, 0)
+	for rows.Next() {
+		h := dueHold{}
+		if err = rows.Scan(&h.ID, &h.UserID, &h.Amount); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		holds = append(holds, h)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, h := range holds {
+		if _, err = tx.Exec(ctx, expire, holdStatusExpired, now, h.ID); err != nil {
+			return 0, err
+		}
+		if _, err = tx.Exec(ctx, release, h.Amount, now, h.UserID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(holds), nil
+}
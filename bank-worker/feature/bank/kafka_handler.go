@@ -5,6 +5,7 @@ import (
 	"bank-worker/pkg"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -16,7 +17,9 @@ import (
 type NewTransferEventHandler struct {
 }
 
-func (*NewTransferEventHandler) Handle(ctx context.Context, msg *sarama.ConsumerMessage) {
+// Handle applies the transfer. Malformed events, unknown users, transfers to the origin wallet and
+// insufficient balance are permanent failures; optimistic-lock misses and database errors are retried.
+func (*NewTransferEventHandler) Handle(ctx context.Context, msg *sarama.ConsumerMessage) error {
 	var (
 		lvState1       = shared.LogEventStateDecodeRequest
 		lfState1Status = "state_1_decode_message_status"
@@ -40,7 +43,7 @@ func (*NewTransferEventHandler) Handle(ctx context.Context, msg *sarama.Consumer
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState1Status))
 		pkg.LogErrorWithContext(ctx, err, lf)
-		return
+		return pkg.Permanent(err)
 	}
 
 	lf = append(lf,
@@ -56,28 +59,89 @@ func (*NewTransferEventHandler) Handle(ctx context.Context, msg *sarama.Consumer
 		PhoneNumber: payload.PhoneNumberOriginUser,
 		Balance:     payload.Amount,
 	}
+	if _, err = uuid.Parse(payload.Transfer); err != nil {
+		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
+		pkg.LogErrorWithContext(ctx, err, lf)
+		return pkg.Permanent(err)
+	}
 	parse, err := uuid.Parse(payload.TargetUser)
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogErrorWithContext(ctx, err, lf)
-		return
+		return pkg.Permanent(err)
 	}
 	layout := "2006-01-02 15:04:05.000000"
 	t, err := time.Parse(layout, payload.CreatedAt)
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogErrorWithContext(ctx, err, lf)
-		return
+		return pkg.Permanent(err)
+	}
+
+	entryType := entryTypeTransfer
+	if payload.ReversalOf != "" {
+		entryType = entryTypeReversal
 	}
 
-	_, _, _, _, err = transferTX(ctx, user, parse, payload.Remarks, t, payload.Transfer)
+	_, _, _, _, err = transferTX(ctx, user, parse, entryType, payload.Remarks, t, payload.Transfer)
+	if errors.Is(err, errDuplicateEvent) {
+		lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+		pkg.LogInfoWithContext(ctx, "duplicate transfer event skipped", lf)
+		return nil
+	}
+	if errors.Is(err, errTransferFailed) {
+		lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
+		pkg.LogInfoWithContext(ctx, "event of a failed transfer skipped", lf)
+		return nil
+	}
 	if err != nil {
 		lf = append(lf, pkg.LogStatusFailed(lfState2Status))
 		pkg.LogErrorWithContext(ctx, err, lf)
-		return
+		return classifyTransferError(err)
 	}
 
 	lf = append(lf, pkg.LogStatusSuccess(lfState2Status))
 
 	pkg.LogInfoWithContext(ctx, "success insert user", lf)
+	return nil
+}
+
+// HandleDeadLetter marks the transfer FAILED once its event is given up on, so clients polling the
+// transfer status can see the reason. A scheduled transfer run is failed along with it and retried
+// by the scheduler, and a split bill share paid by it can be paid again. The inbox row is written in
+// the same transaction, so a redriven copy of the event is skipped instead of paying the transfer
+// that was already reported as failed.
+func (*NewTransferEventHandler) HandleDeadLetter(ctx context.Context, msg *sarama.ConsumerMessage, cause error) {
+	lf := []slog.Attr{
+		pkg.LogEventName("Transfer-Worker"),
+		pkg.LogEventState(shared.LogEventStateUpdateDB),
+	}
+
+	var payload TransferEvent
+	if err := json.Unmarshal(msg.Value, &payload); err != nil {
+		return
+	}
+	transferId, err := uuid.Parse(payload.Transfer)
+	if err != nil {
+		return
+	}
+
+	err = failTransfer(ctx, transferId, cause.Error())
+	if errors.Is(err, errDuplicateEvent) {
+		pkg.LogInfoWithContext(ctx, "dead-lettered transfer event was already processed", lf)
+		return
+	}
+	if err != nil {
+		pkg.LogErrorWithContext(ctx, err, lf)
+	}
+}
+
+func classifyTransferError(err error) error {
+	switch {
+	case errors.Is(err, errUserNotFound), errors.Is(err, errBalanceNotEnough), errors.Is(err, errAccountNotActive),
+		errors.Is(err, errLimitExceeded), errors.Is(err, errSelfTransfer):
+		return pkg.Permanent(err)
+	default:
+		return pkg.Retryable(err)
+	}
 }
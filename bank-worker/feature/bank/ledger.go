@@ -0,0 +1,150 @@
+package bank
+
+import (
+	"bank-worker/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// The ledger mirrors bank-backend/module/bank/internal/ledger: every money movement is a journal
+// entry whose postings sum to zero, and account balances are a cache of their postings.
+
+const (
+	accountTypeWallet = "WALLET"
+
+	entryTypeTransfer = "TRANSFER"
+	entryTypeReversal = "REVERSAL"
+)
+
+var errUnbalancedEntry = errors.New("ledger: journal entry is not balanced")
+
+type posting struct {
+	AccountID uuid.UUID
+	Amount    int
+}
+
+type postingResult struct {
+	AccountID     uuid.UUID
+	BalanceBefore int
+	BalanceAfter  int
+}
+
+// walletAccount returns the wallet account of userID, opening it on first use.
+func walletAccount(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (uuid.UUID, error) {
+	id, err := pkg.GenerateId()
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	insertWallet := `
+		INSERT INTO ledger_account (id, code, account_type, user_id, balance, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, 1, $5, $5) ON CONFLICT (user_id) DO NOTHING
+	`
+	selectWallet := `SELECT id FROM ledger_account WHERE user_id = $1`
+
+	_, err = tx.Exec(ctx, insertWallet, id, fmt.Sprintf("WALLET:%s", userID), accountTypeWallet, userID, time.Now())
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	var accountID uuid.UUID
+	err = tx.QueryRow(ctx, selectWallet, userID).Scan(&accountID)
+	return accountID, err
+}
+
+// moveFunds posts a two-legged entry taking amount out of from and into to.
+func moveFunds(ctx context.Context, tx pgx.Tx, entryType string, referenceID uuid.UUID, description string, from uuid.UUID, to uuid.UUID, amount int) (postingResult, postingResult, error) {
+	results, err := postJournal(ctx, tx, entryType, referenceID, description, []posting{
+		{AccountID: from, Amount: -amount},
+		{AccountID: to, Amount: amount},
+	})
+	if err != nil {
+		return postingResult{}, postingResult{}, err
+	}
+	return results[0], results[1], nil
+}
+
+// postJournal writes a journal entry with its postings and updates the cached account balances in
+// account id order. Wallet accounts may not go below zero.
+func postJournal(ctx context.Context, tx pgx.Tx, entryType string, referenceID uuid.UUID, description string, postings []posting) ([]postingResult, error) {
+	if len(postings) < 2 {
+		return nil, errUnbalancedEntry
+	}
+	sum := 0
+	for _, p := range postings {
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return nil, errUnbalancedEntry
+	}
+
+	journalQuery := `
+		INSERT INTO journal_entry (id, reference_id, entry_type, description, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	updateAccount := `
+		update ledger_account set balance = balance + $1, version = version + 1, updated_at = $2
+		where id = $3 RETURNING balance, account_type
+	`
+	postingQuery := `
+		INSERT INTO posting (id, journal_entry_id, account_id, amount, balance_after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	entryID, err := pkg.GenerateId()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	_, err = tx.Exec(ctx, journalQuery, entryID, referenceID, entryType, description, now)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(postings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return postings[order[a]].AccountID.String() < postings[order[b]].AccountID.String()
+	})
+
+	results := make([]postingResult, len(postings))
+	for _, i := range order {
+		p := postings[i]
+
+		var balance int
+		var accountType string
+		err = tx.QueryRow(ctx, updateAccount, p.Amount, now, p.AccountID).Scan(&balance, &accountType)
+		if err != nil {
+			return nil, err
+		}
+		if accountType == accountTypeWallet && balance < 0 {
+			return nil, errBalanceNotEnough
+		}
+
+		postingID, err := pkg.GenerateId()
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, postingQuery, postingID, entryID, p.AccountID, p.Amount, balance, now)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i] = postingResult{
+			AccountID:     p.AccountID,
+			BalanceBefore: balance - p.Amount,
+			BalanceAfter:  balance,
+		}
+	}
+
+	return results, nil
+}
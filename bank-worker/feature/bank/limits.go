@@ -0,0 +1,102 @@
+package bank
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// The limits mirror bank-backend/module/bank/internal/limits. A transfer is checked again here
+// because the sender's outgoing sums and the receiver's balance may have moved since it was accepted.
+
+const (
+	limitMaxBalance      = "MAX_BALANCE"
+	limitPerTransaction  = "PER_TRANSACTION"
+	limitDailyOutgoing   = "DAILY_OUTGOING"
+	limitMonthlyOutgoing = "MONTHLY_OUTGOING"
+
+	limitDailyWindow   = 24 * time.Hour
+	limitMonthlyWindow = 30 * 24 * time.Hour
+
+	entryTypePayment = "PAYMENT"
+)
+
+type tierLimits struct {
+	MaxBalance      int
+	PerTransaction  int
+	DailyOutgoing   int
+	MonthlyOutgoing int
+}
+
+func userTierLimits(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (tierLimits, error) {
+	query := `
+		SELECT t.max_balance, t.per_transaction, t.daily_outgoing, t.monthly_outgoing
+		FROM "user" u JOIN kyc_tier_limit t ON t.tier = u.kyc_tier
+		WHERE u.id = $1
+	`
+
+	limits := tierLimits{}
+	err := tx.QueryRow(ctx, query, userID).Scan(&limits.MaxBalance, &limits.PerTransaction, &limits.DailyOutgoing, &limits.MonthlyOutgoing)
+	if err == pgx.ErrNoRows {
+		err = errUserNotFound
+	}
+	return limits, err
+}
+
+// checkTransferLimits rejects amount when it exceeds a limit of the sender's tier or would take the
+// receiver above its wallet cap.
+func checkTransferLimits(ctx context.Context, tx pgx.Tx, origin uuid.UUID, destination uuid.UUID, amount int, destinationBalance int, now time.Time) error {
+	limits, err := userTierLimits(ctx, tx, origin)
+	if err != nil {
+		return err
+	}
+	if amount > limits.PerTransaction {
+		return limitError(limitPerTransaction, limits.PerTransaction, limits.PerTransaction)
+	}
+
+	query := `
+		SELECT coalesce(-sum(p.amount) FILTER (WHERE p.created_at > $3), 0), coalesce(-sum(p.amount), 0)
+		FROM posting p
+			JOIN ledger_account a ON a.id = p.account_id
+			JOIN journal_entry j ON j.id = p.journal_entry_id
+		WHERE a.user_id = $1 AND p.amount < 0 AND j.entry_type = ANY($2) AND p.created_at > $4
+	`
+
+	var daily, monthly int
+	err = tx.QueryRow(ctx, query, origin, []string{entryTypePayment, entryTypeTransfer}, now.Add(-limitDailyWindow), now.Add(-limitMonthlyWindow)).Scan(&daily, &monthly)
+	if err != nil {
+		return err
+	}
+
+	// active holds count as outgoing, capturing one is not checked again
+	var held int
+	err = tx.QueryRow(ctx, `SELECT coalesce(sum(amount), 0) FROM hold WHERE user_id = $1 AND status = $2 AND expires_at > $3`,
+		origin, holdStatusActive, now).Scan(&held)
+	if err != nil {
+		return err
+	}
+	daily += held
+	monthly += held
+	if daily+amount > limits.DailyOutgoing {
+		return limitError(limitDailyOutgoing, limits.DailyOutgoing, max(limits.DailyOutgoing-daily, 0))
+	}
+	if monthly+amount > limits.MonthlyOutgoing {
+		return limitError(limitMonthlyOutgoing, limits.MonthlyOutgoing, max(limits.MonthlyOutgoing-monthly, 0))
+	}
+
+	limits, err = userTierLimits(ctx, tx, destination)
+	if err != nil {
+		return err
+	}
+	if destinationBalance+amount > limits.MaxBalance {
+		return limitError(limitMaxBalance, limits.MaxBalance, max(limits.MaxBalance-destinationBalance, 0))
+	}
+	return nil
+}
+
+func limitError(limit string, limitAmount int, remaining int) error {
+	return fmt.Errorf("%w: %s of %d, %d remaining", errLimitExceeded, limit, limitAmount, remaining)
+}
@@ -6,5 +6,36 @@ type TransferEvent struct {
 	PhoneNumberOriginUser string `json:"phone_number_origin_user"`
 	TargetUser            string `json:"target_user"`
 	Remarks               string `json:"remarks"`
-	CreatedAt             string `json:"created_at"`
+	// ReversalOf is set when the transfer moves the funds of an earlier transfer back
+	ReversalOf string `json:"reversal_of,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// MoneyRequestEvent mirrors the event bank-backend publishes on money request state changes.
+type MoneyRequestEvent struct {
+	EventType     string `json:"event_type"`
+	MoneyRequest  string `json:"money_request_id"`
+	RequesterUser string `json:"requester_user"`
+	PayerUser     string `json:"payer_user"`
+	Amount        int    `json:"amount"`
+	Remarks       string `json:"remarks"`
+	Status        string `json:"status"`
+	TransferID    string `json:"transfer_id,omitempty"`
+	ExpiresAt     string `json:"expires_at"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// SplitBillEvent mirrors the event bank-backend publishes about split bills. Participant fields are
+// only set for the events about one share.
+type SplitBillEvent struct {
+	EventType              string `json:"event_type"`
+	SplitBill              string `json:"split_bill_id"`
+	Title                  string `json:"title"`
+	OrganizerUser          string `json:"organizer_user"`
+	OrganizerPhoneNumber   string `json:"organizer_phone_number"`
+	TotalAmount            int    `json:"total_amount"`
+	ParticipantUser        string `json:"participant_user,omitempty"`
+	ParticipantPhoneNumber string `json:"participant_phone_number,omitempty"`
+	ShareAmount            int    `json:"share_amount,omitempty"`
+	CreatedAt              string `json:"created_at"`
 }
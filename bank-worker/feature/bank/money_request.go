@@ -0,0 +1,102 @@
+package bank
+
+import (
+	"bank-worker/pkg"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpireMoneyRequests moves up to limit pending money requests whose expiry has passed to EXPIRED.
+// The MoneyRequestEvent goes through the outbox in the same transaction, so bank-backend's relay
+// publishes it to MoneyRequestTopic. Requests are locked with SKIP LOCKED, so several sweepers can run
+// side by side.
+func ExpireMoneyRequests(ctx context.Context, limit int) (int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	selectDue := `
+		SELECT id, requester_user_id, payer_user_id, amount, remarks, expires_at
+		FROM money_request
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`
+	expire := `update money_request set status = $1, updated_at = $2 where id = $3`
+	insertOutbox := `
+		INSERT INTO outbox (id, aggregate_id, topic, message_key, payload, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6)
+	`
+
+	type dueRequest struct {
+		ID              uuid.UUID
+		RequesterUserID uuid.UUID
+		PayerUserID     uuid.UUID
+		Amount          int
+		Remarks         string
+		ExpiresAt       time.Time
+	}
+
+	now := time.Now()
+	rows, err := tx.Query(ctx, selectDue, moneyRequestStatusPending, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	requests := make([]dueRequest, 0)
+	for rows.Next() {
+		r := dueRequest{}
+		err = rows.Scan(&r.ID, &r.RequesterUserID, &r.PayerUserID, &r.Amount, &r.Remarks, &r.ExpiresAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		requests = append(requests, r)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range requests {
+		_, err = tx.Exec(ctx, expire, moneyRequestStatusExpired, now, r.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		event := MoneyRequestEvent{
+			EventType:     moneyRequestEventExpired,
+			MoneyRequest:  r.ID.String(),
+			RequesterUser: r.RequesterUserID.String(),
+			PayerUser:     r.PayerUserID.String(),
+			Amount:        r.Amount,
+			Remarks:       r.Remarks,
+			Status:        moneyRequestStatusExpired,
+			ExpiresAt:     r.ExpiresAt.Format(time.RFC3339),
+			CreatedAt:     now.Format("2006-01-02 15:04:05.000000"),
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return 0, err
+		}
+		messageId, err := pkg.GenerateId()
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(ctx, insertOutbox, messageId, r.ID, MoneyRequestTopic, r.ID.String(), string(payload), now)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(requests), nil
+}
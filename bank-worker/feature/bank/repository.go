@@ -4,10 +4,13 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"time"
 )
 
-func transferTX(ctx context.Context, user User, targetUser uuid.UUID, remarks string, created time.Time, transferId string) (User, int, uuid.UUID, time.Time, error) {
+// transferTX posts the transfer as entryType. A REVERSAL claws funds back: it is not limited and still
+// debits a wallet under a debit-only freeze.
+func transferTX(ctx context.Context, user User, targetUser uuid.UUID, entryType string, remarks string, created time.Time, transferId string) (User, int, uuid.UUID, time.Time, error) {
 	returningUser := User{}
 	tx, err := db.Begin(ctx)
 	if err != nil {
@@ -15,20 +18,47 @@ func transferTX(ctx context.Context, user User, targetUser uuid.UUID, remarks st
 	}
 	defer tx.Rollback(ctx)
 
-	updateOriginBalance := `update "user" set balance = balance - $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
+	parse, err := uuid.Parse(transferId)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	// the inbox row is written in the same transaction as the balance updates, so a redelivered
+	// event either sees it and stops here or waits for the first delivery to roll back
+	err = markEventProcessed(ctx, tx, parse, CreateNewTransferTopicGroupConsumer)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	// a transfer reported as FAILED stays failed, even if an event for it is delivered again
+	var status string
+	err = tx.QueryRow(ctx, `select status from transfer where id = $1 for update`, parse).Scan(&status)
+	if err != nil && err != pgx.ErrNoRows {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	if status == transferStatusFailed {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, errTransferFailed
+	}
+
+	updateBalance := `update "user" set balance = $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
+
+	selectUserOrigin := `select id, phone_number, balance, held_balance, version, status from "user" where phone_number = $1`
 
-	selectUserOrigin := `select phone_number, balance, version from "user" where phone_number = $1`
+	selectUserDestination := `select id, phone_number, balance, version, status from "user" where id = $1`
 
 	transactionQuery := `
 		INSERT INTO transaction (id, amount, balance_before, balance_after, transaction_type, user_id, created_at, version, remarks)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at
 	`
 
+	var UserIDOrigin uuid.UUID
 	var PhoneNumberOrigin string
 	var prevBalanceOrigin int
+	var HeldBalanceOrigin int
 	var VersionOrigin int
+	var StatusOrigin string
 
-	err = tx.QueryRow(ctx, selectUserOrigin, user.PhoneNumber).Scan(&PhoneNumberOrigin, &prevBalanceOrigin, &VersionOrigin)
+	err = tx.QueryRow(ctx, selectUserOrigin, user.PhoneNumber).Scan(&UserIDOrigin, &PhoneNumberOrigin, &prevBalanceOrigin, &HeldBalanceOrigin, &VersionOrigin, &StatusOrigin)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			err = errUserNotFound
@@ -36,24 +66,30 @@ func transferTX(ctx context.Context, user User, targetUser uuid.UUID, remarks st
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	err = tx.QueryRow(ctx, updateOriginBalance, user.Balance, time.Now(), PhoneNumberOrigin, VersionOrigin).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
+	reversal := entryType == entryTypeReversal
 
-	if err != nil {
-		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	// the account may have been frozen or closed after the transfer was accepted
+	if StatusOrigin != accountStatusActive && !(reversal && StatusOrigin == accountStatusFrozenDebit) {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, errAccountNotActive
 	}
 
-	// update destination user
-	returningDestUser := User{}
-
-	updateQueryDestination := `update "user" set balance = balance + $1, version = version+1, updated_at = $2 where phone_number = $3 and version = $4 RETURNING id, balance, updated_at `
+	// what active holds reserve cannot be transferred, see ExpireHolds
+	if prevBalanceOrigin-HeldBalanceOrigin < user.Balance {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, errBalanceNotEnough
+	}
 
-	selectUserDestination := `select phone_number, balance, version from "user" where id = $1`
+	// both sides would update the same row at the same version, which can never succeed
+	if targetUser == UserIDOrigin {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, errSelfTransfer
+	}
 
+	var UserIDDestination uuid.UUID
 	var PhoneNumberDestination string
 	var prevBalanceDestination int
 	var VersionDestination int
+	var StatusDestination string
 
-	err = tx.QueryRow(ctx, selectUserDestination, targetUser).Scan(&PhoneNumberDestination, &prevBalanceDestination, &VersionDestination)
+	err = tx.QueryRow(ctx, selectUserDestination, targetUser).Scan(&UserIDDestination, &PhoneNumberDestination, &prevBalanceDestination, &VersionDestination, &StatusDestination)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -62,22 +98,59 @@ func transferTX(ctx context.Context, user User, targetUser uuid.UUID, remarks st
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	err = tx.QueryRow(ctx, updateQueryDestination, user.Balance, time.Now(), PhoneNumberDestination, VersionDestination).Scan(&returningDestUser.ID, &returningDestUser.Balance, &returningDestUser.UpdatedAt)
+	// a debit-only freeze still lets the wallet receive funds
+	if StatusDestination != accountStatusActive && StatusDestination != accountStatusFrozenDebit {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, errAccountNotActive
+	}
 
+	if !reversal {
+		err = checkTransferLimits(ctx, tx, UserIDOrigin, UserIDDestination, user.Balance, prevBalanceDestination, time.Now())
+		if err != nil {
+			return returningUser, 0, uuid.UUID{}, time.Time{}, err
+		}
+	}
+
+	// post the transfer between both wallets
+	walletOrigin, err := walletAccount(ctx, tx, UserIDOrigin)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	walletDestination, err := walletAccount(ctx, tx, UserIDDestination)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	originPosting, destinationPosting, err := moveFunds(ctx, tx, entryType, parse, remarks, walletOrigin, walletDestination, user.Balance)
 	if err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	// insert transaction origin
-	// Sample transaction data
-	parse, err := uuid.Parse(transferId)
+	err = tx.QueryRow(ctx, updateBalance, originPosting.BalanceAfter, time.Now(), PhoneNumberOrigin, VersionOrigin).Scan(&returningUser.ID, &returningUser.Balance, &returningUser.UpdatedAt)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = errConcurrentUpdate
+		}
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
+	// update destination user
+	returningDestUser := User{}
+
+	err = tx.QueryRow(ctx, updateBalance, destinationPosting.BalanceAfter, time.Now(), PhoneNumberDestination, VersionDestination).Scan(&returningDestUser.ID, &returningDestUser.Balance, &returningDestUser.UpdatedAt)
+
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			err = errConcurrentUpdate
+		}
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
+
+	// insert transaction origin
+	// Sample transaction data
 	transaction := Transaction{
 		ID:              parse,
 		Amount:          user.Balance,
-		BalanceBefore:   prevBalanceOrigin,
+		BalanceBefore:   originPosting.BalanceBefore,
 		Remarks:         remarks,
 		BalanceAfter:    returningUser.Balance,
 		TransactionType: "DEBIT",
@@ -89,7 +162,7 @@ func transferTX(ctx context.Context, user User, targetUser uuid.UUID, remarks st
 	transactionDestination := Transaction{
 		ID:              parse,
 		Amount:          user.Balance,
-		BalanceBefore:   prevBalanceDestination,
+		BalanceBefore:   destinationPosting.BalanceBefore,
 		Remarks:         remarks,
 		BalanceAfter:    returningDestUser.Balance,
 		TransactionType: "CREDIT",
@@ -133,11 +206,81 @@ func transferTX(ctx context.Context, user User, targetUser uuid.UUID, remarks st
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
+	err = updateTransferStatus(ctx, tx, parse, transferStatusCompleted, "")
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	err = completeScheduledTransferRun(ctx, tx, parse)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+	err = settleSplitBillShare(ctx, tx, parse)
+	if err != nil {
+		return returningUser, 0, uuid.UUID{}, time.Time{}, err
+	}
+
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
 		return returningUser, 0, uuid.UUID{}, time.Time{}, err
 	}
 
-	return returningUser, prevBalanceOrigin, transactionId, createdAt, nil
+	return returningUser, originPosting.BalanceBefore, transactionId, createdAt, nil
+
+}
+
+// markEventProcessed records eventId in the inbox and returns errDuplicateEvent when the consumer
+// has already processed it.
+func markEventProcessed(ctx context.Context, tx pgx.Tx, eventId uuid.UUID, consumer string) error {
+	query := `INSERT INTO processed_event (event_id, consumer, processed_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+
+	tag, err := tx.Exec(ctx, query, eventId, consumer, time.Now())
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errDuplicateEvent
+	}
+	return nil
+}
+
+// failTransfer marks the transfer FAILED together with its scheduled transfer run and split bill
+// share, and records its event in the inbox. It returns errDuplicateEvent and changes nothing when
+// the event was already processed.
+func failTransfer(ctx context.Context, transferId uuid.UUID, failureReason string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = markEventProcessed(ctx, tx, transferId, CreateNewTransferTopicGroupConsumer)
+	if err != nil {
+		return err
+	}
+	err = updateTransferStatus(ctx, tx, transferId, transferStatusFailed, failureReason)
+	if err != nil {
+		return err
+	}
+	err = failScheduledTransferRun(ctx, tx, transferId, failureReason)
+	if err != nil {
+		return err
+	}
+	err = releaseSplitBillShare(ctx, tx, transferId, failureReason)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// updateTransferStatus updates the transfer record created by bank-backend. It accepts either the
+// pool or a running transaction so COMPLETED can be written atomically with the balance updates.
+func updateTransferStatus(ctx context.Context, q execer, transferId uuid.UUID, status string, failureReason string) error {
+	query := `update transfer set status = $1, failure_reason = $2, updated_at = $3 where id = $4`
 
+	_, err := q.Exec(ctx, query, status, failureReason, time.Now(), transferId)
+	return err
 }
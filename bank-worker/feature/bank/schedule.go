@@ -0,0 +1,34 @@
+package bank
+
+import "time"
+
+// nextScheduledRun returns the occurrence after prev, or false when the schedule has none left. It
+// mirrors NextScheduledRun in bank-backend, which computes the first run.
+func nextScheduledRun(frequency string, dayOfMonth int, prev time.Time, endAt *time.Time) (time.Time, bool) {
+	var next time.Time
+	switch frequency {
+	case scheduleFrequencyDaily:
+		next = prev.AddDate(0, 0, 1)
+	case scheduleFrequencyWeekly:
+		next = prev.AddDate(0, 0, 7)
+	case scheduleFrequencyMonthly:
+		next = monthlyOccurrence(prev, 1, dayOfMonth)
+	default:
+		return time.Time{}, false
+	}
+
+	if endAt != nil && next.After(*endAt) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// monthlyOccurrence returns dayOfMonth of the month `months` after t, clamped to the month's length.
+func monthlyOccurrence(t time.Time, months int, dayOfMonth int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if dayOfMonth > lastDay {
+		dayOfMonth = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, dayOfMonth-1)
+}
@@ -0,0 +1,225 @@
+package bank
+
+import (
+	"bank-worker/pkg"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// TriggerDueScheduledTransfers creates a transfer for up to limit active schedules whose next run is
+// due and moves each schedule to its next occurrence. The TransferEvent goes through the outbox in
+// the same transaction, so bank-backend's relay publishes it to CreateNewTransferTopic. Schedules are
+// locked with SKIP LOCKED, so several schedulers can run side by side.
+func TriggerDueScheduledTransfers(ctx context.Context, limit int) (int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	selectDue := `
+		SELECT s.id, s.user_id, u.phone_number, s.target_user_id, s.amount, coalesce(s.remarks, ''), s.frequency,
+			coalesce(s.day_of_month, 0), s.next_run_at, s.end_at
+		FROM scheduled_transfer s JOIN "user" u ON u.id = s.user_id
+		WHERE s.status = $1 AND s.next_run_at <= $2
+		ORDER BY s.next_run_at
+		LIMIT $3
+		FOR UPDATE OF s SKIP LOCKED
+	`
+	updateSchedule := `update scheduled_transfer set next_run_at = $1, last_run_at = $2, status = $3, updated_at = $2 where id = $4`
+
+	now := time.Now()
+	rows, err := tx.Query(ctx, selectDue, scheduleStatusActive, now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	schedules := make([]ScheduledTransfer, 0)
+	for rows.Next() {
+		s := ScheduledTransfer{}
+		err = rows.Scan(&s.ID, &s.UserID, &s.PhoneNumber, &s.TargetUserID, &s.Amount, &s.Remarks, &s.Frequency, &s.DayOfMonth, &s.NextRunAt, &s.EndAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		schedules = append(schedules, s)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, s := range schedules {
+		err = createScheduledTransferRun(ctx, tx, s, s.NextRunAt, 1, now)
+		if err != nil {
+			return 0, err
+		}
+
+		// occurrences missed while the scheduler was down are skipped, not fired in a burst
+		next, ok := s.NextRunAt, true
+		for ok && !next.After(now) {
+			next, ok = nextScheduledRun(s.Frequency, s.DayOfMonth, next, s.EndAt)
+		}
+		status, nextRunAt := scheduleStatusActive, &next
+		if !ok {
+			status, nextRunAt = scheduleStatusCompleted, nil
+		}
+
+		_, err = tx.Exec(ctx, updateSchedule, nextRunAt, now, status, s.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(schedules), nil
+}
+
+// RetryFailedScheduledTransferRuns creates a new attempt for up to limit failed runs whose retry is
+// due. Runs of paused or cancelled schedules are not retried.
+func RetryFailedScheduledTransferRuns(ctx context.Context, limit int) (int, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	selectDue := `
+		SELECT r.id, r.scheduled_for, r.attempt, s.id, s.user_id, u.phone_number, s.target_user_id, s.amount, coalesce(s.remarks, '')
+		FROM scheduled_transfer_run r
+			JOIN scheduled_transfer s ON s.id = r.scheduled_transfer_id
+			JOIN "user" u ON u.id = s.user_id
+		WHERE r.status = $1 AND r.next_retry_at <= $2 AND s.status IN ($3, $4)
+		ORDER BY r.next_retry_at
+		LIMIT $5
+		FOR UPDATE OF r SKIP LOCKED
+	`
+	clearRetry := `update scheduled_transfer_run set next_retry_at = null, updated_at = $1 where id = $2`
+
+	type failedRun struct {
+		ID           uuid.UUID
+		ScheduledFor time.Time
+		Attempt      int
+		Schedule     ScheduledTransfer
+	}
+
+	now := time.Now()
+	rows, err := tx.Query(ctx, selectDue, transferStatusFailed, now, scheduleStatusActive, scheduleStatusCompleted, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	runs := make([]failedRun, 0)
+	for rows.Next() {
+		r := failedRun{}
+		err = rows.Scan(&r.ID, &r.ScheduledFor, &r.Attempt, &r.Schedule.ID, &r.Schedule.UserID, &r.Schedule.PhoneNumber,
+			&r.Schedule.TargetUserID, &r.Schedule.Amount, &r.Schedule.Remarks)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		runs = append(runs, r)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range runs {
+		_, err = tx.Exec(ctx, clearRetry, now, r.ID)
+		if err != nil {
+			return 0, err
+		}
+		err = createScheduledTransferRun(ctx, tx, r.Schedule, r.ScheduledFor, r.Attempt+1, now)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(runs), nil
+}
+
+// createScheduledTransferRun records a PENDING transfer, its TransferEvent in the outbox and the run
+// linking it to the schedule, the same rows bank-backend writes for a transfer request.
+func createScheduledTransferRun(ctx context.Context, tx pgx.Tx, s ScheduledTransfer, scheduledFor time.Time, attempt int, now time.Time) error {
+	insertTransfer := `
+		INSERT INTO transfer (id, user_id, target_user_id, amount, remarks, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	`
+	insertOutbox := `
+		INSERT INTO outbox (id, aggregate_id, topic, message_key, payload, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6)
+	`
+	insertRun := `
+		INSERT INTO scheduled_transfer_run (id, scheduled_transfer_id, transfer_id, scheduled_for, attempt, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	`
+
+	transferId, err := pkg.GenerateId()
+	if err != nil {
+		return err
+	}
+	messageId, err := pkg.GenerateId()
+	if err != nil {
+		return err
+	}
+	runId, err := pkg.GenerateId()
+	if err != nil {
+		return err
+	}
+
+	event := TransferEvent{
+		Transfer:              transferId.String(),
+		Amount:                s.Amount,
+		PhoneNumberOriginUser: s.PhoneNumber,
+		TargetUser:            s.TargetUserID.String(),
+		Remarks:               s.Remarks,
+		CreatedAt:             now.Format("2006-01-02 15:04:05.000000"),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, insertTransfer, transferId, s.UserID, s.TargetUserID, s.Amount, s.Remarks, transferStatusPending, now)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, insertOutbox, messageId, transferId, CreateNewTransferTopic, transferId.String(), string(payload), now)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, insertRun, runId, s.ID, transferId, scheduledFor, attempt, transferStatusPending, now)
+	return err
+}
+
+// completeScheduledTransferRun marks the run that created the transfer, if any, as COMPLETED.
+func completeScheduledTransferRun(ctx context.Context, q execer, transferId uuid.UUID) error {
+	query := `update scheduled_transfer_run set status = $1, updated_at = $2 where transfer_id = $3`
+
+	_, err := q.Exec(ctx, query, transferStatusCompleted, time.Now(), transferId)
+	return err
+}
+
+// failScheduledTransferRun marks the run that created the transfer, if any, as FAILED and sets its
+// next retry while attempts remain.
+func failScheduledTransferRun(ctx context.Context, q execer, transferId uuid.UUID, failureReason string) error {
+	query := `
+		update scheduled_transfer_run set status = $1, failure_reason = $2, updated_at = $3,
+			next_retry_at = case when attempt < $4 then $3 + make_interval(secs => $5 * power(2, attempt - 1)) end
+		where transfer_id = $6 and status = $7
+	`
+
+	_, err := q.Exec(ctx, query, transferStatusFailed, failureReason, time.Now(), ScheduledTransferMaxAttempts,
+		ScheduledTransferRetryBackoff.Seconds(), transferId, transferStatusPending)
+	return err
+}
@@ -0,0 +1,103 @@
+package bank
+
+import (
+	"bank-worker/pkg"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// settleSplitBillShare marks the split bill share paid by the transfer, if any, as PAID within the
+// transfer's transaction. Once every share of the bill is paid the bill is SETTLED and a
+// SPLIT_BILL_SETTLED event goes through the outbox. The bill row is locked before counting the unpaid
+// shares, so when the last two shares complete side by side the second one sees the first and settles.
+func settleSplitBillShare(ctx context.Context, tx pgx.Tx, transferId uuid.UUID) error {
+	now := time.Now()
+
+	markPaid := `
+		update split_bill_participant set status = $1, paid_at = $2, updated_at = $2
+		where transfer_id = $3 and status = $4
+		returning split_bill_id
+	`
+	var splitBillId uuid.UUID
+	err := tx.QueryRow(ctx, markPaid, splitShareStatusPaid, now, transferId, splitShareStatusProcessing).Scan(&splitBillId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	lockBill := `
+		SELECT b.organizer_user_id, o.phone_number, b.title, b.total_amount
+		FROM split_bill b JOIN "user" o ON o.id = b.organizer_user_id
+		WHERE b.id = $1 AND b.status = $2
+		FOR UPDATE OF b
+	`
+	var (
+		organizerUserId      uuid.UUID
+		organizerPhoneNumber string
+		title                string
+		totalAmount          int
+	)
+	err = tx.QueryRow(ctx, lockBill, splitBillId, splitBillStatusOpen).Scan(&organizerUserId, &organizerPhoneNumber, &title, &totalAmount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	var unpaid bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM split_bill_participant WHERE split_bill_id = $1 AND status <> $2)`,
+		splitBillId, splitShareStatusPaid).Scan(&unpaid)
+	if err != nil || unpaid {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `update split_bill set status = $1, settled_at = $2, updated_at = $2 where id = $3`,
+		splitBillStatusSettled, now, splitBillId)
+	if err != nil {
+		return err
+	}
+
+	event := SplitBillEvent{
+		EventType:            splitBillEventSettled,
+		SplitBill:            splitBillId.String(),
+		Title:                title,
+		OrganizerUser:        organizerUserId.String(),
+		OrganizerPhoneNumber: organizerPhoneNumber,
+		TotalAmount:          totalAmount,
+		CreatedAt:            now.Format("2006-01-02 15:04:05.000000"),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	messageId, err := pkg.GenerateId()
+	if err != nil {
+		return err
+	}
+	insertOutbox := `
+		INSERT INTO outbox (id, aggregate_id, topic, message_key, payload, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6)
+	`
+	_, err = tx.Exec(ctx, insertOutbox, messageId, splitBillId, SplitBillTopic, splitBillId.String(), string(payload), now)
+	return err
+}
+
+// releaseSplitBillShare puts the split bill share paid by a failed transfer, if any, back to PENDING
+// with the failure reason, so the participant can pay it again.
+func releaseSplitBillShare(ctx context.Context, q execer, transferId uuid.UUID, failureReason string) error {
+	query := `
+		update split_bill_participant set status = $1, transfer_id = null, last_failure_reason = $2, updated_at = $3
+		where transfer_id = $4 and status = $5
+	`
+
+	_, err := q.Exec(ctx, query, splitShareStatusPending, failureReason, time.Now(), transferId, splitShareStatusProcessing)
+	return err
+}
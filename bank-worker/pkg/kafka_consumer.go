@@ -3,7 +3,9 @@ package pkg
 import (
 	"context"
 	"github.com/IBM/sarama"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,14 +19,22 @@ func NewKafkaConsumerConfig() *sarama.Config {
 	return cfg
 }
 
+// KafkaConsumerHandler processes one message. Returned errors are classified with Retryable and
+// Permanent and routed by the consumer's RetryPolicy.
 type KafkaConsumerHandler interface {
-	Handle(ctx context.Context, msg *sarama.ConsumerMessage)
+	Handle(ctx context.Context, msg *sarama.ConsumerMessage) error
+}
+
+// KafkaDeadLetterHandler is implemented by handlers that need to react when one of their messages is
+// moved to the dead-letter topic.
+type KafkaDeadLetterHandler interface {
+	HandleDeadLetter(ctx context.Context, msg *sarama.ConsumerMessage, err error)
 }
 
 type KafkaConsumer struct {
 	Handler KafkaConsumerHandler
+	Retry   *RetryPolicy
 	sem     chan struct{}
-	wg      sync.WaitGroup
 }
 
 func NewKafkaConsumer(handler KafkaConsumerHandler, limit int32) *KafkaConsumer {
@@ -34,24 +44,123 @@ func NewKafkaConsumer(handler KafkaConsumerHandler, limit int32) *KafkaConsumer
 	}
 }
 
+// NewKafkaConsumerWithRetry returns a consumer that sends failed messages through the retry and
+// dead-letter topics of policy.
+func NewKafkaConsumerWithRetry(handler KafkaConsumerHandler, limit int32, policy *RetryPolicy) *KafkaConsumer {
+	consumer := NewKafkaConsumer(handler, limit)
+	consumer.Retry = policy
+	return consumer
+}
+
+// ConsumeClaim hands the messages of a partition to the handler, up to limit at once. Offsets are
+// only marked up to the last message whose predecessors all finished, so a message that failed
+// without being routed is never committed past. The claim then stops taking messages and returns
+// the error, which ends the session: the failed message and everything after it are consumed again
+// from the last committed offset. Handlers must therefore be idempotent.
 func (c *KafkaConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	var (
+		wg      sync.WaitGroup
+		offsets offsetTracker
+		failed  atomic.Pointer[error]
+	)
+
 	for msg := range claim.Messages() {
 		c.sem <- struct{}{} // Acquire a token
-		c.wg.Add(1)
+		if failed.Load() != nil {
+			<-c.sem
+			break
+		}
+		offsets.add(msg.Offset)
+		wg.Add(1)
 		go func(msg *sarama.ConsumerMessage) {
-			defer c.wg.Done()
+			defer wg.Done()
 			defer func() { <-c.sem }() // Release the token
 
-			c.Handler.Handle(session.Context(), msg)
-			session.MarkMessage(msg, "")
+			// retried messages carry the time they become due
+			if !waitRetryAt(session.Context(), msg) {
+				return
+			}
+
+			err := c.Handler.Handle(session.Context(), msg)
+			if err != nil && (c.Retry == nil || !c.handleFailure(session.Context(), msg, err)) {
+				failed.CompareAndSwap(nil, &err)
+				return
+			}
+			if last, ok := offsets.finish(msg.Offset); ok {
+				session.MarkOffset(msg.Topic, msg.Partition, last+1, "")
+			}
 		}(msg)
 	}
 
-	c.wg.Wait()
+	wg.Wait()
 
+	if err := failed.Load(); err != nil {
+		return *err
+	}
 	return nil
 }
 
+// offsetTracker follows the in-flight offsets of one partition in the order they were consumed.
+type offsetTracker struct {
+	mu       sync.Mutex
+	pending  []int64
+	finished map[int64]bool
+}
+
+func (t *offsetTracker) add(offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = append(t.pending, offset)
+}
+
+// finish records offset as handled and returns the highest offset whose predecessors are all
+// handled too, or false when an earlier offset is still in flight or failed.
+func (t *offsetTracker) finish(offset int64) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.finished == nil {
+		t.finished = make(map[int64]bool)
+	}
+	t.finished[offset] = true
+
+	last, ok := int64(0), false
+	for len(t.pending) > 0 && t.finished[t.pending[0]] {
+		last, ok = t.pending[0], true
+		delete(t.finished, last)
+		t.pending = t.pending[1:]
+	}
+	return last, ok
+}
+
+// handleFailure routes the failed message and reports whether it may be marked as consumed.
+func (c *KafkaConsumer) handleFailure(ctx context.Context, msg *sarama.ConsumerMessage, cause error) bool {
+	lf := []slog.Attr{
+		LogEventName("kafka-consumer"),
+		slog.String("topic", msg.Topic),
+		slog.Int("partition", int(msg.Partition)),
+		slog.Int64("offset", msg.Offset),
+	}
+
+	deadLetter, err := c.Retry.route(msg, cause)
+	if err != nil {
+		LogErrorWithContext(ctx, err, lf)
+		return false
+	}
+
+	if deadLetter {
+		LogWarnWithContext(ctx, "message moved to dead-letter topic", cause, lf)
+		if h, ok := c.Handler.(KafkaDeadLetterHandler); ok {
+			h.HandleDeadLetter(ctx, msg, cause)
+		}
+		return true
+	}
+
+	LogWarnWithContext(ctx, "message scheduled for retry", cause, lf)
+	return true
+}
+
 func (c *KafkaConsumer) Setup(_ sarama.ConsumerGroupSession) error {
 	return nil
 }
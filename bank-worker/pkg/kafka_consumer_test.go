@@ -0,0 +1,148 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+type fakeSession struct {
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return nil }
+func (s *fakeSession) MemberID() string           { return "" }
+func (s *fakeSession) GenerationID() int32        { return 0 }
+func (s *fakeSession) Commit()                    {}
+func (s *fakeSession) Context() context.Context   { return context.Background() }
+
+func (s *fakeSession) ResetOffset(string, int32, int64, string) {}
+
+func (s *fakeSession) MarkOffset(_ string, _ int32, offset int64, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, offset)
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+// committed returns the offset the group would resume from, or 0 when nothing was marked.
+func (s *fakeSession) committed() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var highest int64
+	for _, offset := range s.marked {
+		highest = max(highest, offset)
+	}
+	return highest
+}
+
+type fakeClaim struct {
+	messages chan *sarama.ConsumerMessage
+}
+
+func newFakeClaim(count int) *fakeClaim {
+	c := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, count)}
+	for i := 0; i < count; i++ {
+		c.messages <- &sarama.ConsumerMessage{Topic: "bank.transfer_created", Offset: int64(i)}
+	}
+	close(c.messages)
+	return c
+}
+
+func (c *fakeClaim) Topic() string                            { return "bank.transfer_created" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return int64(cap(c.messages)) }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// failingHandler fails the message at offset fail after a short delay, so the messages behind it
+// finish first.
+type failingHandler struct {
+	fail int64
+}
+
+func (h failingHandler) Handle(_ context.Context, msg *sarama.ConsumerMessage) error {
+	if msg.Offset == h.fail {
+		time.Sleep(20 * time.Millisecond)
+		return errors.New("handler failed")
+	}
+	return nil
+}
+
+func TestConsumeClaimDoesNotCommitPastFailedMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		retry func(t *testing.T) *RetryPolicy
+		count int
+		fail  int64
+		want  int64
+	}{
+		{
+			name:  "no retry policy, first message fails",
+			retry: func(*testing.T) *RetryPolicy { return nil },
+			count: 2,
+			fail:  0,
+			want:  0,
+		},
+		{
+			name:  "no retry policy, middle message fails",
+			retry: func(*testing.T) *RetryPolicy { return nil },
+			count: 3,
+			fail:  1,
+			want:  1,
+		},
+		{
+			name: "routing the failed message fails",
+			retry: func(t *testing.T) *RetryPolicy {
+				producer := mocks.NewSyncProducer(t, nil)
+				producer.ExpectSendMessageAndFail(errors.New("broker unavailable"))
+				t.Cleanup(func() { producer.Close() })
+				return &RetryPolicy{Producer: producer, Topic: "bank.transfer_created", Attempts: 2, Backoff: time.Second}
+			},
+			count: 2,
+			fail:  0,
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := NewKafkaConsumerWithRetry(failingHandler{fail: tt.fail}, 4, tt.retry(t))
+			session := &fakeSession{}
+
+			err := consumer.ConsumeClaim(session, newFakeClaim(tt.count))
+			if err == nil {
+				t.Fatal("expected the claim to stop with the handler error")
+			}
+			if got := session.committed(); got != tt.want {
+				t.Fatalf("committed offset = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsumeClaimCommitsRoutedFailure(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	defer producer.Close()
+
+	policy := &RetryPolicy{Producer: producer, Topic: "bank.transfer_created", Attempts: 2, Backoff: time.Second}
+	consumer := NewKafkaConsumerWithRetry(failingHandler{fail: 0}, 4, policy)
+	session := &fakeSession{}
+
+	if err := consumer.ConsumeClaim(session, newFakeClaim(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := session.committed(); got != 2 {
+		t.Fatalf("committed offset = %d, want 2", got)
+	}
+}
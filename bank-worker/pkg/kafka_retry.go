@@ -0,0 +1,203 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers written on retried and dead-lettered messages.
+const (
+	HeaderAttempt           = "x-attempt"
+	HeaderRetryAt           = "x-retry-at"
+	HeaderError             = "x-error"
+	HeaderErrorClass        = "x-error-class"
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderRedrivenFrom      = "x-redriven-from"
+)
+
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Retryable marks err as transient, the message is re-attempted through the retry topics.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+// Permanent marks err as non-recoverable, the message goes straight to the dead-letter topic.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsRetryable reports whether err should be retried. Errors that are not classified are treated as
+// retryable so a transient infrastructure failure never drops a message.
+func IsRetryable(err error) bool {
+	var p *permanentError
+	return !errors.As(err, &p)
+}
+
+func RetryTopic(topic string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, attempt)
+}
+
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// RetryPolicy routes failed messages of Topic to RetryTopic(Topic, n) for n in 1..Attempts with an
+// exponential backoff starting at Backoff, then to DeadLetterTopic(Topic).
+type RetryPolicy struct {
+	Producer sarama.SyncProducer
+	Topic    string
+	Attempts int
+	Backoff  time.Duration
+}
+
+// Topics returns the source topic followed by its retry topics, all of which the consumer group
+// has to subscribe to.
+func (p *RetryPolicy) Topics() []string {
+	topics := []string{p.Topic}
+	for i := 1; i <= p.Attempts; i++ {
+		topics = append(topics, RetryTopic(p.Topic, i))
+	}
+	return topics
+}
+
+// route publishes the failed message to the next retry topic or to the dead-letter topic. It
+// reports whether the message was dead-lettered.
+func (p *RetryPolicy) route(msg *sarama.ConsumerMessage, cause error) (bool, error) {
+	attempt, _ := strconv.Atoi(HeaderValue(msg, HeaderAttempt))
+
+	headers := []sarama.RecordHeader{
+		header(HeaderOriginalTopic, p.Topic),
+		header(HeaderOriginalPartition, firstNonEmpty(HeaderValue(msg, HeaderOriginalPartition), strconv.Itoa(int(msg.Partition)))),
+		header(HeaderOriginalOffset, firstNonEmpty(HeaderValue(msg, HeaderOriginalOffset), strconv.FormatInt(msg.Offset, 10))),
+		header(HeaderError, cause.Error()),
+	}
+
+	out := &sarama.ProducerMessage{
+		Key:   sarama.ByteEncoder(msg.Key),
+		Value: sarama.ByteEncoder(msg.Value),
+	}
+
+	deadLetter := !IsRetryable(cause) || attempt >= p.Attempts
+	if deadLetter {
+		class := "retryable"
+		if !IsRetryable(cause) {
+			class = "permanent"
+		}
+		out.Topic = DeadLetterTopic(p.Topic)
+		headers = append(headers,
+			header(HeaderAttempt, strconv.Itoa(attempt)),
+			header(HeaderErrorClass, class),
+		)
+	} else {
+		delay := p.Backoff * time.Duration(1<<attempt)
+		out.Topic = RetryTopic(p.Topic, attempt+1)
+		headers = append(headers,
+			header(HeaderAttempt, strconv.Itoa(attempt+1)),
+			header(HeaderRetryAt, strconv.FormatInt(time.Now().Add(delay).UnixMilli(), 10)),
+		)
+	}
+	out.Headers = headers
+
+	_, _, err := p.Producer.SendMessage(out)
+	return deadLetter, err
+}
+
+// waitRetryAt blocks until the x-retry-at header of a retried message is due. It returns false when
+// ctx is cancelled first.
+func waitRetryAt(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	retryAt, err := strconv.ParseInt(HeaderValue(msg, HeaderRetryAt), 10, 64)
+	if err != nil {
+		return true
+	}
+	delay := time.Until(time.UnixMilli(retryAt))
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// DeadLetterRedriver publishes dead-lettered messages back to their original topic with a fresh
+// attempt count.
+type DeadLetterRedriver struct {
+	Producer sarama.SyncProducer
+	Topic    string
+	redriven atomic.Int64
+}
+
+func (r *DeadLetterRedriver) Handle(_ context.Context, msg *sarama.ConsumerMessage) error {
+	out := &sarama.ProducerMessage{
+		Topic: firstNonEmpty(HeaderValue(msg, HeaderOriginalTopic), r.Topic),
+		Key:   sarama.ByteEncoder(msg.Key),
+		Value: sarama.ByteEncoder(msg.Value),
+		Headers: []sarama.RecordHeader{
+			header(HeaderRedrivenFrom, fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)),
+		},
+	}
+	if _, _, err := r.Producer.SendMessage(out); err != nil {
+		return err
+	}
+	r.redriven.Add(1)
+	return nil
+}
+
+// Redriven returns the number of messages published back so far.
+func (r *DeadLetterRedriver) Redriven() int64 {
+	return r.redriven.Load()
+}
+
+func HeaderValue(msg *sarama.ConsumerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -37,3 +37,793 @@ create table transaction
 alter table transaction
     owner to postgres;
 
+create index transaction_user_id_id_index
+    on transaction (user_id, id desc);
+
+create table transfer
+(
+    id             uuid not null
+        constraint transfer_pk
+            primary key,
+    user_id        uuid
+        constraint transfer_user_id_fk
+            references "user",
+    target_user_id uuid
+        constraint transfer_target_user_id_fk
+            references "user",
+    amount         integer,
+    remarks        varchar(59),
+    status         varchar(10),
+    failure_reason text,
+    created_at     timestamp,
+    updated_at     timestamp
+);
+
+alter table transfer
+    owner to postgres;
+
+create table outbox
+(
+    id           uuid not null
+        constraint outbox_pk
+            primary key,
+    aggregate_id uuid,
+    topic        varchar(100),
+    message_key  varchar(100),
+    payload      text,
+    attempts     integer default 0,
+    last_error   text,
+    created_at   timestamp,
+    sent_at      timestamp
+);
+
+alter table outbox
+    owner to postgres;
+
+create index outbox_unsent_index
+    on outbox (created_at)
+    where sent_at is null;
+
+create table processed_event
+(
+    event_id     uuid         not null,
+    consumer     varchar(100) not null,
+    processed_at timestamp,
+    constraint processed_event_pk
+        primary key (event_id, consumer)
+);
+
+alter table processed_event
+    owner to postgres;
+
+-- double-entry ledger: every money movement is a journal entry whose postings sum to zero.
+-- ledger_account.balance (and "user".balance for wallets) is a cache of the account's postings.
+create table ledger_account
+(
+    id           uuid        not null
+        constraint ledger_account_pk
+            primary key,
+    code         varchar(60) not null
+        constraint ledger_account_code_uk
+            unique,
+    account_type varchar(10) not null,
+    user_id      uuid
+        constraint ledger_account_user_id_uk
+            unique
+        constraint ledger_account_user_id_fk
+            references "user",
+    balance      bigint      not null default 0,
+    version      integer     not null default 1,
+    created_at   timestamp,
+    updated_at   timestamp,
+    constraint ledger_account_wallet_balance_check
+        check (account_type <> 'WALLET' or balance >= 0)
+);
+
+alter table ledger_account
+    owner to postgres;
+
+create table journal_entry
+(
+    id           uuid not null
+        constraint journal_entry_pk
+            primary key,
+    reference_id uuid,
+    entry_type   varchar(20),
+    description  varchar(100),
+    created_at   timestamp
+);
+
+alter table journal_entry
+    owner to postgres;
+
+create index journal_entry_reference_id_index
+    on journal_entry (reference_id);
+
+create table posting
+(
+    id               uuid   not null
+        constraint posting_pk
+            primary key,
+    journal_entry_id uuid   not null
+        constraint posting_journal_entry_id_fk
+            references journal_entry,
+    account_id       uuid   not null
+        constraint posting_account_id_fk
+            references ledger_account,
+    amount           bigint not null,
+    balance_after    bigint not null,
+    created_at       timestamp
+);
+
+alter table posting
+    owner to postgres;
+
+create index posting_account_id_index
+    on posting (account_id, created_at);
+
+-- a journal entry has to be balanced when its transaction commits
+create function check_journal_entry_balanced() returns trigger as
+$$
+begin
+    if (select coalesce(sum(amount), 0) from posting where journal_entry_id = new.journal_entry_id) <> 0 then
+        raise exception 'journal entry % is not balanced', new.journal_entry_id;
+    end if;
+    return null;
+end;
+$$ language plpgsql;
+
+create constraint trigger posting_journal_entry_balanced
+    after insert
+    on posting
+    deferrable initially deferred
+    for each row
+execute function check_journal_entry_balanced();
+
+-- accounts whose cached balance drifted from their postings, expected to be empty
+create view ledger_account_drift as
+select a.id, a.code, a.balance, coalesce(sum(p.amount), 0) as posted_balance
+from ledger_account a
+         left join posting p on p.account_id = a.id
+group by a.id
+having a.balance <> coalesce(sum(p.amount), 0);
+
+insert into ledger_account (id, code, account_type, balance, version, created_at, updated_at)
+values (gen_random_uuid(), 'SYSTEM:TOPUP_CLEARING', 'SYSTEM', 0, 1, now(), now()),
+       (gen_random_uuid(), 'SYSTEM:PAYMENT_SETTLEMENT', 'SYSTEM', 0, 1, now(), now()),
+       (gen_random_uuid(), 'SYSTEM:OPENING_BALANCE', 'SYSTEM', 0, 1, now(), now());
+
+-- open a wallet for every existing user, carrying the current balance over as an opening balance
+do
+$$
+    declare
+        u          record;
+        wallet_id  uuid;
+        entry_id   uuid;
+        opening_id uuid := (select id from ledger_account where code = 'SYSTEM:OPENING_BALANCE');
+        opening    bigint;
+    begin
+        for u in select id, coalesce(balance, 0) as balance from "user"
+            loop
+                wallet_id := gen_random_uuid();
+                insert into ledger_account (id, code, account_type, user_id, balance, version, created_at, updated_at)
+                values (wallet_id, 'WALLET:' || u.id, 'WALLET', u.id, u.balance, 1, now(), now());
+
+                if u.balance <> 0 then
+                    entry_id := gen_random_uuid();
+                    update ledger_account set balance = balance - u.balance where id = opening_id
+                    returning balance into opening;
+
+                    insert into journal_entry (id, reference_id, entry_type, description, created_at)
+                    values (entry_id, u.id, 'OPENING_BALANCE', 'opening balance', now());
+                    insert into posting (id, journal_entry_id, account_id, amount, balance_after, created_at)
+                    values (gen_random_uuid(), entry_id, wallet_id, u.balance, u.balance, now()),
+                           (gen_random_uuid(), entry_id, opening_id, -u.balance, opening, now());
+                end if;
+            end loop;
+    end
+$$;
+
+
+create table idempotency_key
+(
+    phone_number    varchar(25)  not null,
+    idempotency_key varchar(255) not null,
+    fingerprint     varchar(64)  not null,
+    status          varchar(12)  not null,
+    response_status integer,
+    response_body   bytea,
+    content_type    varchar(100),
+    created_at      timestamp,
+    completed_at    timestamp,
+    expires_at      timestamp,
+    constraint idempotency_key_pk
+        primary key (phone_number, idempotency_key)
+);
+
+alter table idempotency_key
+    owner to postgres;
+
+create table scheduled_transfer
+(
+    id             uuid        not null
+        constraint scheduled_transfer_pk
+            primary key,
+    user_id        uuid        not null
+        constraint scheduled_transfer_user_id_fk
+            references "user",
+    target_user_id uuid        not null
+        constraint scheduled_transfer_target_user_id_fk
+            references "user",
+    amount         integer     not null,
+    remarks        varchar(59),
+    frequency      varchar(10) not null,
+    day_of_month   integer,
+    start_at       timestamp   not null,
+    end_at         timestamp,
+    next_run_at    timestamp,
+    last_run_at    timestamp,
+    status         varchar(10) not null,
+    created_at     timestamp,
+    updated_at     timestamp
+);
+
+alter table scheduled_transfer
+    owner to postgres;
+
+create index scheduled_transfer_due_index
+    on scheduled_transfer (next_run_at)
+    where status = 'ACTIVE';
+
+create index scheduled_transfer_user_id_index
+    on scheduled_transfer (user_id, created_at);
+
+create table scheduled_transfer_run
+(
+    id                    uuid        not null
+        constraint scheduled_transfer_run_pk
+            primary key,
+    scheduled_transfer_id uuid        not null
+        constraint scheduled_transfer_run_scheduled_transfer_id_fk
+            references scheduled_transfer,
+    transfer_id           uuid        not null
+        constraint scheduled_transfer_run_transfer_id_fk
+            references transfer,
+    scheduled_for         timestamp   not null,
+    attempt               integer     not null,
+    status                varchar(10) not null,
+    failure_reason        text,
+    next_retry_at         timestamp,
+    created_at            timestamp,
+    updated_at            timestamp
+);
+
+alter table scheduled_transfer_run
+    owner to postgres;
+
+create unique index scheduled_transfer_run_transfer_id_index
+    on scheduled_transfer_run (transfer_id);
+
+create index scheduled_transfer_run_schedule_index
+    on scheduled_transfer_run (scheduled_transfer_id, created_at);
+
+create index scheduled_transfer_run_retry_index
+    on scheduled_transfer_run (next_retry_at)
+    where status = 'FAILED';
+
+create table refresh_token
+(
+    jti               uuid        not null
+        constraint refresh_token_pk
+            primary key,
+    family_id         uuid        not null,
+    phone_number      varchar(25) not null,
+    access_token_jti  uuid,
+    access_expires_at timestamp,
+    expires_at        timestamp   not null,
+    created_at        timestamp,
+    rotated_at        timestamp,
+    replaced_by       uuid,
+    revoked_at        timestamp
+);
+
+alter table refresh_token
+    owner to postgres;
+
+create index refresh_token_family_id_index
+    on refresh_token (family_id);
+
+create index refresh_token_access_token_jti_index
+    on refresh_token (access_token_jti);
+
+create table revoked_access_token
+(
+    jti          uuid not null
+        constraint revoked_access_token_pk
+            primary key,
+    phone_number varchar(25),
+    expires_at   timestamp,
+    revoked_at   timestamp
+);
+
+alter table revoked_access_token
+    owner to postgres;
+
+create table role
+(
+    code        varchar(20) not null
+        constraint role_pk
+            primary key,
+    description varchar(100),
+    created_at  timestamp
+);
+
+alter table role
+    owner to postgres;
+
+create table role_permission
+(
+    role_code  varchar(20) not null
+        constraint role_permission_role_code_fk
+            references role,
+    permission varchar(50) not null,
+    constraint role_permission_pk
+        primary key (role_code, permission)
+);
+
+alter table role_permission
+    owner to postgres;
+
+create table user_role
+(
+    user_id    uuid        not null
+        constraint user_role_user_id_fk
+            references "user",
+    role_code  varchar(20) not null
+        constraint user_role_role_code_fk
+            references role,
+    created_at timestamp,
+    constraint user_role_pk
+        primary key (user_id, role_code)
+);
+
+alter table user_role
+    owner to postgres;
+
+insert into role (code, description, created_at)
+values ('customer', 'wallet owner', now()),
+       ('support', 'customer support agent', now()),
+       ('admin', 'back-office administrator', now());
+
+insert into role_permission (role_code, permission)
+values ('customer', 'wallet:read'),
+       ('customer', 'wallet:write'),
+       ('customer', 'profile:write'),
+       ('support', 'users:read'),
+       ('admin', 'users:read'),
+       ('admin', 'users:write');
+
+-- every existing user is a customer
+insert into user_role (user_id, role_code, created_at)
+select id, 'customer', now()
+from "user";
+
+alter table "user"
+    add column status varchar(20) default 'ACTIVE' not null;
+
+create table pin_lockout
+(
+    phone_number    varchar(25) not null
+        constraint pin_lockout_pk
+            primary key,
+    failed_attempts integer     not null,
+    locked_until    timestamp,
+    updated_at      timestamp
+);
+
+alter table pin_lockout
+    owner to postgres;
+
+create table balance_adjustment
+(
+    id                 uuid         not null
+        constraint balance_adjustment_pk
+            primary key,
+    user_id            uuid         not null
+        constraint balance_adjustment_user_id_fk
+            references "user",
+    transaction_id     uuid         not null,
+    admin_phone_number varchar(25)  not null,
+    adjustment_type    varchar(6)   not null,
+    amount             integer      not null,
+    reason             varchar(100) not null,
+    created_at         timestamp
+);
+
+alter table balance_adjustment
+    owner to postgres;
+
+create index balance_adjustment_user_id_index
+    on balance_adjustment (user_id);
+
+create table admin_audit_log
+(
+    id                 uuid        not null
+        constraint admin_audit_log_pk
+            primary key,
+    admin_phone_number varchar(25) not null,
+    action             varchar(30) not null,
+    user_id            uuid
+        constraint admin_audit_log_user_id_fk
+            references "user",
+    reason             varchar(255),
+    detail             varchar(255),
+    created_at         timestamp
+);
+
+alter table admin_audit_log
+    owner to postgres;
+
+create index admin_audit_log_user_id_index
+    on admin_audit_log (user_id);
+
+insert into ledger_account (id, code, account_type, balance, version, created_at, updated_at)
+values (gen_random_uuid(), 'SYSTEM:MANUAL_ADJUSTMENT', 'SYSTEM', 0, 1, now(), now());
+
+-- accounts frozen through the admin API are FROZEN, which blocked every operation like FROZEN_ALL
+update "user"
+set status = 'FROZEN_ALL'
+where status = 'FROZEN';
+
+create table account_status_history
+(
+    id          uuid         not null
+        constraint account_status_history_pk
+            primary key,
+    user_id     uuid         not null
+        constraint account_status_history_user_id_fk
+            references "user",
+    from_status varchar(20)  not null,
+    to_status   varchar(20)  not null,
+    reason      varchar(255),
+    changed_by  varchar(25)  not null,
+    created_at  timestamp
+);
+
+alter table account_status_history
+    owner to postgres;
+
+create index account_status_history_user_id_index
+    on account_status_history (user_id);
+
+create table pin_lockout_ip
+(
+    ip_address      varchar(45) not null
+        constraint pin_lockout_ip_pk
+            primary key,
+    failed_attempts integer     not null,
+    locked_until    timestamp,
+    updated_at      timestamp
+);
+
+alter table pin_lockout_ip
+    owner to postgres;
+
+create table pin_otp
+(
+    id                     uuid        not null
+        constraint pin_otp_pk
+            primary key,
+    phone_number           varchar(25) not null,
+    otp_hash               varchar(64) not null,
+    attempts               integer     not null,
+    expires_at             timestamp   not null,
+    reset_token_hash       varchar(64)
+        constraint pin_otp_reset_token_hash_uindex
+            unique,
+    reset_token_expires_at timestamp,
+    consumed_at            timestamp,
+    created_at             timestamp
+);
+
+alter table pin_otp
+    owner to postgres;
+
+create index pin_otp_phone_number_index
+    on pin_otp (phone_number, created_at);
+
+create table transaction_authorization
+(
+    id           uuid        not null
+        constraint transaction_authorization_pk
+            primary key,
+    phone_number varchar(25) not null,
+    token_hash   varchar(64) not null
+        constraint transaction_authorization_token_hash_uindex
+            unique,
+    operation    varchar(10) not null,
+    amount       integer     not null,
+    target       varchar(50) not null,
+    expires_at   timestamp   not null,
+    used_at      timestamp,
+    created_at   timestamp
+);
+
+alter table transaction_authorization
+    owner to postgres;
+
+-- transaction limits per KYC tier, amounts in the wallet currency
+create table kyc_tier_limit
+(
+    tier             varchar(20) not null
+        constraint kyc_tier_limit_pk
+            primary key,
+    max_balance      bigint      not null,
+    per_transaction  bigint      not null,
+    daily_outgoing   bigint      not null,
+    monthly_outgoing bigint      not null
+);
+
+alter table kyc_tier_limit
+    owner to postgres;
+
+insert into kyc_tier_limit (tier, max_balance, per_transaction, daily_outgoing, monthly_outgoing)
+values ('UNVERIFIED', 2000000, 1000000, 2000000, 10000000),
+       ('VERIFIED', 20000000, 10000000, 20000000, 100000000);
+
+alter table "user"
+    add kyc_tier varchar(20) default 'UNVERIFIED' not null
+        constraint user_kyc_tier_fk
+            references kyc_tier_limit;
+
+create table kyc_submission
+(
+    id                 uuid         not null
+        constraint kyc_submission_pk
+            primary key,
+    user_id            uuid         not null
+        constraint kyc_submission_user_id_fk
+            references "user",
+    id_number          varchar(16)  not null,
+    full_name          varchar(100) not null,
+    date_of_birth      date         not null,
+    photo_key          varchar(255) not null,
+    photo_content_type varchar(50)  not null,
+    status             varchar(20)  not null,
+    rejection_reason   varchar(255),
+    reviewed_by        varchar(25),
+    submitted_at       timestamp    not null,
+    reviewed_at        timestamp,
+    updated_at         timestamp
+);
+
+alter table kyc_submission
+    owner to postgres;
+
+create index kyc_submission_user_id_index
+    on kyc_submission (user_id);
+
+create index kyc_submission_status_index
+    on kyc_submission (status, id);
+
+-- a user has at most one submission waiting for review
+create unique index kyc_submission_user_id_pending_uindex
+    on kyc_submission (user_id)
+    where status in ('SUBMITTED', 'UNDER_REVIEW');
+
+create table beneficiary
+(
+    id                  uuid        not null
+        constraint beneficiary_pk
+            primary key,
+    user_id             uuid        not null
+        constraint beneficiary_user_id_fk
+            references "user",
+    beneficiary_user_id uuid        not null
+        constraint beneficiary_beneficiary_user_id_fk
+            references "user",
+    nickname            varchar(30) not null,
+    created_at          timestamp   not null,
+    updated_at          timestamp   not null,
+    constraint beneficiary_user_id_beneficiary_user_id_uk
+        unique (user_id, beneficiary_user_id)
+);
+
+alter table beneficiary
+    owner to postgres;
+
+create table money_request
+(
+    id                uuid        not null
+        constraint money_request_pk
+            primary key,
+    requester_user_id uuid        not null
+        constraint money_request_requester_user_id_fk
+            references "user",
+    payer_user_id     uuid        not null
+        constraint money_request_payer_user_id_fk
+            references "user",
+    amount            integer     not null,
+    remarks           varchar(50) not null,
+    status            varchar(10) not null,
+    transfer_id       uuid
+        constraint money_request_transfer_id_fk
+            references transfer,
+    expires_at        timestamp   not null,
+    responded_at      timestamp,
+    created_at        timestamp   not null,
+    updated_at        timestamp   not null
+);
+
+alter table money_request
+    owner to postgres;
+
+create index money_request_payer_user_id_index
+    on money_request (payer_user_id, id);
+
+create index money_request_requester_user_id_index
+    on money_request (requester_user_id, id);
+
+create index money_request_expiry_index
+    on money_request (expires_at)
+    where status = 'PENDING';
+
+create table split_bill
+(
+    id                uuid        not null
+        constraint split_bill_pk
+            primary key,
+    organizer_user_id uuid        not null
+        constraint split_bill_organizer_user_id_fk
+            references "user",
+    title             varchar(50) not null,
+    total_amount      integer     not null,
+    split_type        varchar(10) not null,
+    status            varchar(10) not null,
+    created_at        timestamp   not null,
+    updated_at        timestamp   not null,
+    settled_at        timestamp
+);
+
+alter table split_bill
+    owner to postgres;
+
+create index split_bill_organizer_user_id_index
+    on split_bill (organizer_user_id, id);
+
+create table split_bill_participant
+(
+    id                  uuid        not null
+        constraint split_bill_participant_pk
+            primary key,
+    split_bill_id       uuid        not null
+        constraint split_bill_participant_split_bill_id_fk
+            references split_bill,
+    user_id             uuid        not null
+        constraint split_bill_participant_user_id_fk
+            references "user",
+    share_amount        integer     not null,
+    status              varchar(10) not null,
+    transfer_id         uuid
+        constraint split_bill_participant_transfer_id_fk
+            references transfer,
+    last_failure_reason text,
+    last_reminded_at    timestamp,
+    paid_at             timestamp,
+    created_at          timestamp   not null,
+    updated_at          timestamp   not null,
+    constraint split_bill_participant_split_bill_id_user_id_uk
+        unique (split_bill_id, user_id)
+);
+
+alter table split_bill_participant
+    owner to postgres;
+
+create index split_bill_participant_user_id_index
+    on split_bill_participant (user_id);
+
+create index split_bill_participant_transfer_id_index
+    on split_bill_participant (transfer_id);
+
+-- a refund credit references the payment it pays back
+alter table transaction
+    add reference_id uuid;
+
+create index transaction_reference_id_index
+    on transaction (reference_id);
+
+create table payment_refund
+(
+    id                 uuid         not null
+        constraint payment_refund_pk
+            primary key,
+    payment_id         uuid         not null,
+    transaction_id     uuid         not null,
+    user_id            uuid         not null
+        constraint payment_refund_user_id_fk
+            references "user",
+    amount             integer      not null,
+    reason             varchar(100) not null,
+    admin_phone_number varchar(25)  not null,
+    created_at         timestamp    not null
+);
+
+alter table payment_refund
+    owner to postgres;
+
+create index payment_refund_payment_id_index
+    on payment_refund (payment_id);
+
+-- id is the transfer carrying the reversal out
+create table transfer_reversal
+(
+    id                 uuid         not null
+        constraint transfer_reversal_pk
+            primary key
+        constraint transfer_reversal_id_fk
+            references transfer,
+    transfer_id        uuid         not null
+        constraint transfer_reversal_transfer_id_fk
+            references transfer,
+    amount             integer      not null,
+    reason             varchar(100) not null,
+    admin_phone_number varchar(25)  not null,
+    created_at         timestamp    not null
+);
+
+alter table transfer_reversal
+    owner to postgres;
+
+create index transfer_reversal_transfer_id_index
+    on transfer_reversal (transfer_id);
+
+-- reserved by active holds, balance - held_balance is what can be spent
+alter table "user"
+    add held_balance integer default 0 not null;
+
+create table hold
+(
+    id              uuid        not null
+        constraint hold_pk
+            primary key,
+    user_id         uuid        not null
+        constraint hold_user_id_fk
+            references "user",
+    amount          integer     not null,
+    captured_amount integer     default 0 not null,
+    remarks         varchar(50) not null,
+    status          varchar(20) not null,
+    transaction_id  uuid,
+    expires_at      timestamp   not null,
+    created_at      timestamp   not null,
+    updated_at      timestamp   not null
+);
+
+alter table hold
+    owner to postgres;
+
+create index hold_user_id_index
+    on hold (user_id);
+
+create index hold_status_expires_at_index
+    on hold (status, expires_at);
+
+-- a relay claims messages before publishing them, see RelayOutbox
+alter table outbox
+    add claimed_until timestamp;
+
+-- a merchant reference can be paid once per wallet and is the step-up target of a payment
+create table payment_reference
+(
+    user_id        uuid        not null
+        constraint payment_reference_user_id_fk
+            references "user",
+    reference      varchar(50) not null,
+    transaction_id uuid        not null,
+    created_at     timestamp,
+    constraint payment_reference_pk
+        primary key (user_id, reference)
+);
+
+alter table payment_reference
+    owner to postgres;